@@ -3,9 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/makeworld-the-better-one/amfora/bookmarks"
-	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/makeworld-the-better-one/amfora/display"
 	"github.com/makeworld-the-better-one/amfora/subscriptions"
@@ -45,7 +45,6 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
 		os.Exit(1)
 	}
-	client.Init()
 
 	err = subscriptions.Init()
 	if err != nil {
@@ -65,7 +64,23 @@ func main() {
 
 	// Initialize Amfora's settings
 	display.Init(version, commit, builtBy)
-	display.NewTab()
+	restored, err := display.RestoreSession()
+	if len(os.Args[1:]) > 0 {
+		// Open the URL in a new tab instead of replacing a restored one
+		display.NewTab()
+	} else if !restored {
+		display.OpenNewTab()
+	}
+	if err != nil {
+		display.Error("Session Error", err.Error())
+	}
+	if len(config.KeyWarnings) > 0 {
+		display.Error("Keybinding Warning", "Some keybindings in the config couldn't be used:\n\n"+
+			strings.Join(config.KeyWarnings, "\n"))
+	}
+	if err := display.StartRemote(); err != nil {
+		display.Error("Remote Control Error", err.Error())
+	}
 	if len(os.Args[1:]) > 0 {
 		display.URL(os.Args[1])
 	}
@@ -148,17 +148,52 @@ func Get(url string) (string, bool) {
 	return "", false
 }
 
+// Remove removes the bookmark at the provided URL.
+// The order of the other bookmarks is kept.
 func Remove(url string) {
 	for i, bkmk := range data.Bookmarks {
 		if bkmk.URL == url {
-			data.Bookmarks[i] = data.Bookmarks[len(data.Bookmarks)-1]
-			data.Bookmarks = data.Bookmarks[:len(data.Bookmarks)-1]
+			data.Bookmarks = append(data.Bookmarks[:i], data.Bookmarks[i+1:]...)
 			writeXbel() //nolint:errcheck
 			return
 		}
 	}
 }
 
+// Move moves the bookmark at the provided URL up or down in the order,
+// by swapping it with the bookmark before it, or after it if down is true.
+// It returns false if the bookmark doesn't exist or can't move further.
+func Move(url string, down bool) bool {
+	for i, bkmk := range data.Bookmarks {
+		if bkmk.URL != url {
+			continue
+		}
+		j := i - 1
+		if down {
+			j = i + 1
+		}
+		if j < 0 || j >= len(data.Bookmarks) {
+			return false
+		}
+		data.Bookmarks[i], data.Bookmarks[j] = data.Bookmarks[j], data.Bookmarks[i]
+		writeXbel() //nolint:errcheck
+		return true
+	}
+	return false
+}
+
+// List returns the URLs and names of all the bookmarks, in the order
+// they are stored in. Each index of the slices is one bookmark.
+func List() ([]string, []string) {
+	urls := make([]string, len(data.Bookmarks))
+	names := make([]string, len(data.Bookmarks))
+	for i, bkmk := range data.Bookmarks {
+		urls[i] = bkmk.URL
+		names[i] = bkmk.Name
+	}
+	return urls, names
+}
+
 // All returns all the bookmarks in a map of URLs to names.
 // It also returns a slice of map keys, sorted so that the map *values*
 // are in alphabetical order, with case ignored.
@@ -0,0 +1,47 @@
+package bookmarks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func setBookmarks(urls ...string) {
+	data.Bookmarks = make([]*xbelBookmark, 0, len(urls))
+	for _, u := range urls {
+		data.Bookmarks = append(data.Bookmarks, &xbelBookmark{URL: u, Name: u})
+	}
+}
+
+func TestMove(t *testing.T) {
+	setBookmarks("a", "b", "c")
+
+	if !Move("c", false) {
+		t.Error("Move(c, up) = false, want true")
+	}
+	if urls, _ := List(); !reflect.DeepEqual(urls, []string{"a", "c", "b"}) {
+		t.Errorf("order after moving c up = %q", urls)
+	}
+	if Move("a", false) {
+		t.Error("Move(a, up) = true for the first bookmark, want false")
+	}
+	if Move("b", true) {
+		t.Error("Move(b, down) = true for the last bookmark, want false")
+	}
+	if Move("x", true) {
+		t.Error("Move(x, down) = true for a missing bookmark, want false")
+	}
+	if !Move("a", true) {
+		t.Error("Move(a, down) = false, want true")
+	}
+	if urls, _ := List(); !reflect.DeepEqual(urls, []string{"c", "a", "b"}) {
+		t.Errorf("order after moving a down = %q", urls)
+	}
+}
+
+func TestRemoveKeepsOrder(t *testing.T) {
+	setBookmarks("a", "b", "c", "d")
+	Remove("b")
+	if urls, _ := List(); !reflect.DeepEqual(urls, []string{"a", "c", "d"}) {
+		t.Errorf("order after removing b = %q", urls)
+	}
+}
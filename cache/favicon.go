@@ -21,11 +21,14 @@ func AddFavicon(host, emoji string) {
 	favMu.Unlock()
 }
 
-// ClearFavicons removes all favicons from the cache
-func ClearFavicons() {
+// ClearFavicons removes all favicons from the cache, and returns how many
+// there were.
+func ClearFavicons() int {
 	favMu.Lock()
+	defer favMu.Unlock()
+	n := len(favicons)
 	favicons = make(map[string]string)
-	favMu.Unlock()
+	return n
 }
 
 // GetFavicon returns the favicon string for the host.
@@ -9,13 +9,51 @@ import (
 	"github.com/makeworld-the-better-one/amfora/structs"
 )
 
-var pages = make(map[string]*structs.Page) // The actual cache
-var urls = make([]string, 0)               // Duplicate of the keys in the `pages` map, but in order of being added
+var pages = make(map[string]*structs.Page) // The actual cache, by the key of each page URL
+var urls = make([]string, 0)               // Duplicate of the keys in the `pages` map, least recently used first
+var keyFunc func(url string) string        // Turns URLs into the keys of pages, see SetKeyFunc
 var maxPages = 0                           // Max allowed number of pages in cache
 var maxSize = 0                            // Max allowed cache size in bytes
 var lock = sync.RWMutex{}
 var timeout = time.Duration(0)
 
+// Cache stores pages by their URL. Pages is the one used by Amfora, but
+// code that takes a Cache can be given another one, like in tests.
+type Cache interface {
+	Get(url string) (*structs.Page, bool) // Like GetPage
+	Set(p *structs.Page)                  // Like AddPage
+	Remove(url string)                    // Like RemovePage
+	Size() int                            // Like SizePages
+}
+
+// Pages is the Cache of the functions of this package, like GetPage and AddPage.
+var Pages Cache = pageCache{}
+
+type pageCache struct{}
+
+func (pageCache) Get(url string) (*structs.Page, bool) { return GetPage(url) }
+func (pageCache) Set(p *structs.Page)                  { AddPage(p) }
+func (pageCache) Remove(url string)                    { RemovePage(url) }
+func (pageCache) Size() int                            { return SizePages() }
+
+// SetKeyFunc sets the func that turns URLs into the keys pages are cached by,
+// so URLs that point to the same page can share an entry. Pages keep their URL,
+// which is what URLs returns. A nil func uses URLs as they are, which is the default.
+// It should be set before any pages are added.
+func SetKeyFunc(f func(url string) string) {
+	lock.Lock()
+	defer lock.Unlock()
+	keyFunc = f
+}
+
+// key returns the key a page with the URL is cached by.
+func key(url string) string {
+	if keyFunc == nil {
+		return url
+	}
+	return keyFunc(url)
+}
+
 // SetMaxPages sets the max number of pages the cache can hold.
 // A value <= 0 means infinite pages.
 func SetMaxPages(max int) {
@@ -29,7 +67,7 @@ func SetMaxSize(max int) {
 }
 
 // SetTimeout sets the max number of a seconds a page can still
-// be valid for. A value <= 0 means forever.
+// be valid for, after it was made. A value <= 0 means forever.
 func SetTimeout(t int) {
 	if t <= 0 {
 		timeout = time.Duration(0)
@@ -38,25 +76,59 @@ func SetTimeout(t int) {
 	timeout = time.Duration(t) * time.Second
 }
 
-func removeIndex(s []string, i int) []string {
-	s[len(s)-1], s[i] = s[i], s[len(s)-1]
-	return s[:len(s)-1]
+// MaxPages returns the max number of pages the cache can hold.
+func MaxPages() int {
+	return maxPages
+}
+
+// MaxSize returns the max size the page cache can be, in bytes.
+func MaxSize() int {
+	return maxSize
 }
 
+// removeURL removes the URL from urls, keeping the order of the others.
 func removeURL(url string) {
 	for i := range urls {
 		if urls[i] == url {
-			urls = removeIndex(urls, i)
+			urls = append(urls[:i], urls[i+1:]...)
 			return
 		}
 	}
 }
 
-// AddPage adds a page to the cache, removing earlier pages as needed
-// to keep the cache inside its limits.
+// sizePages is SizePages, for when the lock is already held.
+func sizePages() int {
+	n := 0
+	for _, page := range pages {
+		n += page.Size()
+	}
+	return n
+}
+
+// evict removes the least recently used page from the cache.
+// Pages with a zero MadeAt are never removed.
+// It returns false if there was no page that could be removed.
+//
+// The lock must be held when calling it.
+func evict() bool {
+	for _, url := range urls {
+		if pages[url].MadeAt.IsZero() {
+			continue
+		}
+		delete(pages, url)
+		removeURL(url)
+		return true
+	}
+	return false
+}
+
+// AddPage adds a page to the cache, removing the least recently used
+// pages as needed to keep the cache inside its limits.
+// Pages with a zero MadeAt are never removed to make room.
 //
-// If your page is larger than the max cache size, the provided page
-// will silently not be added to the cache.
+// If your page is larger than the max cache size, or there isn't
+// room for it after removing pages, the provided page will silently
+// not be added to the cache, and the page cached for the URL is kept.
 func AddPage(p *structs.Page) {
 	if p.URL == "" {
 		// Just in case, these pages shouldn't be cached
@@ -68,23 +140,41 @@ func AddPage(p *structs.Page) {
 		return
 	}
 
+	lock.Lock()
+	defer lock.Unlock()
+	k := key(p.URL)
+
+	// Remove the old version of the page, so it doesn't count against the limits.
+	// It's put back if there isn't room for the new one.
+	old, hadOld := pages[k]
+	delete(pages, k)
+	removeURL(k)
+	keepOld := func() {
+		if hadOld {
+			pages[k] = old
+			urls = append(urls, k)
+		}
+	}
+
 	// Remove earlier pages to make room for this one
 	// There should only ever be 1 page to remove at most,
 	// but this handles more just in case.
-	for NumPages() >= maxPages && maxPages > 0 {
-		RemovePage(urls[0])
+	for len(pages) >= maxPages && maxPages > 0 {
+		if !evict() {
+			keepOld()
+			return
+		}
 	}
 	// Do the same but for cache size
-	for SizePages()+p.Size() > maxSize && maxSize > 0 {
-		RemovePage(urls[0])
+	for sizePages()+p.Size() > maxSize && maxSize > 0 {
+		if !evict() {
+			keepOld()
+			return
+		}
 	}
 
-	lock.Lock()
-	defer lock.Unlock()
-	pages[p.URL] = p
-	// Remove the URL if it was already there, then add it to the end
-	removeURL(p.URL)
-	urls = append(urls, p.URL)
+	pages[k] = p
+	urls = append(urls, k)
 }
 
 // RemovePage will remove a page from the cache.
@@ -92,27 +182,26 @@ func AddPage(p *structs.Page) {
 func RemovePage(url string) {
 	lock.Lock()
 	defer lock.Unlock()
+	url = key(url)
 	delete(pages, url)
 	removeURL(url)
 }
 
-// ClearPages removes all pages from the cache.
-func ClearPages() {
+// ClearPages removes all pages from the cache, and returns how many there were.
+func ClearPages() int {
 	lock.Lock()
 	defer lock.Unlock()
+	n := len(pages)
 	pages = make(map[string]*structs.Page)
 	urls = make([]string, 0)
+	return n
 }
 
 // SizePages returns the approx. current size of the cache in bytes.
 func SizePages() int {
 	lock.RLock()
 	defer lock.RUnlock()
-	n := 0
-	for _, page := range pages {
-		n += page.Size()
-	}
-	return n
+	return sizePages()
 }
 
 func NumPages() int {
@@ -121,15 +210,58 @@ func NumPages() int {
 	return len(pages)
 }
 
+// Expired returns true if the page is older than the timeout,
+// and so should be fetched again instead of used.
+// Pages with a zero MadeAt never expire.
+func Expired(p *structs.Page) bool {
+	return timeout != 0 && !p.MadeAt.IsZero() && time.Since(p.MadeAt) >= timeout
+}
+
 // GetPage returns the page struct, and a bool indicating if the page was in the cache or not.
-// (nil, false) is returned if the page isn't in the cache.
+// (nil, false) is returned if the page isn't in the cache, or it has expired.
+// Expired pages are removed, and the page becomes the most recently used one otherwise.
 func GetPage(url string) (*structs.Page, bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	url = key(url)
+
+	p, ok := pages[url]
+	if !ok {
+		return nil, false
+	}
+	removeURL(url)
+	if Expired(p) {
+		delete(pages, url)
+		return nil, false
+	}
+	urls = append(urls, url)
+	return p, ok
+}
+
+// URLs returns the URLs of all the pages in the cache,
+// most recently used first. They're the URLs of the pages, not their keys.
+func URLs() []string {
 	lock.RLock()
 	defer lock.RUnlock()
 
-	p, ok := pages[url]
-	if ok && (timeout == 0 || time.Since(p.MadeAt) < timeout) {
-		return p, ok
+	ret := make([]string, len(urls))
+	for i := range urls {
+		ret[i] = pages[urls[len(urls)-1-i]].URL
+	}
+	return ret
+}
+
+// NumForever returns the number of pages in the cache with a zero MadeAt,
+// which are never removed to make room.
+func NumForever() int {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	n := 0
+	for _, page := range pages {
+		if page.MadeAt.IsZero() {
+			n++
+		}
 	}
-	return nil, false
+	return n
 }
@@ -1,19 +1,23 @@
 package cache
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/stretchr/testify/assert"
 )
 
-var p = structs.Page{URL: "example.com"}
-var p2 = structs.Page{URL: "example.org"}
+var p = structs.Page{URL: "example.com", MadeAt: time.Now()}
+var p2 = structs.Page{URL: "example.org", MadeAt: time.Now()}
+var p3 = structs.Page{URL: "example.net", MadeAt: time.Now()}
 
 func reset() {
 	ClearPages()
 	SetMaxPages(0)
 	SetMaxSize(0)
+	SetTimeout(0)
 }
 
 func TestMaxPages(t *testing.T) {
@@ -45,7 +49,8 @@ func TestRemove(t *testing.T) {
 func TestClearAndNumPages(t *testing.T) {
 	reset()
 	AddPage(&p)
-	ClearPages()
+	AddPage(&p2)
+	assert.Equal(t, 2, ClearPages(), "ClearPages should report the two pages removed")
 	assert.Equal(t, 0, len(pages), "map should be empty")
 	assert.Equal(t, 0, len(urls), "urls slice shoulde be empty")
 	assert.Equal(t, 0, NumPages(), "NumPages should report empty too")
@@ -69,3 +74,101 @@ func TestGet(t *testing.T) {
 		t.Error("page urls don't match")
 	}
 }
+
+func TestLeastRecentlyUsed(t *testing.T) {
+	reset()
+	SetMaxPages(2)
+	AddPage(&p)
+	AddPage(&p2)
+	GetPage(p.URL)
+	AddPage(&p3)
+	_, ok := GetPage(p.URL)
+	assert.True(t, ok, "the page that was used should still be there")
+	_, ok = GetPage(p2.URL)
+	assert.False(t, ok, "the least recently used page should be removed")
+	assert.Equal(t, []string{p.URL, p3.URL}, URLs(), "urls should be most recently used first")
+}
+
+func TestForeverPages(t *testing.T) {
+	reset()
+	forever := structs.Page{URL: "about:forever"}
+	SetMaxPages(2)
+	AddPage(&forever)
+	AddPage(&p)
+	AddPage(&p2)
+	_, ok := GetPage(forever.URL)
+	assert.True(t, ok, "pages with a zero MadeAt should never be removed")
+	assert.Equal(t, 2, NumPages(), "the other page should have been removed instead")
+	assert.Equal(t, 1, NumForever())
+
+	SetMaxPages(1)
+	AddPage(&p3)
+	_, ok = GetPage(p3.URL)
+	assert.False(t, ok, "pages can't be added when only forever pages are left")
+}
+
+func TestForeverPagesKeepOld(t *testing.T) {
+	reset()
+	forever := structs.Page{URL: "about:forever"}
+	small := structs.Page{URL: "example.com", MadeAt: time.Now()}
+	big := structs.Page{URL: "example.com", MadeAt: time.Now(), Raw: strings.Repeat("a", 60)}
+	// Room for the big page, but not with the forever one
+	SetMaxSize(forever.Size() + small.Size() + 50)
+	AddPage(&forever)
+	AddPage(&small)
+	AddPage(&big)
+	page, ok := GetPage(small.URL)
+	assert.True(t, ok, "the old page should be kept when there isn't room for the new one")
+	assert.Equal(t, &small, page)
+	assert.Equal(t, 2, NumPages())
+}
+
+func TestExpiry(t *testing.T) {
+	reset()
+	SetTimeout(60)
+	old := structs.Page{URL: "example.com/old", MadeAt: time.Now().Add(-2 * time.Minute)}
+	forever := structs.Page{URL: "example.com/forever"}
+	AddPage(&old)
+	AddPage(&forever)
+	AddPage(&p)
+
+	_, ok := GetPage(old.URL)
+	assert.False(t, ok, "pages older than the timeout should not be returned")
+	assert.Equal(t, 2, NumPages(), "expired pages should be removed")
+	_, ok = GetPage(forever.URL)
+	assert.True(t, ok, "pages with a zero MadeAt should never expire")
+	_, ok = GetPage(p.URL)
+	assert.True(t, ok, "new pages should be returned")
+}
+
+func TestPagesCache(t *testing.T) {
+	reset()
+	assert := assert.New(t)
+	Pages.Set(&p)
+	got, ok := GetPage(p.URL)
+	assert.True(ok, "Set should add the page to the package cache")
+	assert.Equal(&p, got)
+	got, ok = Pages.Get(p.URL)
+	assert.True(ok, "Get should find the page")
+	assert.Equal(&p, got)
+	assert.Equal(SizePages(), Pages.Size(), "Size should match SizePages")
+	Pages.Remove(p.URL)
+	assert.Equal(0, NumPages(), "Remove should remove the page")
+}
+
+func TestKeyFunc(t *testing.T) {
+	reset()
+	SetKeyFunc(strings.ToLower)
+	defer SetKeyFunc(nil)
+	assert := assert.New(t)
+
+	mixed := structs.Page{URL: "gemini://Example.com/Page", MadeAt: time.Now()}
+	AddPage(&mixed)
+	got, ok := GetPage("gemini://example.com/page")
+	assert.True(ok, "a URL with the same key should get the page")
+	assert.Equal(&mixed, got)
+	assert.Equal([]string{"gemini://Example.com/Page"}, URLs(), "the URL of the page should be kept")
+
+	RemovePage("GEMINI://EXAMPLE.COM/PAGE")
+	assert.Equal(0, NumPages(), "a URL with the same key should remove the page")
+}
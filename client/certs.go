@@ -0,0 +1,200 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// Client certificates (identities) chosen by the user for each host,
+// and generating new ones.
+//
+// Identities set in the config under [auth] always take priority over this store.
+
+var ErrInvalidIdentityName = errors.New("identity name is empty or contains a path separator")
+var ErrIdentityExists = errors.New("an identity with that name already exists")
+
+var certStore = config.CertStore
+
+// certStoreMu protects certStore, since viper is not thread-safe.
+var certStoreMu = sync.RWMutex{}
+
+// Identity is a client certificate and its key, stored as file paths.
+type Identity struct {
+	Name string // Filename of the cert without the extension
+	Cert string
+	Key  string
+}
+
+// hostKey returns the viper key for a host, like idKey in tofu.go.
+func hostKey(host string) string {
+	return strings.ReplaceAll(strings.ToLower(host), ".", "/")
+}
+
+// newIdentity returns the Identity for the provided cert and key paths.
+func newIdentity(certPath, keyPath string) Identity {
+	return Identity{
+		Name: strings.TrimSuffix(filepath.Base(certPath), filepath.Ext(certPath)),
+		Cert: certPath,
+		Key:  keyPath,
+	}
+}
+
+// storedIdentity returns the identity stored for the host, and false
+// if there isn't one.
+func storedIdentity(host string) (Identity, bool) {
+	certStoreMu.RLock()
+	defer certStoreMu.RUnlock()
+
+	certPath := certStore.GetString(hostKey(host) + ".cert")
+	keyPath := certStore.GetString(hostKey(host) + ".key")
+	if certPath == "" || keyPath == "" {
+		return Identity{}, false
+	}
+	return newIdentity(certPath, keyPath), true
+}
+
+// CertInConfig returns whether a client certificate for the host is set
+// in the config, in which case identities chosen for the host aren't used.
+func CertInConfig(host string) bool {
+	return viper.GetString("auth.certs."+host) != "" || viper.GetString("auth.keys."+host) != ""
+}
+
+// SetIdentity remembers the identity to use for the host, and saves it to disk.
+// The host should include the port if it's not the default.
+func SetIdentity(host string, id Identity) error {
+	certStoreMu.Lock()
+	certStore.Set(hostKey(host)+".cert", id.Cert)
+	certStore.Set(hostKey(host)+".key", id.Key)
+	err := certStore.WriteConfig()
+	certStoreMu.Unlock()
+
+	// Force the cert to be read again
+	certCacheMu.Lock()
+	delete(certCache, host)
+	certCacheMu.Unlock()
+
+	return err
+}
+
+// HostIdentities returns the identities remembered for each host, and a sorted
+// slice of the hosts.
+func HostIdentities() (map[string]Identity, []string) {
+	certStoreMu.RLock()
+	defer certStoreMu.RUnlock()
+
+	ids := make(map[string]Identity)
+	hosts := make([]string, 0)
+	for key, v := range certStore.AllSettings() {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		certPath, _ := m["cert"].(string)
+		keyPath, _ := m["key"].(string)
+		if certPath == "" || keyPath == "" {
+			continue
+		}
+		host := strings.ReplaceAll(key, "/", ".")
+		ids[host] = newIdentity(certPath, keyPath)
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return ids, hosts
+}
+
+// Identities returns every identity that can be chosen, sorted by name.
+// These are the generated identities, along with any others used for a host.
+func Identities() []Identity {
+	ids := make([]Identity, 0)
+	seen := make(map[string]bool)
+
+	certs, _ := filepath.Glob(filepath.Join(config.IdentitiesDir, "*.crt"))
+	for _, certPath := range certs {
+		keyPath := strings.TrimSuffix(certPath, ".crt") + ".key"
+		if _, err := os.Stat(keyPath); err != nil {
+			continue
+		}
+		ids = append(ids, newIdentity(certPath, keyPath))
+		seen[certPath] = true
+	}
+
+	hostIDs, _ := HostIdentities()
+	for _, id := range hostIDs {
+		if !seen[id.Cert] {
+			ids = append(ids, id)
+			seen[id.Cert] = true
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Name < ids[j].Name })
+	return ids
+}
+
+// NewIdentity generates a self-signed client certificate with the provided
+// name as the common name, and stores it in the identities directory.
+func NewIdentity(name string) (Identity, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return Identity{}, ErrInvalidIdentityName
+	}
+	id := Identity{
+		Name: name,
+		Cert: filepath.Join(config.IdentitiesDir, name+".crt"),
+		Key:  filepath.Join(config.IdentitiesDir, name+".key"),
+	}
+	if _, err := os.Stat(id.Cert); err == nil {
+		return Identity{}, ErrIdentityExists
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Identity{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return Identity{}, err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(100, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return Identity{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	err = ioutil.WriteFile(id.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		return Identity{}, err
+	}
+	err = ioutil.WriteFile(id.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600)
+	if err != nil {
+		os.Remove(id.Key) //nolint:errcheck
+		return Identity{}, err
+	}
+	return id, nil
+}
@@ -2,13 +2,11 @@
 package client
 
 import (
+	"context"
 	"io/ioutil"
-	"net"
 	"net/url"
 	"sync"
-	"time"
 
-	"github.com/makeworld-the-better-one/go-gemini"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
@@ -16,17 +14,8 @@ import (
 var (
 	certCache   = make(map[string][][]byte)
 	certCacheMu = &sync.RWMutex{}
-
-	fetchClient *gemini.Client
 )
 
-func Init() {
-	fetchClient = &gemini.Client{
-		ConnectTimeout: 10 * time.Second, // Default is 15
-		ReadTimeout:    time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second,
-	}
-}
-
 func clientCert(host string) ([]byte, []byte) {
 	certCacheMu.RLock()
 	pair, ok := certCache[host]
@@ -44,6 +33,13 @@ func clientCert(host string) ([]byte, []byte) {
 	if err != nil {
 		keyPath = viper.GetString("auth.keys." + host)
 	}
+	if certPath == "" && keyPath == "" {
+		// Not in the config, use the identity chosen for the host if there is one
+		if id, ok := storedIdentity(host); ok {
+			certPath = id.Cert
+			keyPath = id.Key
+		}
+	}
 	if certPath == "" && keyPath == "" {
 		certCacheMu.Lock()
 		certCache[host] = [][]byte{nil, nil}
@@ -78,60 +74,42 @@ func HasClientCert(host string) bool {
 	return cert != nil
 }
 
-func fetch(u string, c *gemini.Client) (*gemini.Response, error) {
-	parsed, _ := url.Parse(u)
-	cert, key := clientCert(parsed.Host)
-
-	var res *gemini.Response
-	var err error
-	if cert != nil {
-		res, err = c.FetchWithCert(u, cert, key)
-	} else {
-		res, err = c.Fetch(u)
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	ok := handleTofu(parsed.Hostname(), parsed.Port(), res.Cert)
-	if !ok {
-		return res, ErrTofu
-	}
-
-	return res, err
-}
-
 // Fetch returns response data and an error.
+// A Gemini proxy set for the host in the config is used, if there is one.
 // The error text is human friendly and should be displayed.
-func Fetch(u string) (*gemini.Response, error) {
-	return fetch(u, fetchClient)
+func Fetch(u string) (*Response, error) {
+	return FetchContext(context.Background(), u)
 }
 
-func fetchWithProxy(proxyHostname, proxyPort, u string, c *gemini.Client) (*gemini.Response, error) {
-	parsed, _ := url.Parse(u)
-	cert, key := clientCert(parsed.Host)
-
-	var res *gemini.Response
-	var err error
-	if cert != nil {
-		res, err = c.FetchWithHostAndCert(net.JoinHostPort(proxyHostname, proxyPort), u, cert, key)
-	} else {
-		res, err = c.FetchWithHost(net.JoinHostPort(proxyHostname, proxyPort), u)
+// fetchURL makes the request for FetchContext, to the Gemini proxy set for
+// the host if there is one.
+func fetchURL(ctx context.Context, u string) (*Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
 	}
+	proxyHostname, proxyPort, ok, err := geminiProxyForHost(parsed.Hostname())
 	if err != nil {
 		return nil, err
 	}
-
-	// Only associate the returned cert with the proxy
-	ok := handleTofu(proxyHostname, proxyPort, res.Cert)
-	if !ok {
-		return res, ErrTofu
+	if ok {
+		return proxyRequest(ctx, proxyHostname, proxyPort, u)
 	}
+	return request(ctx, parsed.Hostname(), parsed.Port(), u)
+}
 
-	return res, nil
+// proxyRequest is request through the Gemini proxy at hostname and port,
+// which is kept in the response.
+func proxyRequest(ctx context.Context, proxyHostname, proxyPort, u string) (*Response, error) {
+	res, err := request(ctx, proxyHostname, proxyPort, u)
+	if res != nil {
+		res.ProxyHostname = proxyHostname
+		res.ProxyPort = proxyPort
+	}
+	return res, err
 }
 
 // FetchWithProxy is the same as Fetch, but uses a proxy.
-func FetchWithProxy(proxyHostname, proxyPort, u string) (*gemini.Response, error) {
-	return fetchWithProxy(proxyHostname, proxyPort, u, fetchClient)
+func FetchWithProxy(proxyHostname, proxyPort, u string) (*Response, error) {
+	return FetchWithProxyContext(context.Background(), proxyHostname, proxyPort, u)
 }
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net"
+)
+
+// Requests can be stopped with a context. Connections are dialed with it, and
+// closed if it's done before the response header has been read, which stops
+// the TLS handshake or the wait for the server wherever they are. The context
+// doesn't affect the response body, which can be closed to stop reading it.
+
+// closeOnDone closes the connection if the context is done before the
+// returned func is called. Once that returns, the connection won't be closed.
+func closeOnDone(ctx context.Context, conn net.Conn) func() {
+	if ctx.Done() == nil {
+		// Can't be cancelled
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// doneResponse returns ctx.Err() if the context is done, and closes the
+// response, since it might have been cut off. Otherwise it returns res and err.
+func doneResponse(ctx context.Context, res *Response, err error) (*Response, error) {
+	if ctx.Err() == nil {
+		return res, err
+	}
+	if res != nil && res.Body != nil {
+		res.Body.Close()
+	}
+	return nil, ctx.Err()
+}
+
+// FetchContext is the same as Fetch, but the request is stopped if the
+// context is done before the response header has been read. ctx.Err() is
+// returned for stopped requests.
+func FetchContext(ctx context.Context, u string) (*Response, error) {
+	res, err := fetchURL(ctx, u)
+	return doneResponse(ctx, res, err)
+}
+
+// FetchWithProxyContext is the same as FetchWithProxy, but the request is
+// stopped like it is by FetchContext.
+func FetchWithProxyContext(ctx context.Context, proxyHostname, proxyPort, u string) (*Response, error) {
+	res, err := proxyRequest(ctx, proxyHostname, proxyPort, u)
+	return doneResponse(ctx, res, err)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFetchContextStops(t *testing.T) {
+	// A server that accepts connections but never does the TLS handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		conn := <-accepted
+		defer conn.Close()
+		cancel()
+		// Wait for the client to close it
+		conn.Read(make([]byte, 1024)) //nolint:errcheck
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := FetchContext(ctx, "gemini://"+ln.Addr().String()+"/")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("FetchContext returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FetchContext didn't stop when the context was cancelled")
+	}
+}
+
+func TestCloseOnDone(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := closeOnDone(ctx, a)
+	cancel()
+	if _, err := a.Read(make([]byte, 1)); err == nil {
+		t.Error("Read succeeded after the context was cancelled")
+	}
+	stop()
+
+	// Not closed once it's stopped
+	c, d := net.Pipe()
+	defer c.Close()
+	defer d.Close()
+	ctx, cancel = context.WithCancel(context.Background())
+	closeOnDone(ctx, c)()
+	cancel()
+	go d.Write([]byte{1}) //nolint:errcheck
+	if _, err := c.Read(make([]byte, 1)); err != nil {
+		t.Errorf("Read after stopping = %v, want no error", err)
+	}
+}
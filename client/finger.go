@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Finger is supported by converting responses to look like Gemini ones.
+// See RFC 1288 for details on the protocol. Both finger://user@host and
+// finger://host/user URLs are supported. The status of responses is always
+// 20, and the meta is always text/plain.
+
+// FingerUser returns the user to ask the server about for a finger:// URL.
+// It's empty if the URL is for the server itself, which lists its users.
+func FingerUser(parsed *url.URL) string {
+	if parsed.User != nil {
+		return parsed.User.Username()
+	}
+	return strings.TrimPrefix(parsed.Path, "/")
+}
+
+// FetchFinger makes a request to a finger:// URL.
+// The request is stopped if the context is done before the response starts,
+// and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func FetchFinger(ctx context.Context, u string) (*Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "79")
+	}
+
+	conn, br, err := fetchPlain(ctx, "finger", host, FingerUser(parsed)+"\r\n")
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(conn, 20, "text/plain", br), nil
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/go-gemini"
+	"golang.org/x/net/idna"
+)
+
+// Gemini requests and Titan uploads are made here instead of by go-gemini,
+// which makes its own TLS config. That way the TLS session cache can be set,
+// see tlssession.go, and the connection is kept for the read timeout of the
+// response. The server cert is checked like go-gemini did, before TOFU.
+
+var ErrHeader = errors.New("invalid response header")
+
+// certNameMatches returns true if the hostname matches the name from a cert,
+// which can start with a "*." wildcard for one label.
+func certNameMatches(name, hostname string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if strings.HasPrefix(name, "*.") {
+		i := strings.IndexByte(hostname, '.')
+		return i > 0 && hostname[i:] == name[1:]
+	}
+	return name == hostname
+}
+
+// verifyCert returns an error if the server cert isn't for the hostname, or
+// isn't valid at this time. Certs without any SANs are checked against their
+// common name, since many Gemini servers use them.
+func verifyCert(cert *x509.Certificate, hostname string) error {
+	if err := cert.VerifyHostname(hostname); err != nil {
+		if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 ||
+			!certNameMatches(cert.Subject.CommonName, hostname) {
+			return fmt.Errorf("hostname does not verify: %w", err)
+		}
+	}
+	if cert.NotBefore.After(time.Now()) {
+		return errors.New("server cert is for the future") //nolint:goerr113
+	}
+	if cert.NotAfter.Before(time.Now()) {
+		return errors.New("server cert is expired") //nolint:goerr113
+	}
+	return nil
+}
+
+// tlsConnect connects to the server at hostname and port for the scheme,
+// Gemini or Titan, and does the TLS handshake with the client cert, if it
+// isn't nil. Connections without a client cert resume TLS sessions, since a
+// resumed session would keep the identity it started with. The handshake is
+// part of the dial timeout. The server has to follow the TLS policy, and its
+// cert is checked by verifyCert, but not TOFU. The connection is closed if
+// the context is done during the handshake.
+//
+// The error text is human friendly and should be displayed.
+func tlsConnect(ctx context.Context, scheme, hostname, port string, cert, key []byte) (*tls.Conn, error) {
+	asciiHostname, err := idna.ToASCII(hostname)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname: %w", err)
+	}
+	conf := &tls.Config{
+		InsecureSkipVerify: true, // TOFU is used instead
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         asciiHostname,
+	}
+	if cert != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{pair}
+	} else {
+		conf.ClientSessionCache = sessionCache()
+	}
+
+	timeout := DialTimeout(scheme)
+	rawConn, err := dialGemini(ctx, &net.Dialer{Timeout: timeout}, net.JoinHostPort(asciiHostname, port))
+	if ctx.Err() != nil {
+		if err == nil {
+			rawConn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the server: %w", err)
+	}
+	conn := tls.Client(rawConn, conf)
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	}
+	stopWatching := closeOnDone(ctx, conn)
+	err = handshake(conn)
+	stopWatching()
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if policyErr := policyError(rawConn); policyErr != nil {
+			// The handshake error doesn't say why the connection was closed
+			return nil, policyErr
+		}
+		return nil, fmt.Errorf("failed to connect to the server: %w", err)
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck
+
+	state := conn.ConnectionState()
+	if err := checkTLSPolicy(state.Version, state.CipherSuite); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := verifyCert(state.PeerCertificates[0], asciiHostname); err != nil {
+		if hostname == asciiHostname || verifyCert(state.PeerCertificates[0], hostname) != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// readHeader reads the header of a Gemini or Titan response, and returns its
+// status and meta. ErrHeader is returned if it isn't a valid header.
+func readHeader(br *bufio.Reader) (int, string, error) {
+	line, err := br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return 0, "", ErrHeader
+	}
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return 0, "", fmt.Errorf("failed to read the response header: %w", err)
+	}
+	header := strings.TrimRight(string(line), "\r\n")
+	if len(header) < 2 || header[0] < '1' || header[0] > '6' || header[1] < '0' || header[1] > '9' ||
+		(len(header) > 2 && header[2] != ' ') {
+		return 0, "", ErrHeader
+	}
+	status, _ := strconv.Atoi(header[:2])
+	meta := ""
+	if len(header) > 3 {
+		meta = header[3:]
+	}
+	if len(meta) > gemini.MetaMaxLength {
+		return 0, "", ErrHeader
+	}
+	return status, meta, nil
+}
+
+// request sends the request for the Gemini URL to the server at hostname and
+// port, which is the server for the URL, or a Gemini proxy for it. The response
+// is returned once its header has been read, and the rest of it can take up to
+// page_max_time to arrive. The TOFU check is done for the server connected to,
+// and if it fails, ErrTofu is returned with the response, which has the cert.
+// The request is stopped if the context is done before the header has been
+// read, and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func request(ctx context.Context, hostname, port, u string) (*Response, error) {
+	if port == "" {
+		port = "1965"
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	requestURL, err := gemini.GetPunycodeURL(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname: %w", err)
+	}
+	if len(requestURL) > gemini.URLMaxLength {
+		return nil, errors.New("url is too long") //nolint:goerr113
+	}
+	cert, key := clientCert(parsed.Host)
+
+	conn, err := tlsConnect(ctx, "gemini", hostname, port, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	stopWatching := closeOnDone(ctx, conn)
+	br, err := sendPlain(conn, requestURL+"\r\n", ReadTimeout("gemini"))
+	var status int
+	var meta string
+	if err == nil {
+		status, meta, err = readHeader(br)
+	}
+	stopWatching()
+
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !gemini.IsStatusValid(status) {
+		conn.Close()
+		return nil, fmt.Errorf("invalid status code: %d", status) //nolint:goerr113
+	}
+
+	res := newResponse(conn, status, meta, br)
+	res.Cert = conn.ConnectionState().PeerCertificates[0]
+	res.SetReadTimeout(pageMaxTime()) //nolint:errcheck
+	if !handleTofu(hostname, port, res.Cert) {
+		return res, ErrTofu
+	}
+	return res, nil
+}
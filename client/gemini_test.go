@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		status int
+		meta   string
+		err    bool
+	}{
+		{"20 text/gemini\r\n", 20, "text/gemini", false},
+		{"20 text/gemini\n", 20, "text/gemini", false},
+		{"51\r\n", 51, "", false},
+		{"51 \r\n", 51, "", false},
+		{"30 gemini://example.com/", 30, "gemini://example.com/", false}, // EOF after the header
+		{"2 text/gemini\r\n", 0, "", true},
+		{"20text/gemini\r\n", 0, "", true},
+		{"70 text/gemini\r\n", 0, "", true},
+		{"ab text/gemini\r\n", 0, "", true},
+		{"20 " + strings.Repeat("a", 1025) + "\r\n", 0, "", true},
+		{"", 0, "", true},
+	}
+	for _, tt := range tests {
+		status, meta, err := readHeader(bufio.NewReader(strings.NewReader(tt.header)))
+		if (err != nil) != tt.err {
+			t.Errorf("readHeader(%q) error = %v, want error: %t", tt.header, err, tt.err)
+			continue
+		}
+		if status != tt.status || meta != tt.meta {
+			t.Errorf("readHeader(%q) = %d, %q, want %d, %q", tt.header, status, meta, tt.status, tt.meta)
+		}
+	}
+}
+
+func TestCertNameMatches(t *testing.T) {
+	tests := []struct {
+		name, hostname string
+		want           bool
+	}{
+		{"example.com", "example.com", true},
+		{"Example.com.", "example.COM", true},
+		{"example.com", "example.org", false},
+		{"*.example.com", "gemini.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "a.b.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := certNameMatches(tt.name, tt.hostname); got != tt.want {
+			t.Errorf("certNameMatches(%q, %q) = %t, want %t", tt.name, tt.hostname, got, tt.want)
+		}
+	}
+}
+
+// serveGemini runs a Gemini server with a cert for localhost, that responds
+// with a page to every request. It returns the server's port.
+func serveGemini(t *testing.T) (string, func()) {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{testServerCert(t)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				bufio.NewReader(conn).ReadString('\n')            //nolint:errcheck
+				conn.Write([]byte("20 text/gemini\r\n# Hello\n")) //nolint:errcheck
+			}()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port, func() { ln.Close() }
+}
+
+func TestRequestResumesSession(t *testing.T) {
+	defer useTempTofuStore(t)()
+	defer viper.Set("tls.session_resumption", nil)
+	viper.Set("tls.session_resumption", true)
+
+	port, stop := serveGemini(t)
+	defer stop()
+
+	before := GetHandshakeStats()
+	for i := 0; i < 2; i++ {
+		res, err := Fetch("gemini://localhost:" + port + "/")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i+1, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.Status != 20 || string(body) != "# Hello\n" {
+			t.Fatalf("request %d = %d, %q", i+1, res.Status, body)
+		}
+	}
+	after := GetHandshakeStats()
+	if after.Full-before.Full != 1 || after.Resumed-before.Resumed != 1 {
+		t.Errorf("made %d full and %d resumed handshakes, want 1 of each",
+			after.Full-before.Full, after.Resumed-before.Resumed)
+	}
+}
+
+func TestRequestHostProxyTofu(t *testing.T) {
+	defer useTempTofuStore(t)()
+	defer viper.Set("host-proxies.rules", []string{})
+
+	port, stop := serveGemini(t)
+	defer stop()
+	viper.Set("host-proxies.rules", []string{"example.com gemini://localhost:" + port})
+
+	// The proxy used another cert before
+	saveTofuEntry("localhost", port, &x509.Certificate{
+		RawSubjectPublicKeyInfo: []byte("old"),
+		NotAfter:                time.Now().Add(time.Hour),
+	})
+
+	res, err := Fetch("gemini://example.com/")
+	if !errors.Is(err, ErrTofu) {
+		t.Fatalf("Fetch error = %v, want ErrTofu", err)
+	}
+	res.Body.Close()
+	if res.ProxyHostname != "localhost" || res.ProxyPort != port {
+		t.Errorf("response is from proxy %q, %q, want %q, %q", res.ProxyHostname, res.ProxyPort, "localhost", port)
+	}
+	// The origin's entry is left alone
+	if _, _, err := loadTofuEntry("example.com", ""); err == nil {
+		t.Error("the proxy's cert was saved for example.com")
+	}
+}
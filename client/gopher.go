@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Gopher is supported by converting responses to look like Gemini ones.
+// See RFC 1436 and RFC 4266 for details on the protocol and its URLs.
+
+var ErrGopherSearch = errors.New("no search query for the Gopher search server")
+
+// GopherResponse is a Gopher response, converted to look like a Gemini one
+// so it can be displayed the same way. The status is always 20, and the meta
+// is the mediatype guessed from the item type.
+type GopherResponse struct {
+	*Response
+	ItemType byte
+}
+
+// gopherText reads a text response until the line with a single period,
+// which marks the end of it.
+type gopherText struct {
+	r    *bufio.Reader
+	line []byte // What's left of the current line
+	done bool
+}
+
+func (t *gopherText) Read(p []byte) (int, error) {
+	if len(t.line) == 0 {
+		if t.done {
+			return 0, io.EOF
+		}
+		line, err := t.r.ReadBytes('\n')
+		if strings.TrimRight(string(line), "\r\n") == "." {
+			t.done = true
+			return 0, io.EOF
+		}
+		if err != nil {
+			t.done = true
+			if len(line) == 0 || !errors.Is(err, io.EOF) {
+				return 0, err
+			}
+		}
+		t.line = line
+	}
+	n := copy(p, t.line)
+	t.line = t.line[n:]
+	return n, nil
+}
+
+// GopherItemType returns the item type and selector of a gopher:// URL.
+// Directories are assumed if the URL has no path.
+func GopherItemType(parsed *url.URL) (byte, string) {
+	if len(parsed.Path) < 2 {
+		return '1', ""
+	}
+	return parsed.Path[1], parsed.Path[2:]
+}
+
+// gopherMediatype returns the mediatype to use for an item type.
+func gopherMediatype(itemType byte, selector string) string {
+	switch itemType {
+	case '0':
+		return "text/plain"
+	case '1', '7':
+		return string(structs.GopherMenu)
+	case 'g':
+		return "image/gif"
+	case 'p':
+		return "image/png"
+	}
+	// Guess from the file extension, which is usually there for binary files
+	mediatype := mime.TypeByExtension(path.Ext(selector))
+	if mediatype == "" || strings.HasPrefix(mediatype, "text/") {
+		// Text files of unknown types are downloaded as binary files anyway,
+		// because they might not be UTF-8
+		return "application/octet-stream"
+	}
+	return mediatype
+}
+
+// FetchGopher makes a request to a gopher:// URL.
+// For search servers (item type 7) the query string of the URL, or the
+// part of the selector after a tab, is sent as the search.
+// The request is stopped if the context is done before the response starts,
+// and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func FetchGopher(ctx context.Context, u string) (*GopherResponse, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	itemType, selector := GopherItemType(parsed)
+
+	search := ""
+	if i := strings.IndexByte(selector, '\t'); i != -1 {
+		search = selector[i+1:]
+		selector = selector[:i]
+	} else if parsed.RawQuery != "" {
+		search, err = url.QueryUnescape(parsed.RawQuery)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query string: %w", err)
+		}
+	}
+	if itemType == '7' && search == "" {
+		return nil, ErrGopherSearch
+	}
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "70")
+	}
+
+	request := selector
+	if search != "" {
+		request += "\t" + search
+	}
+	conn, br, err := fetchPlain(ctx, "gopher", host, request+"\r\n")
+	if err != nil {
+		return nil, err
+	}
+
+	mediatype := gopherMediatype(itemType, selector)
+	var body io.Reader = br
+	if mediatype == "text/plain" || mediatype == string(structs.GopherMenu) {
+		body = &gopherText{r: br}
+	}
+	return &GopherResponse{
+		Response: newResponse(conn, 20, mediatype, body),
+		ItemType: itemType,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"mime"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Nex is supported by converting responses to look like Gemini ones.
+// The request is just the selector, and the response has no header or
+// status, so every response that can be read is treated as a success.
+// Directories are the selectors ending in a slash, and are plain text
+// with gemtext-like link lines. The status of responses is always 20, and
+// the meta is the mediatype guessed from the selector.
+
+// NexSelector returns the selector to send for a nex:// URL.
+func NexSelector(parsed *url.URL) string {
+	return strings.TrimPrefix(parsed.Path, "/")
+}
+
+// nexMediatype returns the mediatype to use for a selector.
+func nexMediatype(selector string) string {
+	if selector == "" || strings.HasSuffix(selector, "/") {
+		return string(structs.NexDirectory)
+	}
+	mediatype := mime.TypeByExtension(path.Ext(selector))
+	if mediatype == "" || strings.HasPrefix(mediatype, "text/plain") {
+		// Most files are plain text, with or without an extension
+		return "text/plain"
+	}
+	return mediatype
+}
+
+// FetchNex makes a request to a nex:// URL.
+// The request is stopped if the context is done before the response starts,
+// and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func FetchNex(ctx context.Context, u string) (*Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	selector := NexSelector(parsed)
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "1900")
+	}
+
+	conn, br, err := fetchPlain(ctx, "nex", host, selector+"\r\n")
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(conn, 20, nexMediatype(selector), br), nil
+}
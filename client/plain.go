@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Spartan, Gopher, Finger, and Nex requests are all sent over a plain TCP
+// connection, a line or so followed by the response, so they're made the same
+// way. The connection is made through the SOCKS5 proxy set for the host, and
+// the timeouts for the scheme are used.
+
+// fetchPlain connects to the address for the scheme, sends the request, and
+// waits for the response to start. The rest of it can take up to page_max_time
+// to arrive, or any amount of time if that's 0. The request is stopped if the
+// context is done first, and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func fetchPlain(ctx context.Context, scheme, address, request string) (net.Conn, *bufio.Reader, error) {
+	conn, err := dial(ctx, &net.Dialer{Timeout: DialTimeout(scheme)}, address)
+	if ctx.Err() != nil {
+		if err == nil {
+			conn.Close()
+		}
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the server: %w", err)
+	}
+	stopWatching := closeOnDone(ctx, conn)
+	br, err := sendPlain(conn, request, ReadTimeout(scheme))
+	stopWatching()
+
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if pageMaxTime() > 0 {
+		conn.SetDeadline(time.Now().Add(pageMaxTime())) //nolint:errcheck
+	} else {
+		// No limit, like for Gemini
+		conn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+	return conn, br, nil
+}
+
+// sendPlain sends the request on the connection, and returns a reader for the
+// response once it starts, which has to be within the timeout.
+func sendPlain(conn net.Conn, request string, timeout time.Duration) (*bufio.Reader, error) {
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	}
+	if _, err := io.WriteString(conn, request); err != nil {
+		return nil, fmt.Errorf("failed to send the request: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	if _, err := br.Peek(1); err != nil && !errors.Is(err, io.EOF) {
+		// The server didn't start responding in time
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+	return br, nil
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// The config isn't loaded in tests
+	viper.Set("timeouts.dial_timeout", 10)
+	viper.Set("timeouts.read_timeout", 10)
+	viper.Set("a-general.page_max_time", 10)
+}
+
+// plainServer serves one connection, replying with the response once the
+// request line has arrived. It returns the address, and a channel that gets
+// the request line.
+func plainServer(t *testing.T, response string) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := make(chan string, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		requests <- line
+		conn.Write([]byte(response)) //nolint:errcheck
+	}()
+	return ln.Addr().String(), requests
+}
+
+func TestFetchFinger(t *testing.T) {
+	addr, requests := plainServer(t, "Login: alice\r\n")
+	res, err := FetchFinger(context.Background(), "finger://alice@"+addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if req := <-requests; req != "alice\r\n" {
+		t.Errorf("request = %q, want %q", req, "alice\r\n")
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.Status != 20 || res.Meta != "text/plain" || string(body) != "Login: alice\r\n" {
+		t.Errorf("response = %d %q %q", res.Status, res.Meta, body)
+	}
+}
+
+func TestFetchFingerNoPageMaxTime(t *testing.T) {
+	// 0 means the body can take any amount of time
+	viper.Set("a-general.page_max_time", 0)
+	defer viper.Set("a-general.page_max_time", 10)
+
+	addr, _ := plainServer(t, "Login: alice\r\n")
+	res, err := FetchFinger(context.Background(), "finger://alice@"+addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil || string(body) != "Login: alice\r\n" {
+		t.Errorf("body = %q, %v", body, err)
+	}
+}
+
+func TestFetchSpartan(t *testing.T) {
+	addr, requests := plainServer(t, "3 /other\r\n")
+	res, err := FetchSpartan(context.Background(), "spartan://"+addr+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	want := "127.0.0.1 /page 0\r\n"
+	if req := <-requests; req != want {
+		t.Errorf("request = %q, want %q", req, want)
+	}
+	if res.Status != 30 || res.Meta != "/other" {
+		t.Errorf("response = %d %q, want 30 /other", res.Status, res.Meta)
+	}
+}
+
+func TestFetchPlainStopped(t *testing.T) {
+	addr, _ := plainServer(t, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FetchNex(ctx, "nex://"+addr+"/"); err != context.Canceled {
+		t.Errorf("FetchNex with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Proxies can be set for hosts in the host-proxies section of the config.
+// A proxy is either a SOCKS5 server that connections to the host are made
+// through, or a Gemini proxy that's sent the whole URL instead of the host.
+// The proxies section is different, it's for the schemes Amfora can't load.
+
+var ErrProxyRule = errors.New(`host-proxies rules need to be a host pattern and a proxy, like "*.onion socks5://127.0.0.1:9050"`)
+
+type hostProxy struct {
+	socks bool   // A SOCKS5 proxy, instead of a Gemini one
+	addr  string // host:port
+}
+
+// matchHost returns true if the hostname matches the pattern, which is either
+// a hostname, one starting with "*." that matches its subdomains too, or "*".
+func matchHost(pattern, hostname string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		return hostname == pattern[2:] || strings.HasSuffix(hostname, pattern[1:])
+	}
+	return hostname == pattern
+}
+
+// isLocalhost returns true if the hostname is for this computer.
+func isLocalhost(hostname string) bool {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if hostname == "localhost" || strings.HasSuffix(hostname, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parseProxy parses a proxy from the config. It returns false if the
+// proxy is "off" or empty, which means connecting directly.
+func parseProxy(s string) (hostProxy, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return hostProxy{}, false, nil
+	}
+
+	p := hostProxy{}
+	port := "1965"
+	switch {
+	case strings.HasPrefix(s, "socks5://"):
+		p.socks = true
+		port = "1080"
+		s = strings.TrimPrefix(s, "socks5://")
+	case strings.HasPrefix(s, "gemini://"):
+		s = strings.TrimPrefix(s, "gemini://")
+	case strings.Contains(s, "://"):
+		return hostProxy{}, false, fmt.Errorf("unsupported proxy %q, only socks5:// and Gemini proxies can be used", s) //nolint:goerr113
+	}
+	s = strings.TrimSuffix(s, "/")
+
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		// No port
+		s = net.JoinHostPort(strings.Trim(s, "[]"), port)
+	}
+	p.addr = s
+	return p, true, nil
+}
+
+// proxyForHost returns the proxy that should be used for the hostname, from
+// the host-proxies section of the config. It returns false if there isn't one.
+//
+// The error text is human friendly and should be displayed.
+func proxyForHost(hostname string) (hostProxy, bool, error) {
+	for _, rule := range viper.GetStringSlice("host-proxies.rules") {
+		fields := strings.Fields(rule)
+		if len(fields) != 2 {
+			return hostProxy{}, false, ErrProxyRule
+		}
+		if matchHost(fields[0], hostname) {
+			return parseProxy(fields[1])
+		}
+	}
+	if viper.GetBool("host-proxies.bypass_localhost") && isLocalhost(hostname) {
+		return hostProxy{}, false, nil
+	}
+	return parseProxy(viper.GetString("host-proxies.default"))
+}
+
+// geminiProxyForHost returns the Gemini proxy set for the hostname, if there is one.
+func geminiProxyForHost(hostname string) (string, string, bool, error) {
+	p, ok, err := proxyForHost(hostname)
+	if err != nil || !ok || p.socks {
+		return "", "", false, err
+	}
+	proxyHostname, proxyPort, err := net.SplitHostPort(p.addr)
+	if err != nil {
+		return "", "", false, err
+	}
+	return proxyHostname, proxyPort, true, nil
+}
+
+// dial makes a TCP connection to the address, through the SOCKS5 proxy set
+// for its host if there is one. Gemini proxies are used by Fetch instead.
+// Connecting stops if the context is done first.
+func dial(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	hostname, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	p, ok, err := proxyForHost(hostname)
+	if err != nil {
+		return nil, err
+	}
+	if ok && p.socks {
+		return dialSOCKS(ctx, dialer, p.addr, address)
+	}
+	return dialer.DialContext(ctx, "tcp", address)
+}
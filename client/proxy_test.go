@@ -0,0 +1,95 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		hostname string
+		want     bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "EXAMPLE.com.", true},
+		{"example.com", "www.example.com", false},
+		{"*.onion", "example.onion", true},
+		{"*.onion", "a.b.onion", true},
+		{"*.onion", "onion", true},
+		{"*.onion", "notonion", false},
+		{"*.example.com", "example.org", false},
+		{"*", "anything.example", true},
+	}
+	for _, tt := range tests {
+		if got := matchHost(tt.pattern, tt.hostname); got != tt.want {
+			t.Errorf("matchHost(%q, %q) = %t, want %t", tt.pattern, tt.hostname, got, tt.want)
+		}
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	tests := []struct {
+		s     string
+		proxy hostProxy
+		ok    bool
+		err   bool
+	}{
+		{"", hostProxy{}, false, false},
+		{" off ", hostProxy{}, false, false},
+		{"socks5://127.0.0.1:9050", hostProxy{true, "127.0.0.1:9050"}, true, false},
+		{"socks5://localhost", hostProxy{true, "localhost:1080"}, true, false},
+		{"socks5://[::1]", hostProxy{true, "[::1]:1080"}, true, false},
+		{"gemini://proxy.example.com/", hostProxy{false, "proxy.example.com:1965"}, true, false},
+		{"proxy.example.com:1966", hostProxy{false, "proxy.example.com:1966"}, true, false},
+		{"http://proxy.example.com", hostProxy{}, false, true},
+	}
+	for _, tt := range tests {
+		proxy, ok, err := parseProxy(tt.s)
+		if (err != nil) != tt.err || ok != tt.ok || proxy != tt.proxy {
+			t.Errorf("parseProxy(%q) = %+v, %t, %v, want %+v, %t, error: %t",
+				tt.s, proxy, ok, err, tt.proxy, tt.ok, tt.err)
+		}
+	}
+}
+
+func TestProxyForHost(t *testing.T) {
+	defer func() {
+		viper.Set("host-proxies.rules", []string{})
+		viper.Set("host-proxies.default", "")
+		viper.Set("host-proxies.bypass_localhost", false)
+	}()
+	viper.Set("host-proxies.rules", []string{
+		"*.onion socks5://127.0.0.1:9050",
+		"direct.example.com off",
+		"gemini.example.com gemini://proxy.example.com",
+	})
+	viper.Set("host-proxies.default", "socks5://127.0.0.1:1080")
+	viper.Set("host-proxies.bypass_localhost", true)
+
+	tests := []struct {
+		hostname string
+		proxy    hostProxy
+		ok       bool
+	}{
+		{"example.onion", hostProxy{true, "127.0.0.1:9050"}, true},
+		{"direct.example.com", hostProxy{}, false},
+		{"gemini.example.com", hostProxy{false, "proxy.example.com:1965"}, true},
+		{"localhost", hostProxy{}, false},
+		{"::1", hostProxy{}, false},
+		{"example.org", hostProxy{true, "127.0.0.1:1080"}, true},
+	}
+	for _, tt := range tests {
+		proxy, ok, err := proxyForHost(tt.hostname)
+		if err != nil || ok != tt.ok || proxy != tt.proxy {
+			t.Errorf("proxyForHost(%q) = %+v, %t, %v, want %+v, %t",
+				tt.hostname, proxy, ok, err, tt.proxy, tt.ok)
+		}
+	}
+
+	viper.Set("host-proxies.rules", []string{"example.com"})
+	if _, _, err := proxyForHost("example.com"); err != ErrProxyRule {
+		t.Errorf("proxyForHost with an invalid rule returned %v, want ErrProxyRule", err)
+	}
+}
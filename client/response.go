@@ -0,0 +1,56 @@
+package client
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// Response is a response with the connection it's read from, so the read
+// timeout of the body can be changed. Responses for other protocols than
+// Gemini are converted to look like Gemini ones, so they can be displayed
+// the same way.
+type Response struct {
+	*gemini.Response
+
+	// ProxyHostname and ProxyPort are of the Gemini proxy the response came
+	// from, if one was used. The cert of the response is the proxy's then.
+	ProxyHostname string
+	ProxyPort     string
+
+	conn net.Conn
+}
+
+// SetReadTimeout changes the read timeout for the rest of the response body.
+// A zero duration disables the timeout.
+func (r *Response) SetReadTimeout(d time.Duration) error {
+	if d <= 0 {
+		return r.conn.SetReadDeadline(time.Time{})
+	}
+	return r.conn.SetReadDeadline(time.Now().Add(d))
+}
+
+// connBody is the body of a Response, which closes the connection.
+type connBody struct {
+	io.Reader
+	conn net.Conn
+}
+
+func (b *connBody) Close() error {
+	return b.conn.Close()
+}
+
+// newResponse returns the response for the connection, with the body read
+// from r.
+func newResponse(conn net.Conn, status int, meta string, r io.Reader) *Response {
+	return &Response{
+		Response: &gemini.Response{
+			Status: status,
+			Meta:   meta,
+			Body:   &connBody{r, conn},
+		},
+		conn: conn,
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// A SOCKS5 client, for proxies set in the host-proxies section of the config.
+// See RFC 1928. Only proxies that don't need authentication are supported.
+
+var socksReplies = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// dialSOCKS connects to the address through the SOCKS5 proxy at proxyAddr.
+// Hostnames are resolved by the proxy, so .onion addresses work with Tor.
+// The dialer's timeout is for the whole handshake with the proxy, which also
+// stops if the context is done.
+func dialSOCKS(ctx context.Context, dialer *net.Dialer, proxyAddr, address string) (net.Conn, error) {
+	hostname, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %q", portStr) //nolint:goerr113
+	}
+	if len(hostname) > 255 {
+		return nil, errors.New("hostname is too long for a SOCKS proxy") //nolint:goerr113
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SOCKS proxy: %w", err)
+	}
+	if dialer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout)) //nolint:errcheck
+	}
+	stopWatching := closeOnDone(ctx, conn)
+	err = socksHandshake(conn, hostname, port)
+	stopWatching()
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck
+	return conn, nil
+}
+
+// socksHandshake asks the proxy on the connection to connect to the host.
+func socksHandshake(conn io.ReadWriter, hostname string, port int) error {
+	// Version 5, with one method: no authentication
+	if _, err := conn.Write([]byte{5, 1, 0}); err != nil {
+		return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+	}
+	if reply[0] != 5 {
+		return errors.New("the proxy isn't a SOCKS5 proxy") //nolint:goerr113
+	}
+	if reply[1] != 0 {
+		return errors.New("the SOCKS proxy needs authentication, which isn't supported") //nolint:goerr113
+	}
+
+	// Connect command
+	req := []byte{5, 1, 0}
+	if ip := net.ParseIP(hostname); ip == nil {
+		req = append(req, 3, byte(len(hostname)))
+		req = append(req, hostname...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, 1)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, 4)
+		req = append(req, ip.To16()...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+	}
+
+	// Version, status, reserved byte, then the address the proxy used and its port
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+	}
+	if head[1] != 0 {
+		msg, ok := socksReplies[head[1]]
+		if !ok {
+			msg = "unknown error " + strconv.Itoa(int(head[1]))
+		}
+		return errors.New("the SOCKS proxy couldn't connect: " + msg) //nolint:goerr113
+	}
+	var addrLen int
+	switch head[3] {
+	case 1:
+		addrLen = net.IPv4len
+	case 4:
+		addrLen = net.IPv6len
+	case 3:
+		b := make([]byte, 1)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+		}
+		addrLen = int(b[0])
+	default:
+		return errors.New("invalid reply from the SOCKS proxy") //nolint:goerr113
+	}
+	if _, err := io.ReadFull(conn, make([]byte, addrLen+2)); err != nil {
+		return fmt.Errorf("failed to talk to the SOCKS proxy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSOCKS is the proxy end of a SOCKS handshake. It replies to the greeting
+// with method, and to the connect request with reply, and sends the request it
+// got on the returned channel. The connection is closed after the reply.
+func fakeSOCKS(conn net.Conn, method, reply []byte) <-chan []byte {
+	requests := make(chan []byte, 1)
+	go func() {
+		defer conn.Close()
+		defer close(requests)
+		greeting := make([]byte, 3)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		if _, err := conn.Write(method); err != nil || !bytes.Equal(method, []byte{5, 0}) {
+			return
+		}
+		req := make([]byte, 512)
+		n, err := conn.Read(req)
+		if err != nil {
+			return
+		}
+		requests <- req[:n]
+		conn.Write(reply) //nolint:errcheck
+	}()
+	return requests
+}
+
+var socksHandshakeTests = []struct {
+	name     string
+	hostname string
+	method   []byte
+	reply    []byte
+	request  []byte // What the proxy should get
+	err      string // Part of the error, if there should be one
+}{
+	{
+		name:     "Domain",
+		hostname: "example.onion",
+		method:   []byte{5, 0},
+		reply:    []byte{5, 0, 0, 1, 127, 0, 0, 1, 0x07, 0xad},
+		request:  append(append([]byte{5, 1, 0, 3, 13}, "example.onion"...), 0x07, 0xad),
+	},
+	{
+		name:     "Domain in the reply",
+		hostname: "example.com",
+		method:   []byte{5, 0},
+		reply:    append(append([]byte{5, 0, 0, 3, 11}, "example.com"...), 0x07, 0xad),
+		request:  append(append([]byte{5, 1, 0, 3, 11}, "example.com"...), 0x07, 0xad),
+	},
+	{
+		name:     "IPv4",
+		hostname: "192.0.2.1",
+		method:   []byte{5, 0},
+		reply:    []byte{5, 0, 0, 1, 192, 0, 2, 1, 0x07, 0xad},
+		request:  []byte{5, 1, 0, 1, 192, 0, 2, 1, 0x07, 0xad},
+	},
+	{
+		name:     "IPv6",
+		hostname: "2001:db8::1",
+		method:   []byte{5, 0},
+		reply:    append(append([]byte{5, 0, 0, 4}, net.ParseIP("2001:db8::1")...), 0x07, 0xad),
+		request:  append(append([]byte{5, 1, 0, 4}, net.ParseIP("2001:db8::1")...), 0x07, 0xad),
+	},
+	{
+		name:     "Auth rejected",
+		hostname: "example.com",
+		method:   []byte{5, 0xff},
+		err:      "needs authentication",
+	},
+	{
+		name:     "Not SOCKS5",
+		hostname: "example.com",
+		method:   []byte{4, 0},
+		err:      "isn't a SOCKS5 proxy",
+	},
+	{
+		name:     "Connection refused",
+		hostname: "example.com",
+		method:   []byte{5, 0},
+		reply:    []byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0},
+		request:  append(append([]byte{5, 1, 0, 3, 11}, "example.com"...), 0x07, 0xad),
+		err:      "connection refused",
+	},
+	{
+		name:     "Short reply",
+		hostname: "example.com",
+		method:   []byte{5, 0},
+		reply:    []byte{5, 0, 0, 1, 127, 0},
+		request:  append(append([]byte{5, 1, 0, 3, 11}, "example.com"...), 0x07, 0xad),
+		err:      "failed to talk to the SOCKS proxy",
+	},
+	{
+		name:     "Invalid address type",
+		hostname: "example.com",
+		method:   []byte{5, 0},
+		reply:    []byte{5, 0, 0, 9, 0, 0},
+		request:  append(append([]byte{5, 1, 0, 3, 11}, "example.com"...), 0x07, 0xad),
+		err:      "invalid reply",
+	},
+}
+
+func TestSocksHandshake(t *testing.T) {
+	for _, tt := range socksHandshakeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientEnd, proxyEnd := net.Pipe()
+			defer clientEnd.Close()
+			requests := fakeSOCKS(proxyEnd, tt.method, tt.reply)
+
+			err := socksHandshake(clientEnd, tt.hostname, 1965)
+			if tt.err == "" && err != nil {
+				t.Errorf("error = %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error = %v, want one with %q", err, tt.err)
+			}
+			clientEnd.Close()
+			if req := <-requests; !bytes.Equal(req, tt.request) {
+				t.Errorf("proxy got request %v, want %v", req, tt.request)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Spartan is a protocol similar to Gemini, but without TLS.
+// See spartan://mozz.us/ for the specification. Responses are converted to
+// look like Gemini ones: Spartan status codes are multiplied by ten, so
+// 2 (success) becomes 20, 3 (redirect) becomes 30, etc.
+
+var ErrSpartanHeader = errors.New("invalid response header")
+
+// FetchSpartan makes a request to a spartan:// URL.
+// The query string of the URL, if there is one, is decoded and sent as the
+// data block of the request, which is how input for prompt lines is sent.
+// The request is stopped if the context is done before the response starts,
+// and ctx.Err() is returned.
+//
+// The error text is human friendly and should be displayed.
+func FetchSpartan(ctx context.Context, u string) (*Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	data, err := url.QueryUnescape(parsed.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query string: %w", err)
+	}
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "300")
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	conn, br, err := fetchPlain(ctx, "spartan", host,
+		fmt.Sprintf("%s %s %d\r\n%s", parsed.Hostname(), path, len(data), data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Status line is one digit, a space, and the meta
+	header, err := br.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && header != "") {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read the response header: %w", err)
+	}
+	header = strings.TrimRight(header, "\r\n")
+	if len(header) < 2 || header[0] < '2' || header[0] > '5' || header[1] != ' ' {
+		conn.Close()
+		return nil, ErrSpartanHeader
+	}
+	return newResponse(conn, int(header[0]-'0')*10, header[2:], br), nil
+}
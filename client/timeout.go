@@ -0,0 +1,48 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// timeout returns the timeout from the config for the scheme. A timeout set for
+// the scheme, like timeouts.gopher.dial_timeout, is used over the general one.
+func timeout(scheme, name string) time.Duration {
+	key := "timeouts." + scheme + "." + name
+	if !viper.IsSet(key) {
+		key = "timeouts." + name
+	}
+	return time.Duration(viper.GetFloat64(key) * float64(time.Second))
+}
+
+// DialTimeout returns how long connecting to a server for the scheme can take,
+// including the TLS handshake.
+func DialTimeout(scheme string) time.Duration {
+	return timeout(scheme, "dial_timeout")
+}
+
+// ReadTimeout returns how long a server for the scheme can take to start responding,
+// once the request is sent.
+func ReadTimeout(scheme string) time.Duration {
+	return timeout(scheme, "read_timeout")
+}
+
+// pageMaxTime returns how long the rest of a response can take to load,
+// after it starts.
+func pageMaxTime() time.Duration {
+	return time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second
+}
+
+// IsTimeout returns true if the error is from a connection timing out.
+func IsTimeout(err error) bool {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	// Not all errors wrap the network error
+	return err != nil && strings.Contains(err.Error(), "i/o timeout")
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// Titan is a companion protocol to Gemini for uploading content.
+// See gemini://transjovian.org/titan for the specification.
+
+// TitanURL returns the titan:// URL to upload content to the provided
+// gemini:// URL, with the parameters for the content added to the path.
+// The token is left out if it's empty.
+func TitanURL(u, mediatype string, size int, token string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != "gemini" {
+		return "", errors.New("only gemini:// pages can be uploaded to")
+	}
+	parsed.Scheme = "titan"
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+
+	params := ";mime=" + strings.ReplaceAll(url.PathEscape(mediatype), "%2F", "/") + ";size=" + strconv.Itoa(size)
+	if token != "" {
+		params += ";token=" + url.PathEscape(token)
+	}
+	return parsed.String() + params, nil
+}
+
+// Upload sends the data to the titan:// URL, which should come from TitanURL.
+// The same client certificates and TOFU database are used as for Gemini.
+// The response has no body, it is only the status and meta.
+//
+// The error text is human friendly and should be displayed.
+func Upload(u string, data []byte) (*gemini.Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if len(u) > gemini.URLMaxLength {
+		return nil, errors.New("url is too long")
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "1965"
+	}
+
+	// Certificates are stored for the Gemini host, which is the same
+	certPEM, keyPEM := clientCert(parsed.Host)
+	conn, err := tlsConnect(context.Background(), "titan", parsed.Hostname(), port, certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	cert := conn.ConnectionState().PeerCertificates[0]
+	if !handleTofu(parsed.Hostname(), parsed.Port(), cert) {
+		return &gemini.Response{Cert: cert}, ErrTofu
+	}
+
+	// Uploads can take a while, so the timeout is only for the response
+	_, err = fmt.Fprintf(conn, "%s\r\n", u)
+	if err == nil {
+		_, err = conn.Write(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send the upload: %w", err)
+	}
+	if timeout := ReadTimeout("titan"); timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	}
+
+	status, meta, err := readHeader(bufio.NewReader(conn))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gemini.Response{
+		Status: status,
+		Meta:   meta,
+		Body:   ioutil.NopCloser(strings.NewReader("")),
+		Cert:   cert,
+	}, nil
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// The TLS versions and cipher suites servers can use, from the tls section of
+// the config. The ServerHello is read as it arrives, and the connection is
+// closed if the server picked something that isn't allowed, before a client
+// cert is sent. That gives a clearer error than the TLS handshake would.
+
+// ErrTLSPolicy is wrapped by the errors for servers that don't follow the policy.
+var ErrTLSPolicy = errors.New("the server's TLS settings aren't allowed by the config")
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "1.0",
+	tls.VersionTLS11: "1.1",
+	tls.VersionTLS12: "1.2",
+	tls.VersionTLS13: "1.3",
+}
+
+// tlsMinVersion returns the minimum TLS version from the config.
+// TLS 1.2 is used for an invalid value.
+func tlsMinVersion() uint16 {
+	if strings.TrimSpace(viper.GetString("tls.min_version")) == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// tlsCiphers returns the IDs of the cipher suites set in the config,
+// or nil if all of them are allowed. Unknown names are returned as an error.
+func tlsCiphers() ([]uint16, error) {
+	names := viper.GetStringSlice("tls.ciphers")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	ids := make(map[string]uint16)
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids[s.Name] = s.ID
+	}
+	ciphers := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q in the tls section of the config", name) //nolint:goerr113
+		}
+		ciphers = append(ciphers, id)
+	}
+	return ciphers, nil
+}
+
+// checkTLSPolicy returns an error wrapping ErrTLSPolicy if the version or
+// cipher suite picked by a server isn't allowed by the config.
+func checkTLSPolicy(version, cipher uint16) error {
+	if version < tlsMinVersion() {
+		name, ok := tlsVersionNames[version]
+		if !ok {
+			name = fmt.Sprintf("0x%04x", version)
+		}
+		return fmt.Errorf("%w: the server uses TLS %s, but min_version is %s",
+			ErrTLSPolicy, name, tlsVersionNames[tlsMinVersion()])
+	}
+	ciphers, err := tlsCiphers()
+	if err != nil {
+		return err
+	}
+	if ciphers == nil {
+		return nil
+	}
+	for _, c := range ciphers {
+		if c == cipher {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the server uses the cipher suite %s, which isn't in ciphers",
+		ErrTLSPolicy, tls.CipherSuiteName(cipher))
+}
+
+// IsTLSPolicyError returns true if the error is from a server that doesn't
+// follow the TLS policy in the config.
+func IsTLSPolicyError(err error) bool {
+	return errors.Is(err, ErrTLSPolicy)
+}
+
+// parseServerHello returns the TLS version and cipher suite picked by the
+// server in the ServerHello record at the start of data. ok is false if data
+// doesn't have all of the record yet, and an error is returned if it's not
+// a ServerHello.
+func parseServerHello(data []byte) (version, cipher uint16, ok bool, err error) {
+	errNotHello := errors.New("the server didn't start the TLS handshake") //nolint:goerr113
+	if len(data) < 5 {
+		return 0, 0, false, nil
+	}
+	if data[0] != 22 { // Handshake record
+		return 0, 0, false, errNotHello
+	}
+	end := 5 + int(binary.BigEndian.Uint16(data[3:5]))
+	if len(data) < end {
+		return 0, 0, false, nil
+	}
+	msg := data[5:end]
+	// Type, length, legacy version, random, and session ID length
+	if len(msg) < 39 || msg[0] != 2 {
+		return 0, 0, false, errNotHello
+	}
+	version = binary.BigEndian.Uint16(msg[4:6])
+	i := 39 + int(msg[38]) // After the session ID
+	if len(msg) < i+3 {
+		return 0, 0, false, errNotHello
+	}
+	cipher = binary.BigEndian.Uint16(msg[i : i+2])
+	i += 3 // And the compression method
+
+	if len(msg) < i+2 {
+		// No extensions
+		return version, cipher, true, nil
+	}
+	extEnd := i + 2 + int(binary.BigEndian.Uint16(msg[i:i+2]))
+	if extEnd > len(msg) {
+		return 0, 0, false, errNotHello
+	}
+	for i += 2; i+4 <= extEnd; {
+		extType := binary.BigEndian.Uint16(msg[i : i+2])
+		extLen := int(binary.BigEndian.Uint16(msg[i+2 : i+4]))
+		i += 4
+		if i+extLen > extEnd {
+			return 0, 0, false, errNotHello
+		}
+		if extType == 43 && extLen == 2 {
+			// supported_versions has the real version for TLS 1.3
+			version = binary.BigEndian.Uint16(msg[i : i+2])
+		}
+		i += extLen
+	}
+	return version, cipher, true, nil
+}
+
+// policyConn checks the ServerHello that's read from the connection against
+// the TLS policy, and fails reading if the server doesn't follow it.
+type policyConn struct {
+	net.Conn
+	buf     []byte // What's been read before the ServerHello was checked
+	checked bool
+	err     error
+}
+
+func (c *policyConn) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	for !c.checked {
+		version, cipher, ok, err := parseServerHello(c.buf)
+		if err != nil {
+			// Let the TLS code report what's wrong with it
+			c.checked = true
+			break
+		}
+		if ok {
+			c.checked = true
+			if err := checkTLSPolicy(version, cipher); err != nil {
+				c.err = err
+				c.Conn.Close()
+				return 0, err
+			}
+			break
+		}
+		chunk := make([]byte, 4096)
+		n, err := c.Conn.Read(chunk)
+		c.buf = append(c.buf, chunk[:n]...)
+		if err != nil {
+			c.checked = true
+			if len(c.buf) == 0 {
+				return 0, err
+			}
+		}
+	}
+	if len(c.buf) > 0 {
+		n := copy(p, c.buf)
+		c.buf = c.buf[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// policyError returns the error the connection was closed with, if it's a
+// policyConn that found the server doesn't follow the TLS policy.
+func policyError(conn net.Conn) error {
+	if pc, ok := conn.(*policyConn); ok {
+		return pc.err
+	}
+	return nil
+}
+
+// dialGemini is like dial, but checks the TLS policy of the Gemini server.
+func dialGemini(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	conn, err := dial(ctx, dialer, address)
+	if err != nil {
+		return nil, err
+	}
+	if tlsMinVersion() == tls.VersionTLS12 && len(viper.GetStringSlice("tls.ciphers")) == 0 {
+		// Anything tlsConnect allows is fine
+		return conn, nil
+	}
+	return &policyConn{Conn: conn}, nil
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// testServerCert returns a self-signed cert for a TLS server in tests.
+func testServerCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// recordConn keeps everything that's read from the connection.
+type recordConn struct {
+	net.Conn
+	read []byte
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read = append(c.read, p[:n]...)
+	return n, err
+}
+
+// chunkConn writes to the connection a few bytes at a time, so the other
+// side reads them in many parts.
+type chunkConn struct {
+	net.Conn
+}
+
+func (c *chunkConn) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := 3
+		if n > len(p) {
+			n = len(p)
+		}
+		m, err := c.Conn.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// handshakeOverPipe does a TLS handshake with a server that uses TLS versions
+// up to maxVersion. wrapServer and wrapClient change the server and client
+// ends of the pipe. It returns the client's error and connection state.
+func handshakeOverPipe(t *testing.T, maxVersion uint16, wrapServer, wrapClient func(net.Conn) net.Conn) (tls.ConnectionState, error) {
+	serverEnd, clientEnd := net.Pipe()
+	defer serverEnd.Close()
+	defer clientEnd.Close()
+
+	server := tls.Server(wrapServer(serverEnd), &tls.Config{
+		Certificates: []tls.Certificate{testServerCert(t)},
+		MaxVersion:   maxVersion,
+	})
+	go server.Handshake() //nolint:errcheck
+
+	client := tls.Client(wrapClient(clientEnd), &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+	})
+	err := client.Handshake()
+	return client.ConnectionState(), err
+}
+
+// realServerHello returns the records a server using TLS versions up to
+// maxVersion starts its handshake with, and the version and cipher suite it
+// picked.
+func realServerHello(t *testing.T, maxVersion uint16) ([]byte, uint16, uint16) {
+	var rec *recordConn
+	state, err := handshakeOverPipe(t, maxVersion,
+		func(c net.Conn) net.Conn { return c },
+		func(c net.Conn) net.Conn {
+			rec = &recordConn{Conn: c}
+			return rec
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec.read, state.Version, state.CipherSuite
+}
+
+func TestParseServerHello(t *testing.T) {
+	tls12, version12, cipher12 := realServerHello(t, tls.VersionTLS12)
+	tls13, version13, cipher13 := realServerHello(t, tls.VersionTLS13)
+	if version12 != tls.VersionTLS12 || version13 != tls.VersionTLS13 {
+		t.Fatalf("servers used versions 0x%04x and 0x%04x", version12, version13)
+	}
+	// Only the ServerHello record
+	hello12 := tls12[:5+int(tls12[3])<<8+int(tls12[4])]
+
+	appData := append([]byte{23}, tls12[1:]...)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		version uint16
+		cipher  uint16
+		ok      bool
+		err     bool
+	}{
+		{"TLS 1.2", tls12, version12, cipher12, true, false},
+		{"TLS 1.2 record only", hello12, version12, cipher12, true, false},
+		{"TLS 1.3 with supported_versions", tls13, version13, cipher13, true, false},
+		{"Empty", nil, 0, 0, false, false},
+		{"Record header only", tls12[:5], 0, 0, false, false},
+		{"Truncated record", hello12[:len(hello12)-1], 0, 0, false, false},
+		{"Not a handshake record", appData, 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, cipher, ok, err := parseServerHello(tt.data)
+			if (err != nil) != tt.err {
+				t.Fatalf("error = %v, want error: %t", err, tt.err)
+			}
+			if version != tt.version || cipher != tt.cipher || ok != tt.ok {
+				t.Errorf("got 0x%04x, 0x%04x, %t, want 0x%04x, 0x%04x, %t",
+					version, cipher, ok, tt.version, tt.cipher, tt.ok)
+			}
+		})
+	}
+
+	// Every prefix of the record is incomplete
+	for i := 0; i < len(hello12); i++ {
+		if _, _, ok, err := parseServerHello(hello12[:i]); ok || err != nil {
+			t.Fatalf("parseServerHello of %d bytes = %t, %v, want false, nil", i, ok, err)
+		}
+	}
+}
+
+func TestPolicyConn(t *testing.T) {
+	defer viper.Set("tls.min_version", "1.2")
+
+	tests := []struct {
+		minVersion string
+		maxVersion uint16
+		allowed    bool
+	}{
+		{"1.2", tls.VersionTLS12, true},
+		{"1.2", tls.VersionTLS13, true},
+		{"1.3", tls.VersionTLS13, true},
+		{"1.3", tls.VersionTLS12, false},
+	}
+	for _, tt := range tests {
+		for _, split := range []bool{false, true} {
+			name := fmt.Sprintf("min %s, server max 0x%04x, split %t", tt.minVersion, tt.maxVersion, split)
+			t.Run(name, func(t *testing.T) {
+				viper.Set("tls.min_version", tt.minVersion)
+				wrapServer := func(c net.Conn) net.Conn { return c }
+				if split {
+					// The ServerHello arrives across many reads
+					wrapServer = func(c net.Conn) net.Conn { return &chunkConn{c} }
+				}
+				_, err := handshakeOverPipe(t, tt.maxVersion, wrapServer,
+					func(c net.Conn) net.Conn { return &policyConn{Conn: c} })
+				if tt.allowed && err != nil {
+					t.Errorf("handshake failed: %v", err)
+				}
+				if !tt.allowed && !IsTLSPolicyError(err) {
+					t.Errorf("handshake error = %v, want a TLS policy error", err)
+				}
+			})
+		}
+	}
+}
+
+func TestPolicyError(t *testing.T) {
+	defer viper.Set("tls.min_version", "1.2")
+	viper.Set("tls.min_version", "1.3")
+
+	var pc *policyConn
+	_, err := handshakeOverPipe(t, tls.VersionTLS12,
+		func(c net.Conn) net.Conn { return c },
+		func(c net.Conn) net.Conn {
+			pc = &policyConn{Conn: c}
+			return pc
+		},
+	)
+	if err == nil {
+		t.Fatal("handshake succeeded")
+	}
+	// The error is found on the connection even if it isn't wrapped
+	if !IsTLSPolicyError(policyError(pc)) {
+		t.Errorf("policyError = %v, want a TLS policy error", policyError(pc))
+	}
+	if policyError(&recordConn{}) != nil {
+		t.Error("policyError of another connection isn't nil")
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TLS session resumption, so connecting to a server again skips most of the
+// handshake. The sessions are shared by Gemini requests and Titan uploads,
+// since they're made to the same servers.
+
+// How many servers sessions are kept for.
+const tlsSessionCacheSize = 64
+
+var tlsSessionCache = tls.NewLRUClientSessionCache(tlsSessionCacheSize)
+
+// HandshakeStats is how many TLS handshakes were made, and how long they took.
+type HandshakeStats struct {
+	Full        int
+	FullTime    time.Duration
+	Resumed     int
+	ResumedTime time.Duration
+}
+
+var handshakeStats HandshakeStats
+var handshakeStatsMu = sync.Mutex{}
+
+// sessionCache returns the session cache to use in a TLS config,
+// or nil if session resumption is disabled.
+func sessionCache() tls.ClientSessionCache {
+	if !viper.GetBool("tls.session_resumption") {
+		return nil
+	}
+	return tlsSessionCache
+}
+
+// handshake does the TLS handshake for the connection, and adds how long it
+// took to the stats.
+func handshake(conn *tls.Conn) error {
+	start := time.Now()
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	handshakeStatsMu.Lock()
+	defer handshakeStatsMu.Unlock()
+	if conn.ConnectionState().DidResume {
+		handshakeStats.Resumed++
+		handshakeStats.ResumedTime += elapsed
+	} else {
+		handshakeStats.Full++
+		handshakeStats.FullTime += elapsed
+	}
+	return nil
+}
+
+// GetHandshakeStats returns the stats of the TLS handshakes made this session.
+func GetHandshakeStats() HandshakeStats {
+	handshakeStatsMu.Lock()
+	defer handshakeStatsMu.Unlock()
+	return handshakeStats
+}
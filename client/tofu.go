@@ -3,8 +3,12 @@ package client
 import (
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +32,19 @@ var tofuStore = config.TofuStore
 // call on the funcs on this file.
 var tofuStoreMu = sync.RWMutex{}
 
+// sessionTofu stores the IDs of certs that have been trusted
+// for this session only, by their idKey. It's also protected by tofuStoreMu.
+var sessionTofu = make(map[string]string)
+
+// TofuEntry is a cert stored in the TOFU database.
+type TofuEntry struct {
+	Domain      string
+	Port        string // Empty for port 1965
+	Fingerprint string
+	Expiry      time.Time
+	FirstSeen   time.Time // Zero for entries stored before this was
+}
+
 // idKey returns the config/viper key needed to retrieve
 // a cert's ID / fingerprint.
 func idKey(domain string, port string) string {
@@ -44,6 +61,14 @@ func expiryKey(domain string, port string) string {
 	return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/expiry" + ":" + port
 }
 
+// firstSeenKey returns the key for when the cert was first trusted.
+func firstSeenKey(domain string, port string) string {
+	if port == "1965" || port == "" {
+		return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/first-seen"
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/first-seen" + ":" + port
+}
+
 func loadTofuEntry(domain string, port string) (string, time.Time, error) {
 	tofuStoreMu.RLock()
 	defer tofuStoreMu.RUnlock()
@@ -83,6 +108,7 @@ func saveTofuEntry(domain, port string, cert *x509.Certificate) {
 
 	tofuStore.Set(idKey(domain, port), certID(cert))
 	tofuStore.Set(expiryKey(domain, port), cert.NotAfter.UTC())
+	tofuStore.Set(firstSeenKey(domain, port), time.Now().UTC())
 	tofuStore.WriteConfig() //nolint:errcheck // Not an issue if it's not saved, only cached data
 }
 
@@ -92,6 +118,14 @@ func saveTofuEntry(domain, port string, cert *x509.Certificate) {
 // the TOFU database.
 // If false is returned, the connection should not go ahead.
 func handleTofu(domain, port string, cert *x509.Certificate) bool {
+	tofuStoreMu.RLock()
+	sessionID, ok := sessionTofu[idKey(domain, port)]
+	tofuStoreMu.RUnlock()
+	if ok && sessionID == certID(cert) {
+		// Trusted for now, the stored cert is left alone
+		return true
+	}
+
 	id, expiry, err := loadTofuEntry(domain, port)
 	if err != nil {
 		// Cert isn't in database or data is malformed
@@ -128,6 +162,114 @@ func ResetTofuEntry(domain, port string, cert *x509.Certificate) {
 	saveTofuEntry(domain, port, cert)
 }
 
+// TrustForSession makes the cert passed valid until Amfora is closed,
+// without changing the TOFU entry. The port string can be empty, to indicate port 1965.
+func TrustForSession(domain, port string, cert *x509.Certificate) {
+	tofuStoreMu.Lock()
+	defer tofuStoreMu.Unlock()
+
+	sessionTofu[idKey(domain, port)] = certID(cert)
+}
+
+// TrustedForSession returns true if the cert of the server is only trusted for
+// this session, because it didn't match the one in the TOFU database.
+// The port string can be empty, to indicate port 1965.
+func TrustedForSession(domain, port string) bool {
+	tofuStoreMu.RLock()
+	defer tofuStoreMu.RUnlock()
+	_, ok := sessionTofu[idKey(domain, port)]
+	return ok
+}
+
+// RemoveTofuEntry removes the TOFU entry for the host, so whatever cert
+// it has next is stored instead. The port string can be empty, to indicate port 1965.
+func RemoveTofuEntry(domain, port string) error {
+	tofuStoreMu.Lock()
+	defer tofuStoreMu.Unlock()
+
+	delete(sessionTofu, idKey(domain, port))
+	// Viper can't remove keys, but empty values are treated as not found
+	tofuStore.Set(idKey(domain, port), "")
+	tofuStore.Set(expiryKey(domain, port), "")
+	tofuStore.Set(firstSeenKey(domain, port), "")
+	return tofuStore.WriteConfig()
+}
+
+// ClearTofu removes every entry from the TOFU database, and the certs trusted
+// for this session. It returns how many entries were in the database.
+func ClearTofu() (int, error) {
+	entries := TofuEntries()
+
+	tofuStoreMu.Lock()
+	defer tofuStoreMu.Unlock()
+
+	sessionTofu = make(map[string]string)
+	for _, e := range entries {
+		tofuStore.Set(idKey(e.Domain, e.Port), "")
+		tofuStore.Set(expiryKey(e.Domain, e.Port), "")
+		tofuStore.Set(firstSeenKey(e.Domain, e.Port), "")
+	}
+	return len(entries), tofuStore.WriteConfig()
+}
+
+// Fingerprint returns the fingerprint of the cert, the same way it's stored
+// in the TOFU database.
+func Fingerprint(cert *x509.Certificate) string {
+	return certID(cert)
+}
+
+// GetFingerprint returns the stored fingerprint for the given host.
+// It will be empty if there isn't one stored for that host.
+func GetFingerprint(domain, port string) string {
+	id, _, _ := loadTofuEntry(domain, port)
+	return id
+}
+
+// idKeyHosts returns the domains and ports that the key would be the idKey of.
+// The key of a host with a port can also be read as a host without one, since
+// IPv6 addresses have colons. Only the one whose expiry is also stored is
+// really in the TOFU database, which loadTofuEntry checks.
+func idKeyHosts(key string) [][2]string {
+	hosts := [][2]string{{strings.ReplaceAll(key, "/", "."), ""}}
+	if i := strings.LastIndex(key, ":"); i != -1 {
+		port := key[i+1:]
+		if n, err := strconv.Atoi(port); err == nil && n > 0 && port != "1965" {
+			hosts = append(hosts, [2]string{strings.ReplaceAll(key[:i], "/", "."), port})
+		}
+	}
+	return hosts
+}
+
+// TofuEntries returns all the valid entries in the TOFU database,
+// sorted by domain and port.
+func TofuEntries() []TofuEntry {
+	tofuStoreMu.RLock()
+	keys := tofuStore.AllKeys()
+	tofuStoreMu.RUnlock()
+
+	entries := make([]TofuEntry, 0, len(keys)/3)
+	for _, key := range keys {
+		for _, h := range idKeyHosts(key) {
+			id, expiry, err := loadTofuEntry(h[0], h[1])
+			if err != nil {
+				// Not an entry, like the expiry or first-seen key of one
+				continue
+			}
+			tofuStoreMu.RLock()
+			firstSeen := tofuStore.GetTime(firstSeenKey(h[0], h[1]))
+			tofuStoreMu.RUnlock()
+			entries = append(entries, TofuEntry{h[0], h[1], id, expiry, firstSeen})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Domain == entries[j].Domain {
+			return entries[i].Port < entries[j].Port
+		}
+		return entries[i].Domain < entries[j].Domain
+	})
+	return entries
+}
+
 // GetExpiry returns the stored expiry date for the given host.
 // The time will be empty (zero) if there is not expiry date stored for that host.
 func GetExpiry(domain, port string) time.Time {
@@ -136,3 +278,70 @@ func GetExpiry(domain, port string) time.Time {
 
 	return tofuStore.GetTime(expiryKey(domain, port))
 }
+
+// tofuExport is an entry in an exported TOFU file.
+type tofuExport struct {
+	Domain      string    `json:"domain"`
+	Port        string    `json:"port,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	Expiry      time.Time `json:"expiry"`
+	FirstSeen   time.Time `json:"first_seen,omitempty"`
+}
+
+// ExportTofu writes all the entries in the TOFU database to the file at path,
+// as JSON, so they can be imported with ImportTofu somewhere else.
+func ExportTofu(path string) error {
+	entries := TofuEntries()
+	exported := make([]tofuExport, len(entries))
+	for i, e := range entries {
+		exported[i] = tofuExport(e)
+	}
+	jsonBytes, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, jsonBytes, 0666)
+}
+
+// ImportTofu adds the entries in the file at path, written by ExportTofu,
+// to the TOFU database. An imported entry replaces the stored one for the
+// same host. It returns how many entries were added or changed.
+func ImportTofu(path string) (int, error) {
+	jsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var imported []tofuExport
+	if err := json.Unmarshal(jsonBytes, &imported); err != nil {
+		return 0, err
+	}
+	for _, e := range imported {
+		if e.Domain == "" || len(e.Fingerprint) != sha256.Size*2 || e.Expiry.IsZero() {
+			return 0, fmt.Errorf("invalid entry for %q", e.Domain)
+		}
+	}
+
+	tofuStoreMu.Lock()
+	defer tofuStoreMu.Unlock()
+
+	changed := 0
+	for _, e := range imported {
+		id := strings.ToUpper(e.Fingerprint)
+		if tofuStore.GetString(idKey(e.Domain, e.Port)) == id {
+			continue
+		}
+		delete(sessionTofu, idKey(e.Domain, e.Port))
+		tofuStore.Set(idKey(e.Domain, e.Port), id)
+		tofuStore.Set(expiryKey(e.Domain, e.Port), e.Expiry.UTC())
+		if e.FirstSeen.IsZero() {
+			tofuStore.Set(firstSeenKey(e.Domain, e.Port), "")
+		} else {
+			tofuStore.Set(firstSeenKey(e.Domain, e.Port), e.FirstSeen.UTC())
+		}
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, tofuStore.WriteConfig()
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// useTempTofuStore replaces the TOFU database with an empty one in a temporary
+// directory. The func returned puts the old one back.
+func useTempTofuStore(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "amfora-tofu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := tofuStore
+	tofuStore = viper.New()
+	tofuStore.SetConfigFile(filepath.Join(dir, "tofu.toml"))
+	tofuStore.SetConfigType("toml")
+	return func() {
+		tofuStore = old
+		os.RemoveAll(dir) //nolint:errcheck
+	}
+}
+
+var tofuTestHosts = [][2]string{
+	{"example.com", ""},
+	{"expiry.example.com", ""},         // Looks like an expiry key
+	{"first-seen.example.org", "1966"}, // Looks like a first-seen key, with a port
+	{"::1", "1966"},                    // Colons in the host and before the port
+}
+
+// saveTofuTestHosts trusts a fake cert for each of the tofuTestHosts.
+func saveTofuTestHosts() {
+	for _, h := range tofuTestHosts {
+		saveTofuEntry(h[0], h[1], &x509.Certificate{
+			RawSubjectPublicKeyInfo: []byte(h[0] + h[1]),
+			NotAfter:                time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+}
+
+func TestIdKeyHosts(t *testing.T) {
+	for key, want := range map[string][][2]string{
+		"example/com":      {{"example.com", ""}},
+		"example/com:1966": {{"example.com:1966", ""}, {"example.com", "1966"}},
+		"::1":              {{"::1", ""}, {":", "1"}},
+		"::1:1966":         {{"::1:1966", ""}, {"::1", "1966"}},
+		"example/com:abc":  {{"example.com:abc", ""}},
+	} {
+		if got := idKeyHosts(key); !reflect.DeepEqual(got, want) {
+			t.Errorf("idKeyHosts(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestTofuEntries(t *testing.T) {
+	defer useTempTofuStore(t)()
+	saveTofuTestHosts()
+
+	entries := TofuEntries()
+	want := [][2]string{
+		{"::1", "1966"},
+		{"example.com", ""},
+		{"expiry.example.com", ""},
+		{"first-seen.example.org", "1966"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("TofuEntries() = %+v, want entries for %q", entries, want)
+	}
+	for i, e := range entries {
+		if e.Domain != want[i][0] || e.Port != want[i][1] {
+			t.Errorf("entry %d is for %q port %q, want %q port %q", i, e.Domain, e.Port, want[i][0], want[i][1])
+		}
+		if e.FirstSeen.IsZero() {
+			t.Errorf("entry %d has no first-seen date", i)
+		}
+	}
+}
+
+func TestExportImportTofu(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "tofu.json")
+
+	restore := useTempTofuStore(t)
+	saveTofuTestHosts()
+	exported := TofuEntries()
+	if err := ExportTofu(path); err != nil {
+		t.Fatalf("ExportTofu: %v", err)
+	}
+	restore()
+
+	defer useTempTofuStore(t)()
+	n, err := ImportTofu(path)
+	if err != nil {
+		t.Fatalf("ImportTofu: %v", err)
+	}
+	if n != len(exported) {
+		t.Errorf("ImportTofu added %d entries, want %d", n, len(exported))
+	}
+	imported := TofuEntries()
+	if len(imported) != len(exported) {
+		t.Fatalf("imported entries = %+v, want %+v", imported, exported)
+	}
+	for i := range exported {
+		a, b := exported[i], imported[i]
+		if a.Domain != b.Domain || a.Port != b.Port || a.Fingerprint != b.Fingerprint ||
+			!a.Expiry.Equal(b.Expiry) || !a.FirstSeen.Equal(b.FirstSeen) {
+			t.Errorf("imported entry %d = %+v, want %+v", i, b, a)
+		}
+	}
+
+	// Importing the same entries again changes nothing
+	if n, err := ImportTofu(path); err != nil || n != 0 {
+		t.Errorf("ImportTofu again = %d, %v, want 0, nil", n, err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/gdamore/tcell/v2"
+	humanize "github.com/dustin/go-humanize"
 	"github.com/makeworld-the-better-one/amfora/cache"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/rkoesters/xdg/basedir"
@@ -44,9 +44,27 @@ var TempDownloadsDir string
 var subscriptionDir string
 var SubscriptionPath string
 
+// Session
+var sessionDir string
+var SessionPath string
+var SessionsPath string // Sessions saved by name, separate from the one restored on startup
+
+// Client certificates chosen for each host, and generated ones
+var CertStore = viper.New()
+var certStoreDir string
+var certStorePath string
+var IdentitiesDir string // Where generated client certificates are stored
+
+// The Unix socket for controlling Amfora from other programs, from "remote.socket" in config.
+var RemoteSocketPath string
+
 // Command for opening HTTP(S) URLs in the browser, from "a-general.http" in config.
 var HTTPCommand []string
 
+// Command for opening mailto: URLs, from "a-general.mailto_command" in config.
+// It's empty if the addresses should be displayed instead.
+var MailtoCommand []string
+
 type MediaHandler struct {
 	Cmd      []string
 	NoPrompt bool
@@ -88,6 +106,7 @@ func Init() error {
 
 	// Search for a custom new tab
 	NewTabPath = filepath.Join(configDir, "newtab.gmi")
+	ThemesDir = filepath.Join(configDir, "themes")
 	CustomNewTab = false
 	if _, err := os.Stat(NewTabPath); err == nil {
 		CustomNewTab = true
@@ -130,6 +149,28 @@ func Init() error {
 	}
 	SubscriptionPath = filepath.Join(subscriptionDir, "subscriptions.json")
 
+	// Session dir and path
+	if runtime.GOOS == "windows" {
+		// In APPDATA beside other Amfora files
+		sessionDir = amforaAppData
+	} else {
+		// XDG data dir on POSIX systems
+		sessionDir = filepath.Join(basedir.DataHome, "amfora")
+	}
+	SessionPath = filepath.Join(sessionDir, "session.json")
+	SessionsPath = filepath.Join(sessionDir, "sessions.json")
+
+	// Client cert store dir and path
+	if runtime.GOOS == "windows" {
+		// In APPDATA beside other Amfora files
+		certStoreDir = amforaAppData
+	} else {
+		// XDG data dir on POSIX systems
+		certStoreDir = filepath.Join(basedir.DataHome, "amfora")
+	}
+	certStorePath = filepath.Join(certStoreDir, "certs.toml")
+	IdentitiesDir = filepath.Join(certStoreDir, "identities")
+
 	// *** Create necessary files and folders ***
 
 	// Config
@@ -168,6 +209,21 @@ func Init() error {
 	if err != nil {
 		return err
 	}
+	// Session
+	err = os.MkdirAll(sessionDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// Client certs
+	err = os.MkdirAll(IdentitiesDir, 0700)
+	if err != nil {
+		return err
+	}
+	f, err = os.OpenFile(certStorePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err == nil {
+		f.Close()
+	}
 
 	// *** Setup vipers ***
 
@@ -178,6 +234,13 @@ func Init() error {
 		return err
 	}
 
+	CertStore.SetConfigFile(certStorePath)
+	CertStore.SetConfigType("toml")
+	err = CertStore.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
 	BkmkStore.SetConfigFile(OldBkmkPath)
 	BkmkStore.SetConfigType("toml")
 	err = BkmkStore.ReadInConfig()
@@ -189,21 +252,56 @@ func Init() error {
 	// Setup main config
 
 	viper.SetDefault("a-general.home", "gemini://gemini.circumlunar.space")
+	viper.SetDefault("a-general.home_file", "")
+	viper.SetDefault("a-general.newtab_url", "")
+	viper.SetDefault("a-general.newtab_content", "")
 	viper.SetDefault("a-general.auto_redirect", false)
+	viper.SetDefault("a-general.max_redirects", 5)
+	viper.SetDefault("a-general.cross_site_redirects", "ask")
+	viper.SetDefault("a-general.allowed_schemes", []string{})
 	viper.SetDefault("a-general.http", "default")
+	viper.SetDefault("a-general.http_confirm", true)
+	viper.SetDefault("a-general.quit_confirm", false)
+	viper.SetDefault("a-general.mailto_command", []string{})
 	viper.SetDefault("a-general.search", "gemini://geminispace.info/search")
 	viper.SetDefault("a-general.color", true)
+	viper.SetDefault("a-general.theme", "default")
 	viper.SetDefault("a-general.ansi", true)
 	viper.SetDefault("a-general.bullets", true)
+	viper.SetDefault("a-general.bullet", "•")
+	viper.SetDefault("a-general.quote_prefix", "> ")
+	viper.SetDefault("a-general.preformatted_captions", true)
+	viper.SetDefault("a-general.fold_preformatted", 0)
 	viper.SetDefault("a-general.show_link", false)
+	viper.SetDefault("a-general.show_link_numbers", true)
+	viper.SetDefault("a-general.scheme_badges", true)
+	viper.SetDefault("a-general.subdomains_same_site", false)
+	viper.SetDefault("a-general.mark_new_links", true)
+	viper.SetDefault("a-general.security_indicator", true)
+	viper.SetDefault("a-general.response_info", true)
+	viper.SetDefault("a-general.wrap_text", false)
 	viper.SetDefault("a-general.left_margin", 0.15)
 	viper.SetDefault("a-general.max_width", 100)
+	viper.SetDefault("a-general.center_text", false)
 	viper.SetDefault("a-general.downloads", "")
 	viper.SetDefault("a-general.temp_downloads", "")
 	viper.SetDefault("a-general.page_max_size", 2097152)
 	viper.SetDefault("a-general.page_max_time", 10)
+	viper.SetDefault("a-general.stream_text", true)
+	viper.SetDefault("a-general.stream_max_size", 2097152)
+	viper.SetDefault("a-general.tables", false)
+	viper.SetDefault("a-general.mouse", false)
 	viper.SetDefault("a-general.emoji_favicons", false)
+	viper.SetDefault("a-general.link_preview", false)
 	viper.SetDefault("a-general.scrollbar", "auto")
+	viper.SetDefault("a-general.scroll_indicator", "off")
+	viper.SetDefault("a-general.scroll_indicator_char", "█")
+	viper.SetDefault("a-general.restore_session", false)
+	viper.SetDefault("a-general.page_search_case_sensitive", false)
+	viper.SetDefault("a-general.retry_slow_down", false)
+	viper.SetDefault("a-general.images", "auto")
+	viper.SetDefault("a-general.hyperlinks", "off")
+	viper.SetDefault("a-general.scroll_percentage", 75)
 	viper.SetDefault("keybindings.bind_reload", []string{"R", "Ctrl-R"})
 	viper.SetDefault("keybindings.bind_home", "Backspace")
 	viper.SetDefault("keybindings.bind_bookmarks", "Ctrl-B")
@@ -219,10 +317,46 @@ func Init() error {
 	viper.SetDefault("keybindings.bind_forward", []string{"f", "Alt-Right"})
 	viper.SetDefault("keybindings.bind_new_tab", "Ctrl-T")
 	viper.SetDefault("keybindings.bind_close_tab", "Ctrl-W")
+	viper.SetDefault("keybindings.bind_duplicate_tab", "Ctrl-Y")
 	viper.SetDefault("keybindings.bind_next_tab", "F2")
 	viper.SetDefault("keybindings.bind_prev_tab", "F1")
 	viper.SetDefault("keybindings.bind_quit", []string{"Ctrl-C", "Ctrl-Q", "q"})
 	viper.SetDefault("keybindings.bind_help", "?")
+	viper.SetDefault("keybindings.bind_search", "/")
+	viper.SetDefault("keybindings.bind_next_match", "n")
+	viper.SetDefault("keybindings.bind_prev_match", "N")
+	viper.SetDefault("keybindings.bind_reader", "Ctrl-E")
+	viper.SetDefault("keybindings.bind_command", ":")
+	viper.SetDefault("keybindings.bind_upload", "E")
+	viper.SetDefault("keybindings.bind_goto", "Ctrl-G")
+	viper.SetDefault("keybindings.bind_select", "v")
+	viper.SetDefault("keybindings.bind_rename_bookmark", "r")
+	viper.SetDefault("keybindings.bind_remove_bookmark", "x")
+	viper.SetDefault("keybindings.bind_bookmark_up", "K")
+	viper.SetDefault("keybindings.bind_bookmark_down", "J")
+	viper.SetDefault("keybindings.bind_move_tab_left", "<")
+	viper.SetDefault("keybindings.bind_move_tab_right", ">")
+	viper.SetDefault("keybindings.bind_source", "Ctrl-U")
+	viper.SetDefault("keybindings.bind_stop", "Esc")
+	viper.SetDefault("keybindings.bind_split", "Ctrl-O")
+	viper.SetDefault("keybindings.bind_split_focus", "F3")
+	viper.SetDefault("keybindings.bind_copy_page_url", "C")
+	viper.SetDefault("keybindings.bind_copy_target_url", "c")
+	viper.SetDefault("keybindings.bind_copy_heading_url", "Alt-c")
+	viper.SetDefault("keybindings.bind_new_incognito_tab", "Ctrl-P")
+	viper.SetDefault("keybindings.bind_wrap", "w")
+	viper.SetDefault("keybindings.bind_background_tab", []string{"Alt-Enter", "t"})
+	viper.SetDefault("keybindings.bind_toc", "T")
+	viper.SetDefault("keybindings.bind_reload_all", "Alt-r")
+	viper.SetDefault("keybindings.bind_source_editor", "Alt-u")
+	viper.SetDefault("keybindings.bind_links", "L")
+	viper.SetDefault("keybindings.bind_recent", "H")
+	viper.SetDefault("keybindings.bind_next_link", "]")
+	viper.SetDefault("keybindings.bind_prev_link", "[")
+	viper.SetDefault("keybindings.bind_root", "~")
+	viper.SetDefault("keybindings.bind_up", "Alt-Up")
+	viper.SetDefault("keybindings.bind_fold", "z")
+	viper.SetDefault("keybindings.bind_find_link", "'")
 	viper.SetDefault("keybindings.bind_link1", "1")
 	viper.SetDefault("keybindings.bind_link2", "2")
 	viper.SetDefault("keybindings.bind_link3", "3")
@@ -247,7 +381,19 @@ func Init() error {
 	viper.SetDefault("url-handlers.other", "off")
 	viper.SetDefault("cache.max_size", 0)
 	viper.SetDefault("cache.max_pages", 20)
-	viper.SetDefault("cache.timeout", 1800)
+	viper.SetDefault("cache.lowercase_hosts", true)
+	viper.SetDefault("cache.fold_trailing_slash", false)
+	viper.SetDefault("cache.max_age", 1800)
+	viper.SetDefault("timeouts.dial_timeout", 15)
+	viper.SetDefault("timeouts.read_timeout", 30)
+	viper.SetDefault("tls.min_version", "1.2")
+	viper.SetDefault("tls.ciphers", []string{})
+	viper.SetDefault("tls.session_resumption", true)
+	viper.SetDefault("host-proxies.rules", []string{})
+	viper.SetDefault("host-proxies.default", "off")
+	viper.SetDefault("host-proxies.bypass_localhost", true)
+	viper.SetDefault("remote.enabled", false)
+	viper.SetDefault("remote.socket", "")
 	viper.SetDefault("subscriptions.popup", true)
 	viper.SetDefault("subscriptions.update_interval", 1800)
 	viper.SetDefault("subscriptions.workers", 3)
@@ -330,28 +476,25 @@ func Init() error {
 	}
 
 	// Setup cache from config
-	cache.SetMaxSize(viper.GetInt("cache.max_size"))
+	// The size can be a number of bytes, or a string like "50MB"
+	maxSize, err := humanize.ParseBytes(viper.GetString("cache.max_size"))
+	if err != nil {
+		return fmt.Errorf("cache.max_size is invalid: %w", err)
+	}
+	cache.SetMaxSize(int(maxSize))
 	cache.SetMaxPages(viper.GetInt("cache.max_pages"))
-	cache.SetTimeout(viper.GetInt("cache.timeout"))
+	if viper.InConfig("cache.timeout") && !viper.InConfig("cache.max_age") {
+		// Old name for max_age
+		cache.SetTimeout(viper.GetInt("cache.timeout"))
+	} else {
+		cache.SetTimeout(viper.GetInt("cache.max_age"))
+	}
 
 	// Setup theme
-	configTheme := viper.Sub("theme")
-	if configTheme != nil {
-		for k, v := range configTheme.AllSettings() {
-			colorStr, ok := v.(string)
-			if !ok {
-				return fmt.Errorf(`value for "%s" is not a string: %v`, k, v)
-			}
-			color := tcell.GetColor(strings.ToLower(colorStr))
-			if color == tcell.ColorDefault {
-				return fmt.Errorf(`invalid color format for "%s": %s`, k, colorStr)
-			}
-			SetColor(k, color)
-		}
+	err = loadTheme(viper.GetString("a-general.theme"))
+	if err != nil {
+		return err
 	}
-	if viper.GetBool("a-general.color") {
-		cview.Styles.PrimitiveBackgroundColor = GetColor("bg")
-	} // Otherwise it's black by default
 
 	// Parse HTTP command
 	HTTPCommand = viper.GetStringSlice("a-general.http")
@@ -361,6 +504,10 @@ func Init() error {
 		// The new better way to is to just define a string array in config
 		HTTPCommand = strings.Fields(viper.GetString("a-general.http"))
 	}
+	MailtoCommand = viper.GetStringSlice("a-general.mailto_command")
+	if len(MailtoCommand) == 0 {
+		MailtoCommand = strings.Fields(viper.GetString("a-general.mailto_command"))
+	}
 
 	var rawMediaHandlers []struct {
 		Cmd      []string `mapstructure:"cmd"`
@@ -392,6 +539,21 @@ func Init() error {
 		}
 	}
 
+	// Find the remote control socket
+	RemoteSocketPath = viper.GetString("remote.socket")
+	if RemoteSocketPath == "" {
+		if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			RemoteSocketPath = filepath.Join(runtimeDir, "amfora.sock")
+		} else {
+			RemoteSocketPath = filepath.Join(tofuDBDir, "amfora.sock")
+		}
+	} else {
+		RemoteSocketPath, err = homedir.Expand(RemoteSocketPath)
+		if err != nil {
+			return fmt.Errorf("remote.socket path couldn't be expanded: %w", err)
+		}
+	}
+
 	// Parse scrollbar options
 	switch viper.GetString("a-general.scrollbar") {
 	case "never":
@@ -16,21 +16,52 @@ var defaultConf = []byte(`# This is the default config file.
 # Press Ctrl-H to access it
 home = "gemini://gemini.circumlunar.space"
 
-# Follow up to 5 Gemini redirects without prompting.
-# A prompt is always shown after the 5th redirect and for redirects to protocols other than Gemini.
+# A gemtext file on your computer that's displayed at about:home, like your own
+# start page. It can be used as the home page above, by setting home = "about:home".
+# The file is read again each time the page is opened.
+home_file = ""
+
+# A URL that's loaded in every new tab, like the home page above.
+# If it's not set, new tabs show the new tab page instead.
+newtab_url = ""
+
+# The gemtext shown on the new tab page. If it's not set, the newtab.gmi file
+# in the config folder is used if there is one, or the default new tab page.
+# TOML multi-line strings can be used, between triple quotes: """
+newtab_content = ""
+
+# Follow redirects without prompting, up to max_redirects of them.
+# A prompt is still shown for redirects to another scheme or host, see cross_site_redirects.
 # If set to false, a prompt will be shown before following redirects.
 auto_redirect = false
 
+# The most redirects that are followed for one request. Going past it shows an
+# error with the URLs that were redirected through, which stops redirect loops.
+max_redirects = 5
+
+# What to do with a redirect to a different scheme or host, like from gemini:// to https://.
+# "ask" shows a prompt before following it, even if auto_redirect is on, and
+# "block" always shows an error instead.
+cross_site_redirects = "ask"
+
+# The URL schemes that can be opened, like ["gemini", "file"]. Other URLs aren't
+# connected to or opened with another program, and an error is shown instead.
+# about: pages can always be opened. An empty list allows all schemes.
+allowed_schemes = []
+
 # What command to run to open a HTTP(S) URL.
 # Set to "default" to try to guess the browser, or set to "off" to not open HTTP(S) URLs.
-# If a command is set, than the URL will be added (in quotes) to the end of the command.
-# A space will be prepended to the URL.
+# When HTTP(S) URLs can't be opened, the URL is displayed so it can be copied.
+# If a command is set, any %s in it is replaced with the URL. Otherwise the URL
+# will be added to the end of the command. It's passed to the command as is,
+# without a shell, so spaces and quotes in the URL are safe.
 #
 # The best to define a command is using a string array.
 # Examples:
 # http = ['firefox']
 # http = ['custom-browser', '--flag', '--option=2']
 # http = ['/path/with spaces/in it/firefox']
+# http = ['xdg-open', '%s']
 #
 # Note the use of single quotes, so that backslashes will not be escaped.
 # Using just a string will also work, but it is deprecated, and will degrade if
@@ -38,27 +69,121 @@ auto_redirect = false
 
 http = 'default'
 
+# Whether to ask in the bottom bar before opening a HTTP(S) URL with the command above
+http_confirm = true
+
+# Whether to ask in the bottom bar before quitting, when the quit key is pressed or
+# the last tab is closed, in case it was by accident
+quit_confirm = false
+
+# What command to run to open a mailto: URL, to write an email. It's set like the
+# http command above, and any %s in it is replaced with the URL. Amfora asks in the
+# bottom bar before running it. If it's empty, the addresses, subject, and body of
+# the URL are displayed instead, so the addresses can be copied.
+# Setting mailto in the url-handlers section below is used over this.
+# Examples:
+# mailto_command = ['thunderbird', '-compose', '%s']
+# mailto_command = ['xdg-email', '%s']
+mailto_command = []
+
 # Any URL that will accept a query string can be put here
 search = "gemini://geminispace.info/search"
 
 # Whether colors will be used in the terminal
 color = true
 
+# The colors to use, if color is enabled. The built-in themes are "default",
+# "light", and "solarized-dark". This can also be the name of a TOML or JSON
+# theme file in the themes directory next to this config file, without its
+# extension, or the path to one. Theme files set the same keys as the [theme]
+# section below, at the top level or in a [theme] section of their own.
+# Colors set in [theme] here are always used over the ones from the theme.
+# The theme can be changed while Amfora is running with the theme command.
+theme = "default"
+
 # Whether ANSI color codes from the page content should be rendered
 ansi = true
 
 # Whether to replace list asterisks with unicode bullets
 bullets = true
 
+# The bullet shown before list items, if bullets are enabled.
+# Wrapped lines of an item are indented to line up after it.
+bullet = "•"
+
+# What to put at the start of each line of a quote, which is also in the quote_text color.
+# For example, "│ " for a vertical bar, or "    " to indent quotes instead.
+quote_prefix = "> "
+
+# Whether the alt text of preformatted blocks, on the line that starts them,
+# is shown as a dim caption above the block. It usually describes what's in it,
+# like ASCII art.
+preformatted_captions = true
+
+# Preformatted blocks with more lines than this, like long code listings or logs,
+# are folded into one line that says what's in them. Press the bind_fold key to
+# unfold the block at the top of the screen, or fold it again.
+# Set to 0 to never fold them.
+fold_preformatted = 0
+
 # Whether to show link after link text
 show_link = false
 
-# A number from 0 to 1, indicating what percentage of the terminal width the left margin should take up.
+# Whether to show the number of each link before its text, like [1].
+# Links can be followed by their number either way.
+# It can be changed while browsing with the "link-numbers" command.
+show_link_numbers = true
+
+# Whether links to other schemes than gemini://, like http:// or gopher://, have
+# a badge before their text with the scheme, like [http], so it's clear they leave
+# Gemini. The colors of the badges can be set in the theme section below.
+scheme_badges = true
+
+# Gemini links to other hosts than the page's are in the offsite_link color, set in
+# the theme section below, so they can be told apart from links within the site.
+# Whether links to subdomains of the page's host, or to the host it's a subdomain of,
+# are treated as part of the same site. Otherwise they're off-site links too.
+subdomains_same_site = false
+
+# Whether links that weren't on a page the last time it was loaded are marked in the
+# new_link color when it's reloaded, or in bold without colors. This shows what's new
+# on pages like feeds. A link stops being marked once it's followed.
+mark_new_links = true
+
+# Whether the bottom bar shows how the page was loaded before its URL. A filled circle
+# is for TLS with a trusted cert, a triangle is for a cert that's only trusted until
+# Amfora is closed, and an empty circle is for schemes without TLS, like Spartan and
+# Gopher. The colors can be set in the theme section below.
+security_indicator = true
+
+# Whether the bottom bar shows the status code, mediatype, and size of the response
+# for a couple seconds after a page is loaded, like "20 text/gemini, 4.2 kB".
+# This helps to see what a server sent.
+response_info = true
+
+# Whether long lines of plain text documents are wrapped to fit, instead of
+# scrolling horizontally to see them. Gemtext is always wrapped, except for
+# preformatted blocks. It can be changed while browsing with bind_wrap,
+# or the "wrap" command.
+wrap_text = false
+
+# The size of the left margin. A number from 0 to 1 is the fraction of the terminal width it takes up,
+# and a number of 1 or more is a fixed number of columns. It never takes up more than half the terminal.
+# It can be changed while browsing by typing "left-margin" and the new value in the command palette.
 left_margin = 0.15
 
 # The max number of columns to wrap a page's text to. Preformatted blocks are not wrapped.
 max_width = 100
 
+# Whether the text is centered in the terminal, with the space left by max_width
+# on both sides, instead of starting after the left margin. The left margin is
+# never smaller than left_margin either way.
+center_text = false
+
+# What percentage of the terminal height is scrolled by the page up and page down keys.
+# Set to 100 for full-page jumps. It always scrolls by at least one line.
+scroll_percentage = 75
+
 # 'downloads' is the path to a downloads folder.
 # An empty value means the code will find the default downloads folder for your system.
 # If the path does not exist it will be created.
@@ -67,20 +192,78 @@ downloads = ''
 
 # Max size for displayable content in bytes - after that size a download window pops up
 page_max_size = 2097152  # 2 MiB
-# Max time it takes to load a page in seconds - after that a download window pops up
+# Max time it takes to load a page in seconds, once the server starts responding - after that a download window pops up
+# See the timeouts section for how long servers can take to respond.
 page_max_time = 10
 
-# Whether to replace tab numbers with emoji favicons, which are cached.
+# Whether plain text and gemtext pages that are still loading after half a second are displayed
+# as they arrive, for servers that keep sending, like for logs. They aren't timed out then,
+# and the stop key (Esc by default) stops following them, keeping what has loaded.
+# The bottom bar shows when a page is streaming. Pages that end are cached like any other.
+stream_text = true
+# Max size in bytes of the text kept for those pages. The oldest lines are removed after that,
+# which the bottom bar shows, and the page isn't cached then.
+stream_max_size = 2097152  # 2 MiB
+
+# Whether lines of regular text with | between columns are displayed as tables, with the columns lined up.
+# Tables that are wider than the page are displayed as they are.
+tables = false
+
+# Whether the mouse wheel scrolls the page, and clicking a link follows it.
+# When enabled, your terminal might need a modifier key like Shift to be held to select text.
+mouse = false
+
+# Whether to show the emoji favicons of Gemini capsules in the tab bar, next to the tab titles.
+# Each host's favicon.txt is requested once in the background, and the result is cached.
+# These extra requests aren't made at all when this is disabled.
 emoji_favicons = false
 
+# Whether to show the status and mediatype of a Gemini link when it's selected, before following it.
+# This makes a request for each link you select, and closes it after the response header.
+link_preview = false
+
 # When a scrollbar appears. "never", "auto", and "always" are the only valid values.
 # "auto" means the scrollbar only appears when the page is longer than the window.
 scrollbar = "auto"
 
+# What's shown at the right of the bottom bar for how far the page is scrolled down.
+# "percent" shows a percentage, "bar" shows a bar that fills up, and "off" shows nothing.
+scroll_indicator = "off"
+# The character the bar is made of, for the "bar" scroll indicator. It must be one column wide.
+scroll_indicator_char = "█"
+
+# Whether the history of each tab is saved when quitting, and the tabs are reopened next time.
+# Pages in reopened tabs aren't loaded until you switch to that tab.
+restore_session = false
+
+# Whether searching within a page matches upper and lower case letters exactly.
+page_search_case_sensitive = false
+
+# Whether to automatically retry the request after a server says to slow down (status 44),
+# once the number of seconds the server asked for has passed. The number of seconds
+# is always shown in the bottom bar. The retry is cancelled if you go to another page.
+retry_slow_down = false
+
+# Whether PNG and JPEG images are displayed inline, instead of offering to download them.
+# "auto" detects whether the terminal supports Kitty or Sixel graphics.
+# "kitty" and "sixel" force a graphics protocol, and "off" disables images.
+# Images aren't displayed inside terminal multiplexers like tmux, unless forced.
+images = "auto"
+
+# Whether links are also written as terminal hyperlinks (OSC 8), so they can be opened by the terminal,
+# usually with Ctrl-click. "auto" detects whether the terminal supports them, "on" forces them,
+# and "off" disables them. Terminals that don't support them might display garbled text.
+hyperlinks = "off"
+
 
 [auth]
 # Authentication settings
 # Note the use of single quotes for values, so that backslashes will not be escaped.
+#
+# When a site asks for a client certificate, you can choose one of your identities
+# or generate a new one, and it will be remembered for that site.
+# See about:certs for the identity used by each site.
+# Certificates set below are always used instead of a remembered identity.
 
 [auth.certs]
 # Client certificates
@@ -108,6 +291,8 @@ scrollbar = "auto"
 # bind_tab0 = ")"
 
 # Whitespace is not allowed in any of the keybindings! Use 'Space' and 'Tab' to bind to those keys.
+# Keys that aren't known, and keys bound to more than one command, are listed in a warning
+# when Amfora starts. If a key is bound in this file and also by a default, this file wins.
 # Multiple keys can be bound to one command, just use a TOML array.
 # To add the Alt modifier, the binding must start with Alt-, should be reasonably universal
 # Ctrl- won't work on all keys, see this for a list:
@@ -149,18 +334,54 @@ scrollbar = "auto"
 # bind_add_bookmark
 # bind_save
 # bind_reload
+# bind_reload_all: for loading the pages of all the tabs again
 # bind_back
 # bind_forward
 # bind_pgup
 # bind_pgdn
 # bind_new_tab
 # bind_close_tab
+# bind_duplicate_tab: for opening a copy of the current tab, including its history
+# bind_new_incognito_tab: for opening a new tab whose pages, history, and inputs aren't kept
+# bind_background_tab: for opening the selected link in a new tab, without switching to it
+# bind_wrap: for wrapping or not wrapping the long lines of plain text documents
+# bind_toc: for the table of contents, the headings of the page that can be searched and jumped to
+# bind_links: for the list of the links on the page, which can be searched and followed
+# bind_recent: for the list of the pages visited recently in any tab, which can be searched and opened
+# bind_next_link: for selecting the next link from where the page is scrolled to, which Enter follows
+# bind_prev_link: for selecting the previous link from where the page is scrolled to
+# bind_root: for going to the root of the site, like gemini://example.com/, or of the selected link's site
+# bind_up: for going up a directory, from gemini://example.com/a/b/c to gemini://example.com/a/b/
+# bind_fold: for folding or unfolding the preformatted block at the top of the screen, see fold_preformatted
+# bind_find_link: for typing part of the text or URL of a link, to highlight it and follow it with Enter
 # bind_next_tab
 # bind_prev_tab
 # bind_quit
 # bind_help
 # bind_sub: for viewing the subscriptions page
 # bind_add_sub
+# bind_search: for searching within the current page
+# bind_next_match
+# bind_prev_match
+# bind_reader: for hiding and showing the link lines of the current page
+# bind_command: for opening the command palette
+# bind_upload: for editing the current page and uploading it with Titan
+# bind_goto: for jumping to a line number or percentage of the current page
+# bind_select: for selecting lines of the current page, to copy their text
+# bind_rename_bookmark: for renaming the selected bookmark on the bookmarks page
+# bind_remove_bookmark: for removing the selected bookmark on the bookmarks page
+# bind_bookmark_up: for moving the selected bookmark up on the bookmarks page
+# bind_bookmark_down: for moving the selected bookmark down on the bookmarks page
+# bind_move_tab_left: for moving the current tab one place to the left
+# bind_move_tab_right: for moving the current tab one place to the right
+# bind_source: for showing the source of the current page as it was received
+# bind_source_editor: for opening the source of the current page in your editor, read-only
+# bind_stop: for stopping the page that's loading in the current tab
+# bind_split: for showing a new tab next to the current one, or going back to one tab if already split
+# bind_split_focus: for moving to the other tab when the view is split
+# bind_copy_page_url: for copying the URL of the current page
+# bind_copy_target_url: for copying the URL of the selected link, or the first one on the screen
+# bind_copy_heading_url: for copying the URL of the current page, with a fragment linking to the heading at the top of the screen
 
 [url-handlers]
 # Allows setting the commands to run for various URL schemes.
@@ -254,11 +475,52 @@ other = 'off'
 # Increase the cache size to speed up browsing at the expense of memory
 # Zero values mean there is no limit
 
-max_size = 0  # Size in bytes
+max_size = 0  # Size in bytes, or a string like "50MB"
 max_pages = 30 # The maximum number of pages the cache will store
 
-# How long a page will stay in cache, in seconds.
-timeout = 1800 # 30 mins
+# How long a page will stay in cache, in seconds. After that it's downloaded again.
+# Zero means pages stay until they're removed to make room for others.
+# This used to be called timeout, which still works.
+max_age = 1800 # 30 mins
+
+# URLs that point to the same page are cached and kept in the recent and tab histories
+# once, even if they're written differently. The default port is always ignored, and
+# these options change what else is. The URL shown is still the one you went to.
+# TOFU certificates aren't affected, they're always kept by host and port, ignoring
+# the case of the host and the default port.
+# Whether hosts are the same whatever their case, like Example.com and example.com
+lowercase_hosts = true
+# Whether a path with and without a slash at the end is the same page, like /dir and /dir/.
+# Some servers show different pages for them, so this is off by default.
+fold_trailing_slash = false
+
+[timeouts]
+# How long in seconds connecting to a server can take, including the TLS handshake for Gemini
+dial_timeout = 15
+# How long in seconds a server can take to start responding, once the request is sent
+read_timeout = 30
+
+# The timeouts can also be set for one scheme, like gemini, gopher, spartan, finger, nex, or titan.
+# E.g. to give up on Gopher servers sooner:
+# [timeouts.gopher]
+# dial_timeout = 5
+# read_timeout = 10
+
+[tls]
+# The oldest TLS version servers can use, "1.2" or "1.3".
+# Many Gemini servers only support 1.2, so they can't be connected to with "1.3".
+min_version = "1.2"
+
+# The cipher suites servers can use with TLS 1.2 or 1.3, by their names, like:
+# ciphers = ["TLS_AES_128_GCM_SHA256", "TLS_CHACHA20_POLY1305_SHA256", "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305"]
+# An empty list allows all the ones Go supports by default.
+# Connections to servers that use something that isn't allowed are closed, with an error.
+ciphers = []
+
+# Resume TLS sessions with Gemini servers, so connecting to them again is faster.
+# Sessions aren't resumed when a client certificate is used.
+# How many handshakes were resumed is shown on the about:cache page.
+session_resumption = true
 
 [proxies]
 # Allows setting a Gemini proxy for different schemes.
@@ -272,6 +534,46 @@ timeout = 1800 # 30 mins
 # the url-handlers section.
 #
 # Note that HTTP and HTTPS are treated as separate protocols here.
+#
+# Gopher, Finger, and Nex are supported without a proxy, but a proxy set for one will be used instead.
+
+
+[host-proxies]
+# Proxies to use for certain hosts, no matter the scheme of the URL.
+# Each rule is a host pattern and the proxy to use for it, separated by a space.
+# The first rule that matches the host is used. Patterns can be a hostname,
+# "*.example.com" which matches example.com and all its subdomains, or "*".
+#
+# The proxy can be a SOCKS5 proxy, which is used for every protocol:
+#   "*.onion socks5://127.0.0.1:9050"
+# Or a Gemini proxy, which is only used for gemini:// URLs:
+#   "example.com proxy.example.net:1965"
+# Or "off", to not use a proxy for those hosts.
+#
+# SOCKS5 proxies use port 1080 if no port is given, and Gemini proxies port 1965.
+# Proxies that need a username and password aren't supported.
+rules = []
+
+# The proxy to use for hosts that don't match any rule, in the same format.
+default = "off"
+
+# Don't use the default proxy for localhost and loopback addresses.
+# Rules are still used for them.
+bypass_localhost = true
+
+
+[remote]
+# A Unix socket that other programs can use to control Amfora, for example
+# so a launcher or window manager can open URLs in it. Each line sent to the
+# socket is a JSON command, and a JSON object is sent back for each one:
+#   {"action": "open", "url": "gemini://example.com/"}  opens the URL in a new tab
+#   {"action": "tab", "index": 2}  switches to the tab numbered 2 in the tab bar
+# The response is {"ok": true}, or {"ok": false, "error": "..."} if the command failed.
+enabled = false
+
+# Where the socket is made. It defaults to amfora.sock in $XDG_RUNTIME_DIR,
+# or in the cache folder if that isn't set.
+socket = ""
 
 
 [subscriptions]
@@ -319,6 +621,10 @@ entries_per_page = 20
 # hdg_1 = "green"
 # hdg_2 = "#5f0000"
 
+# The same keys are used in theme files, see the theme option above.
+# For example, a JSON theme file might have:
+# {"bg": "#ffffff", "regular_text": "#000000", "amfora_link": "#005fd7"}
+
 # Available keys to set:
 
 # bg: background for pages, tab row, app in general
@@ -328,18 +634,30 @@ entries_per_page = 20
 # bottombar_text: The color of the text you type
 # bottombar_bg
 # scrollbar: The scrollbar that appears on the right for long pages
+# security_tls: The indicator before the URL for pages loaded with TLS, see security_indicator
+# security_session: The indicator for pages from a server whose cert is only trusted for now
+# security_plain: The indicator for pages loaded without TLS
 
 # hdg_1
 # hdg_2
 # hdg_3
 # amfora_link: A link that Amfora supports viewing. For now this is only gemini://
+# offsite_link: A gemini:// link to another host than the current page, see subdomains_same_site
 # foreign_link: HTTP(S), Gopher, etc
 # link_number: The silver number that appears to the left of a link
+# scheme_badge: The badge before links to other schemes, like [http], see scheme_badges
+# scheme_badge_SCHEME: The badge for a single scheme, like scheme_badge_gopher, instead of scheme_badge
+# new_link: Links that weren't on a page before it was reloaded, see mark_new_links
 # regular_text: Normal gemini text, and plaintext documents
 # quote_text
 # preformatted_text
 # list_text
 
+# ansi_black, ansi_red, ansi_green, ansi_yellow, ansi_blue, ansi_magenta, ansi_cyan, ansi_white,
+# and the same with bright, like ansi_bright_red: The 16 ANSI colors used by pages with ANSI
+# color codes, see the ansi option. They aren't changed unless they're set here, and the
+# 256 colors after them and truecolor codes are never changed.
+
 # btn_bg: The bg color for all modal buttons
 # btn_text: The text color for all modal buttons
 
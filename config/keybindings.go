@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
@@ -52,6 +54,42 @@ const (
 	CmdHelp
 	CmdSub
 	CmdAddSub
+	CmdSearch
+	CmdNextMatch
+	CmdPrevMatch
+	CmdReader
+	CmdDuplicateTab
+	CmdCommand
+	CmdUpload
+	CmdGoto
+	CmdSelect
+	CmdRenameBkmk
+	CmdRemoveBkmk
+	CmdBkmkUp
+	CmdBkmkDown
+	CmdMoveTabLeft
+	CmdMoveTabRight
+	CmdSource
+	CmdStop
+	CmdSplit
+	CmdSplitFocus
+	CmdCopyPageURL
+	CmdCopyTargetURL
+	CmdNewIncognitoTab
+	CmdWrap
+	CmdBackgroundTab
+	CmdTOC
+	CmdReloadAll
+	CmdSourceEditor
+	CmdLinks
+	CmdRecent
+	CmdNextLink
+	CmdPrevLink
+	CmdRoot
+	CmdUp
+	CmdCopyHeadingURL
+	CmdFold
+	CmdFindLink
 )
 
 type keyBinding struct {
@@ -63,6 +101,14 @@ type keyBinding struct {
 // Map of active keybindings to commands.
 var bindings map[keyBinding]Command
 
+// Map of commands to their config keys, used to name them in KeyWarnings.
+var bindingNames map[Command]string
+
+// KeyWarnings describes the keybindings in the config that couldn't be used,
+// either because the key is unknown, or it's bound to more than one command.
+// It's set by KeyInit, and should be displayed to the user.
+var KeyWarnings []string
+
 // inversion of tcell.KeyNames, used to simplify config parsing.
 // used by parseBinding() below.
 var tcellKeys map[string]tcell.Key
@@ -132,7 +178,9 @@ func parseBinding(cmd Command, binding string) {
 	} else {
 		var ok bool
 		k, ok = tcellKeys[binding]
-		if !ok { // Bad keybinding!  Quietly ignore...
+		if !ok { // Bad keybinding! Ignore it, but let the user know
+			KeyWarnings = append(KeyWarnings,
+				fmt.Sprintf("%s: unknown key %q", bindingName(cmd), binding))
 			return
 		}
 		if strings.HasPrefix(binding, "Ctrl") {
@@ -140,42 +188,108 @@ func parseBinding(cmd Command, binding string) {
 		}
 	}
 
-	bindings[keyBinding{k, m, r}] = cmd
+	kb := keyBinding{k, m, r}
+	if other, ok := bindings[kb]; ok && other != cmd {
+		// The key is already bound, the one set in the config wins over a default
+		winner := other
+		if viper.InConfig(bindingNames[cmd]) && !viper.InConfig(bindingNames[other]) {
+			winner = cmd
+		}
+		s, _ := keyBindingToString(kb)
+		KeyWarnings = append(KeyWarnings, fmt.Sprintf("%q is bound to both %s and %s, %s is used",
+			s, bindingName(other), bindingName(cmd), bindingName(winner)))
+		if winner == other {
+			return
+		}
+	}
+	bindings[kb] = cmd
+}
+
+// bindingName returns the name of the config option for the command.
+func bindingName(cmd Command) string {
+	return strings.TrimPrefix(bindingNames[cmd], "keybindings.")
+}
+
+// sortedCommands returns the commands of the map in order, so that
+// keybindings are always parsed in the same order.
+func sortedCommands(m map[Command]string) []Command {
+	cmds := make([]Command, 0, len(m))
+	for c := range m {
+		cmds = append(cmds, c)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i] < cmds[j] })
+	return cmds
 }
 
 // Generate the bindings map from the TOML configuration file.
 // Called by config.Init()
 func KeyInit() {
 	configBindings := map[Command]string{
-		CmdLink1:       "keybindings.bind_link1",
-		CmdLink2:       "keybindings.bind_link2",
-		CmdLink3:       "keybindings.bind_link3",
-		CmdLink4:       "keybindings.bind_link4",
-		CmdLink5:       "keybindings.bind_link5",
-		CmdLink6:       "keybindings.bind_link6",
-		CmdLink7:       "keybindings.bind_link7",
-		CmdLink8:       "keybindings.bind_link8",
-		CmdLink9:       "keybindings.bind_link9",
-		CmdLink0:       "keybindings.bind_link0",
-		CmdBottom:      "keybindings.bind_bottom",
-		CmdEdit:        "keybindings.bind_edit",
-		CmdHome:        "keybindings.bind_home",
-		CmdBookmarks:   "keybindings.bind_bookmarks",
-		CmdAddBookmark: "keybindings.bind_add_bookmark",
-		CmdSave:        "keybindings.bind_save",
-		CmdReload:      "keybindings.bind_reload",
-		CmdBack:        "keybindings.bind_back",
-		CmdForward:     "keybindings.bind_forward",
-		CmdPgup:        "keybindings.bind_pgup",
-		CmdPgdn:        "keybindings.bind_pgdn",
-		CmdNewTab:      "keybindings.bind_new_tab",
-		CmdCloseTab:    "keybindings.bind_close_tab",
-		CmdNextTab:     "keybindings.bind_next_tab",
-		CmdPrevTab:     "keybindings.bind_prev_tab",
-		CmdQuit:        "keybindings.bind_quit",
-		CmdHelp:        "keybindings.bind_help",
-		CmdSub:         "keybindings.bind_sub",
-		CmdAddSub:      "keybindings.bind_add_sub",
+		CmdLink1:           "keybindings.bind_link1",
+		CmdLink2:           "keybindings.bind_link2",
+		CmdLink3:           "keybindings.bind_link3",
+		CmdLink4:           "keybindings.bind_link4",
+		CmdLink5:           "keybindings.bind_link5",
+		CmdLink6:           "keybindings.bind_link6",
+		CmdLink7:           "keybindings.bind_link7",
+		CmdLink8:           "keybindings.bind_link8",
+		CmdLink9:           "keybindings.bind_link9",
+		CmdLink0:           "keybindings.bind_link0",
+		CmdBottom:          "keybindings.bind_bottom",
+		CmdEdit:            "keybindings.bind_edit",
+		CmdHome:            "keybindings.bind_home",
+		CmdBookmarks:       "keybindings.bind_bookmarks",
+		CmdAddBookmark:     "keybindings.bind_add_bookmark",
+		CmdSave:            "keybindings.bind_save",
+		CmdReload:          "keybindings.bind_reload",
+		CmdBack:            "keybindings.bind_back",
+		CmdForward:         "keybindings.bind_forward",
+		CmdPgup:            "keybindings.bind_pgup",
+		CmdPgdn:            "keybindings.bind_pgdn",
+		CmdNewTab:          "keybindings.bind_new_tab",
+		CmdCloseTab:        "keybindings.bind_close_tab",
+		CmdNextTab:         "keybindings.bind_next_tab",
+		CmdPrevTab:         "keybindings.bind_prev_tab",
+		CmdQuit:            "keybindings.bind_quit",
+		CmdHelp:            "keybindings.bind_help",
+		CmdSub:             "keybindings.bind_sub",
+		CmdAddSub:          "keybindings.bind_add_sub",
+		CmdSearch:          "keybindings.bind_search",
+		CmdNextMatch:       "keybindings.bind_next_match",
+		CmdPrevMatch:       "keybindings.bind_prev_match",
+		CmdReader:          "keybindings.bind_reader",
+		CmdDuplicateTab:    "keybindings.bind_duplicate_tab",
+		CmdCommand:         "keybindings.bind_command",
+		CmdUpload:          "keybindings.bind_upload",
+		CmdGoto:            "keybindings.bind_goto",
+		CmdSelect:          "keybindings.bind_select",
+		CmdRenameBkmk:      "keybindings.bind_rename_bookmark",
+		CmdRemoveBkmk:      "keybindings.bind_remove_bookmark",
+		CmdBkmkUp:          "keybindings.bind_bookmark_up",
+		CmdBkmkDown:        "keybindings.bind_bookmark_down",
+		CmdMoveTabLeft:     "keybindings.bind_move_tab_left",
+		CmdMoveTabRight:    "keybindings.bind_move_tab_right",
+		CmdSource:          "keybindings.bind_source",
+		CmdStop:            "keybindings.bind_stop",
+		CmdSplit:           "keybindings.bind_split",
+		CmdSplitFocus:      "keybindings.bind_split_focus",
+		CmdCopyPageURL:     "keybindings.bind_copy_page_url",
+		CmdCopyTargetURL:   "keybindings.bind_copy_target_url",
+		CmdNewIncognitoTab: "keybindings.bind_new_incognito_tab",
+		CmdWrap:            "keybindings.bind_wrap",
+		CmdBackgroundTab:   "keybindings.bind_background_tab",
+		CmdTOC:             "keybindings.bind_toc",
+		CmdReloadAll:       "keybindings.bind_reload_all",
+		CmdSourceEditor:    "keybindings.bind_source_editor",
+		CmdLinks:           "keybindings.bind_links",
+		CmdRecent:          "keybindings.bind_recent",
+		CmdNextLink:        "keybindings.bind_next_link",
+		CmdPrevLink:        "keybindings.bind_prev_link",
+		CmdRoot:            "keybindings.bind_root",
+		CmdUp:              "keybindings.bind_up",
+		CmdCopyHeadingURL:  "keybindings.bind_copy_heading_url",
+		CmdFold:            "keybindings.bind_fold",
+		CmdFindLink:        "keybindings.bind_find_link",
 	}
 	// This is split off to allow shift_numbers to override bind_tab[1-90]
 	// (This is needed for older configs so that the default bind_tab values
@@ -194,13 +308,21 @@ func KeyInit() {
 	}
 	tcellKeys = make(map[string]tcell.Key)
 	bindings = make(map[keyBinding]Command)
+	bindingNames = make(map[Command]string)
+	KeyWarnings = make([]string, 0)
+	for c, name := range configBindings {
+		bindingNames[c] = name
+	}
+	for c, name := range configTabNBindings {
+		bindingNames[c] = name
+	}
 
 	for k, kname := range tcell.KeyNames {
 		tcellKeys[kname] = k
 	}
 
-	for c, allb := range configBindings {
-		for _, b := range viper.GetStringSlice(allb) {
+	for _, c := range sortedCommands(configBindings) {
+		for _, b := range viper.GetStringSlice(configBindings[c]) {
 			parseBinding(c, b)
 		}
 	}
@@ -212,8 +334,8 @@ func KeyInit() {
 			bindings[keyBinding{tcell.KeyRune, 0, r}] = CmdTab1 + Command(i)
 		}
 	} else {
-		for c, allb := range configTabNBindings {
-			for _, b := range viper.GetStringSlice(allb) {
+		for _, c := range sortedCommands(configTabNBindings) {
+			for _, b := range viper.GetStringSlice(configTabNBindings[c]) {
 				parseBinding(c, b)
 			}
 		}
@@ -2,17 +2,36 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
 )
 
 // Functions to allow themeing configuration.
 // UI element colors are mapped to a string key, such as "error" or "tab_bg"
 // These are the same keys used in the config file.
+//
+// A theme is chosen by name with the a-general.theme option. It can be one of
+// the builtinThemes, or a TOML or JSON file that sets the same keys as the
+// theme section of the config, at the top level or in a theme section of its own.
+// Colors set in the config are used over the theme's.
+
+// ThemesDir is where theme files can be found by their name, without the extension.
+var ThemesDir string
 
 var themeMu = sync.RWMutex{}
-var theme = map[string]tcell.Color{
+var theme = copyTheme(defaultTheme)
+
+// themeVersion is changed each time the theme is changed by SetTheme,
+// so content that was formatted with the old colors can be found.
+var themeVersion int
+
+var defaultTheme = map[string]tcell.Color{
 	// Default values below
 
 	"bg":              tcell.ColorBlack, // Used for cview.Styles.PrimitiveBackgroundColor
@@ -23,6 +42,10 @@ var theme = map[string]tcell.Color{
 	"bottombar_bg":    tcell.ColorWhite,
 	"scrollbar":       tcell.ColorWhite,
 
+	"security_tls":     tcell.ColorGreen,
+	"security_session": tcell.Color130, // xterm:DarkOrange3, #af5f00
+	"security_plain":   tcell.ColorBlack,
+
 	// Modals
 	"btn_bg":   tcell.ColorNavy, // All modal buttons
 	"btn_text": tcell.ColorWhite,
@@ -57,20 +80,222 @@ var theme = map[string]tcell.Color{
 	"hdg_2":             tcell.ColorLime,
 	"hdg_3":             tcell.ColorFuchsia,
 	"amfora_link":       tcell.Color33, // xterm:DodgerBlue1, #0087ff
+	"offsite_link":      tcell.Color38, // xterm:DeepSkyBlue2, #00afd7
 	"foreign_link":      tcell.Color92, // xterm:DarkViolet, #8700d7
 	"link_number":       tcell.ColorSilver,
+	"scheme_badge":      tcell.ColorGray,
+	"new_link":          tcell.Color214, // xterm:Orange1, #ffaf00
 	"regular_text":      tcell.ColorWhite,
 	"quote_text":        tcell.ColorWhite,
 	"preformatted_text": tcell.Color229, // xterm:Wheat1, #ffffaf
 	"list_text":         tcell.ColorWhite,
 }
 
+// builtinThemes can be chosen by name instead of using a file.
+// They only set the colors that are different from the defaultTheme.
+var builtinThemes = map[string]map[string]tcell.Color{
+	"default": {},
+	"light": {
+		"bg":                tcell.NewHexColor(0xffffff),
+		"tab_divider":       tcell.NewHexColor(0x000000),
+		"bottombar_label":   tcell.NewHexColor(0x5fafaf),
+		"bottombar_text":    tcell.NewHexColor(0xffffff),
+		"bottombar_bg":      tcell.NewHexColor(0x303030),
+		"scrollbar":         tcell.NewHexColor(0x000000),
+		"security_tls":      tcell.NewHexColor(0x5faf5f),
+		"security_session":  tcell.NewHexColor(0xffaf00),
+		"security_plain":    tcell.NewHexColor(0xffffff),
+		"hdg_1":             tcell.NewHexColor(0xaf0000),
+		"hdg_2":             tcell.NewHexColor(0x008700),
+		"hdg_3":             tcell.NewHexColor(0x870087),
+		"amfora_link":       tcell.NewHexColor(0x005fd7),
+		"offsite_link":      tcell.NewHexColor(0x0087af),
+		"foreign_link":      tcell.NewHexColor(0x8700af),
+		"link_number":       tcell.NewHexColor(0x767676),
+		"scheme_badge":      tcell.NewHexColor(0x767676),
+		"new_link":          tcell.NewHexColor(0xaf5f00),
+		"regular_text":      tcell.NewHexColor(0x000000),
+		"quote_text":        tcell.NewHexColor(0x444444),
+		"preformatted_text": tcell.NewHexColor(0x875f00),
+		"list_text":         tcell.NewHexColor(0x000000),
+	},
+	// https://ethanschoonover.com/solarized/
+	"solarized-dark": {
+		"bg":                tcell.NewHexColor(0x002b36),
+		"tab_num":           tcell.NewHexColor(0x2aa198),
+		"tab_divider":       tcell.NewHexColor(0x586e75),
+		"bottombar_label":   tcell.NewHexColor(0x2aa198),
+		"bottombar_text":    tcell.NewHexColor(0x93a1a1),
+		"bottombar_bg":      tcell.NewHexColor(0x073642),
+		"scrollbar":         tcell.NewHexColor(0x586e75),
+		"security_tls":      tcell.NewHexColor(0x859900),
+		"security_session":  tcell.NewHexColor(0xb58900),
+		"security_plain":    tcell.NewHexColor(0x93a1a1),
+		"btn_bg":            tcell.NewHexColor(0x073642),
+		"btn_text":          tcell.NewHexColor(0x93a1a1),
+		"error_modal_bg":    tcell.NewHexColor(0xdc322f),
+		"hdg_1":             tcell.NewHexColor(0xcb4b16),
+		"hdg_2":             tcell.NewHexColor(0x859900),
+		"hdg_3":             tcell.NewHexColor(0xd33682),
+		"amfora_link":       tcell.NewHexColor(0x268bd2),
+		"offsite_link":      tcell.NewHexColor(0x2aa198),
+		"foreign_link":      tcell.NewHexColor(0x6c71c4),
+		"link_number":       tcell.NewHexColor(0x586e75),
+		"scheme_badge":      tcell.NewHexColor(0x586e75),
+		"new_link":          tcell.NewHexColor(0xcb4b16),
+		"regular_text":      tcell.NewHexColor(0x839496),
+		"quote_text":        tcell.NewHexColor(0x93a1a1),
+		"preformatted_text": tcell.NewHexColor(0xb58900),
+		"list_text":         tcell.NewHexColor(0x839496),
+	},
+}
+
+func copyTheme(t map[string]tcell.Color) map[string]tcell.Color {
+	c := make(map[string]tcell.Color, len(t))
+	for k, v := range t {
+		c[k] = v
+	}
+	return c
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// parseColors turns the settings of a theme file or config section into colors.
+func parseColors(settings map[string]interface{}) (map[string]tcell.Color, error) {
+	colors := make(map[string]tcell.Color, len(settings))
+	for k, v := range settings {
+		colorStr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf(`value for "%s" is not a string: %v`, k, v)
+		}
+		color := tcell.GetColor(strings.ToLower(colorStr))
+		if color == tcell.ColorDefault {
+			return nil, fmt.Errorf(`invalid color format for "%s": %s`, k, colorStr)
+		}
+		colors[k] = color
+	}
+	return colors, nil
+}
+
+// themeFile returns the path of the theme file for the name, which can be a
+// path to a file, or the name of a TOML or JSON file in ThemesDir.
+func themeFile(name string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".toml" || ext == ".json" || strings.ContainsRune(name, os.PathSeparator) {
+		return name, nil
+	}
+	for _, ext := range []string{".toml", ".json"} {
+		path := filepath.Join(ThemesDir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no built-in theme or theme file named %s, in %s", name, ThemesDir)
+}
+
+// themeColors returns the colors set by the theme with the given name.
+func themeColors(name string) (map[string]tcell.Color, error) {
+	if colors, ok := builtinThemes[strings.ToLower(name)]; ok {
+		return colors, nil
+	}
+	path, err := themeFile(name)
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("couldn't read theme file %s: %w", path, err)
+	}
+	settings := v.AllSettings()
+	if sub := v.Sub("theme"); sub != nil {
+		// The keys are in a theme section, like in the config file
+		settings = sub.AllSettings()
+	}
+	colors, err := parseColors(settings)
+	if err != nil {
+		return nil, fmt.Errorf("theme file %s: %w", path, err)
+	}
+	return colors, nil
+}
+
+// loadTheme replaces the colors with the ones from the named theme, and then
+// the ones set in the theme section of the config. The colors aren't changed
+// if there's an error.
+func loadTheme(name string) error {
+	if name == "" {
+		name = "default"
+	}
+	colors, err := themeColors(name)
+	if err != nil {
+		return err
+	}
+	var overrides map[string]tcell.Color
+	if configTheme := viper.Sub("theme"); configTheme != nil {
+		overrides, err = parseColors(configTheme.AllSettings())
+		if err != nil {
+			return err
+		}
+	}
+
+	themeMu.Lock()
+	theme = copyTheme(defaultTheme)
+	for k, v := range colors {
+		theme[k] = v
+	}
+	for k, v := range overrides {
+		theme[k] = v
+	}
+	themeMu.Unlock()
+
+	if viper.GetBool("a-general.color") {
+		cview.Styles.PrimitiveBackgroundColor = GetColor("bg")
+	} // Otherwise it's black by default
+	return nil
+}
+
+// SetTheme changes the theme for the rest of the session. See loadTheme.
+// Content that's already been formatted has to be formatted again
+// for the new colors, see ThemeVersion.
+func SetTheme(name string) error {
+	if err := loadTheme(name); err != nil {
+		return err
+	}
+	viper.Set("a-general.theme", name)
+	themeMu.Lock()
+	themeVersion++
+	themeMu.Unlock()
+	return nil
+}
+
+// ThemeVersion returns a number that changes each time SetTheme is used.
+func ThemeVersion() int {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	return themeVersion
+}
+
 func SetColor(key string, color tcell.Color) {
 	themeMu.Lock()
 	theme[key] = color
 	themeMu.Unlock()
 }
 
+// HasColor returns true if the theme has a color for the key.
+func HasColor(key string) bool {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	_, ok := theme[key]
+	return ok
+}
+
 // GetColor will return tcell.ColorBlack if there is no color for the provided key.
 func GetColor(key string) tcell.Color {
 	themeMu.RLock()
@@ -86,3 +311,15 @@ func GetColorString(key string) string {
 	defer themeMu.RUnlock()
 	return fmt.Sprintf("#%06x", theme[key].TrueColor().Hex())
 }
+
+// ColorStrings returns the colors of all the keys of the theme, as strings
+// like GetColorString returns.
+func ColorStrings() map[string]string {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	colors := make(map[string]string, len(theme))
+	for k, v := range theme {
+		colors[k] = fmt.Sprintf("#%06x", v.TrueColor().Hex())
+	}
+	return colors
+}
@@ -2,9 +2,13 @@ package display
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
 
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
 )
 
 var aboutPage structs.Page
@@ -16,6 +20,11 @@ func aboutInit(version, commit, builtBy string) {
 	aboutPage = createAboutPage("about:about", `# Internal Pages
 
 => about:bookmarks
+=> about:cache
+=> about:home
+=> about:certs
+=> about:downloads
+=> about:tofu
 => about:subscriptions
 => about:manage-subscriptions
 => about:newtab
@@ -33,6 +42,31 @@ func aboutInit(version, commit, builtBy string) {
 	thanksPage = createAboutPage("about:thanks", string(thanks))
 }
 
+// HomePage displays the gemtext file set by home_file in the config,
+// as about:home. The file is read each time, so changes to it are shown.
+// It returns false if the page couldn't be displayed.
+func HomePage(t *tab) bool {
+	path := strings.TrimSpace(viper.GetString("a-general.home_file"))
+	if path == "" {
+		Error("Error", "There's no about:home page, set home_file in the config to a gemtext file to have one.")
+		return false
+	}
+	path, err := homedir.Expand(path)
+	if err != nil {
+		Error("File Error", "Invalid home_file path: "+err.Error())
+		return false
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		Error("File Error", "Couldn't read the home_file: "+err.Error())
+		return false
+	}
+	page := createAboutPage("about:home", string(content))
+	setPage(t, &page)
+	t.applyBottomBar()
+	return true
+}
+
 func createAboutPage(url string, content string) structs.Page {
 	renderContent, links := renderer.RenderGemini(content, textWidth(), false)
 	return structs.Page{
@@ -0,0 +1,103 @@
+package display
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Pages can be reloaded over and over in a tab, for dashboards and status
+// pages. It's started with the auto-reload command, and stops once the tab
+// goes to another page or is closed.
+
+// The shortest time between reloads, so servers aren't overwhelmed.
+const autoReloadMin = 5 * time.Second
+
+// parseAutoReload returns the time between reloads typed after the command.
+// It can be a number of seconds, or a duration like 30s or 5m.
+func parseAutoReload(arg string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(arg); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(arg)
+}
+
+// autoReloadCommand is the auto-reload command. It starts reloading the
+// current page every so often, or stops it for "off" or no value.
+func autoReloadCommand(arg string) {
+	t := tabs[curTab]
+	if arg == "" || strings.EqualFold(arg, "off") {
+		t.stopAutoReload()
+		return
+	}
+	every, err := parseAutoReload(arg)
+	if err != nil || every < autoReloadMin {
+		Error("Command Error", "The time between reloads must be at least 5s, like auto-reload 30s or auto-reload 2m.")
+		return
+	}
+	if !t.hasContent() || strings.HasPrefix(t.page.URL, "about:") {
+		Info("Only pages from a server can be reloaded automatically.")
+		return
+	}
+	t.startAutoReload(every)
+	Info("This page will be reloaded every " + every.String() + ", until you go to another page or use auto-reload off.")
+}
+
+// startAutoReload reloads the tab's current page every interval,
+// replacing any auto-reload that's already running.
+func (t *tab) startAutoReload(every time.Duration) {
+	t.stopAutoReload()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.autoReloadCancel = cancel
+	t.autoReloadURL = t.page.URL
+	go t.autoReload(ctx, t.page.URL, every)
+}
+
+// stopAutoReload stops reloading the tab's page, if it was being reloaded.
+func (t *tab) stopAutoReload() {
+	if t.autoReloadCancel != nil {
+		t.autoReloadCancel()
+		t.autoReloadCancel = nil
+	}
+	t.autoReloadURL = ""
+}
+
+// autoReload reloads the page at u every interval until ctx is canceled.
+// The scroll position is kept when the page hasn't changed.
+func (t *tab) autoReload(ctx context.Context, u string, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !isValidTab(t) {
+			return
+		}
+		if t.mode != tabModeDone || t.page.Mode != structs.ModeOff {
+			// Loading, or links or text are being selected,
+			// so wait until next time
+			continue
+		}
+		if t.page.URL != u {
+			return
+		}
+
+		row, col := t.view.GetScrollOffset()
+		old := t.page.Raw
+		reloadTab(t, u)
+		if ctx.Err() != nil || !isValidTab(t) || t.page.URL != u {
+			return
+		}
+		if t.page.Raw == old {
+			t.view.ScrollTo(row, col)
+			t.saveScroll()
+			App.Draw()
+		}
+	}
+}
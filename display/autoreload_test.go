@@ -0,0 +1,26 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAutoReload(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want time.Duration
+		ok   bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"30s", 30 * time.Second, true},
+		{"2m", 2 * time.Minute, true},
+		{"1m30s", 90 * time.Second, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseAutoReload(tt.arg)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseAutoReload(%q) = %v, %v", tt.arg, got, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package display
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/bookmarks"
@@ -31,6 +33,31 @@ var bkmkModalText string // The current text of the input field in the modal
 func bkmkInit() {
 	panels.AddPanel("bkmk", bkmkModal, false, false)
 
+	m := bkmkModal
+	bkmkColors()
+
+	m.SetBorder(true)
+	frame := m.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" Add Bookmark ")
+	m.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		switch buttonLabel {
+		case "Add":
+			bkmkCh <- add
+		case "Change":
+			bkmkCh <- change
+		case "Remove":
+			bkmkCh <- remove
+		case "Cancel":
+			bkmkCh <- cancel
+		case "":
+			bkmkCh <- cancel
+		}
+	})
+}
+
+// bkmkColors sets the colors of the bookmark modal.
+func bkmkColors() {
 	m := bkmkModal
 	if viper.GetBool("a-general.color") {
 		m.SetBackgroundColor(config.GetColor("bkmk_modal_bg"))
@@ -61,25 +88,6 @@ func bkmkInit() {
 		frame.SetBorderColor(tcell.ColorWhite)
 		frame.SetTitleColor(tcell.ColorWhite)
 	}
-
-	m.SetBorder(true)
-	frame := m.GetFrame()
-	frame.SetTitleAlign(cview.AlignCenter)
-	frame.SetTitle(" Add Bookmark ")
-	m.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		switch buttonLabel {
-		case "Add":
-			bkmkCh <- add
-		case "Change":
-			bkmkCh <- change
-		case "Remove":
-			bkmkCh <- remove
-		case "Cancel":
-			bkmkCh <- cancel
-		case "":
-			bkmkCh <- cancel
-		}
-	})
 }
 
 // Bkmk displays the "Add a bookmark" modal.
@@ -129,20 +137,29 @@ func openBkmkModal(name string, exists bool, favicon string) (string, bkmkAction
 func Bookmarks(t *tab) {
 	bkmkPageRaw := "# Bookmarks\r\n\r\n"
 
-	// Gather bookmarks
-	m, keys := bookmarks.All()
-	for i := range keys {
-		bkmkPageRaw += fmt.Sprintf("=> %s %s\r\n", keys[i], m[keys[i]])
+	urls, names := bookmarks.List()
+	if len(urls) == 0 {
+		bkmkPageRaw += "There are no bookmarks yet.\r\n\r\n"
+	} else {
+		bkmkPageRaw += fmt.Sprintf("Select a bookmark with Tab, then press %s to rename it, %s to remove it, "+
+			"or %s and %s to move it up and down. Press %s to add a bookmark.\r\n\r\n",
+			config.GetKeyBinding(config.CmdRenameBkmk), config.GetKeyBinding(config.CmdRemoveBkmk),
+			config.GetKeyBinding(config.CmdBkmkUp), config.GetKeyBinding(config.CmdBkmkDown),
+			config.GetKeyBinding(config.CmdAddBookmark))
+	}
+	for i := range urls {
+		bkmkPageRaw += fmt.Sprintf("=> %s %s\r\n", urls[i], names[i])
 	}
 	// Render and display
 	content, links := renderer.RenderGemini(bkmkPageRaw, textWidth(), false)
 	page := structs.Page{
-		Raw:       bkmkPageRaw,
-		Content:   content,
-		Links:     links,
-		URL:       "about:bookmarks",
-		TermWidth: termW,
-		Mediatype: structs.TextGemini,
+		Raw:        bkmkPageRaw,
+		Content:    content,
+		Links:      links,
+		URL:        "about:bookmarks",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
 	}
 	setPage(t, &page)
 	t.applyBottomBar()
@@ -155,11 +172,19 @@ func addBookmark() {
 	t := tabs[curTab]
 	p := t.page
 
+	if p.URL == "about:bookmarks" {
+		addBookmarkURL(t)
+		return
+	}
 	if !t.hasContent() {
 		// It's an about: page, or a malformed one
 		return
 	}
 	name, exists := bookmarks.Get(p.URL)
+	if !exists {
+		// Suggest the page title as the name
+		name = firstHeading(p)
+	}
 	// Open a bookmark modal with the current name of the bookmark, if it exists
 	newName, action := openBkmkModal(name, exists, p.Favicon)
 
@@ -174,3 +199,97 @@ func addBookmark() {
 	}
 	// Other case is action == cancel, so nothing needs to happen
 }
+
+// addBookmarkURL asks for a URL and name, and adds it as a bookmark.
+// It's used on the bookmarks page, and should be called in a goroutine.
+func addBookmarkURL(t *tab) {
+	u, ok := Input("URL to bookmark:", false)
+	if !ok || strings.TrimSpace(u) == "" {
+		return
+	}
+	u = normalizeURL(fixUserURL(strings.TrimSpace(u)))
+	if _, exists := bookmarks.Get(u); exists {
+		Info("This URL is already bookmarked.")
+		return
+	}
+	name, action := openBkmkModal("", false, "")
+	if action != add {
+		return
+	}
+	bookmarks.Add(u, name)
+	reloadBookmarks(t, u)
+}
+
+// editBookmark renames, removes, or moves the bookmark selected on the bookmarks page,
+// depending on the command. It should be called in a goroutine.
+func editBookmark(t *tab, cmd config.Command) {
+	if t.page.Mode != structs.ModeLinkSelect || t.page.Selected == "" {
+		Info("Select a bookmark first, by pressing Tab.")
+		return
+	}
+	u := t.page.Selected
+	name, exists := bookmarks.Get(u)
+	if !exists {
+		return
+	}
+
+	//nolint:exhaustive
+	switch cmd {
+	case config.CmdRenameBkmk:
+		newName, action := openBkmkModal(name, true, "")
+		//nolint:exhaustive
+		switch action {
+		case change:
+			bookmarks.Change(u, newName)
+		case remove:
+			bookmarks.Remove(u)
+			u = ""
+		default:
+			return
+		}
+	case config.CmdRemoveBkmk:
+		if !YesNo("Remove the bookmark " + name + "?") {
+			return
+		}
+		bookmarks.Remove(u)
+		u = ""
+	case config.CmdBkmkUp:
+		if !bookmarks.Move(u, false) {
+			return
+		}
+	case config.CmdBkmkDown:
+		if !bookmarks.Move(u, true) {
+			return
+		}
+	}
+	reloadBookmarks(t, u)
+}
+
+// reloadBookmarks displays the bookmarks page again in the tab, keeping the scroll
+// position. The bookmark for the URL is selected, if it's not empty.
+func reloadBookmarks(t *tab, u string) {
+	if t.page.URL != "about:bookmarks" {
+		// The tab has moved on
+		return
+	}
+	t.saveScroll()
+	row, col := t.page.Row, t.page.Column
+	Bookmarks(t)
+	t.page.Row, t.page.Column = row, col
+
+	for i, link := range t.page.Links {
+		if link == u {
+			t.page.Mode = structs.ModeLinkSelect
+			t.page.Selected = link
+			t.page.SelectedID = strconv.Itoa(i)
+			t.applySelected()
+			break
+		}
+	}
+	t.applyScroll()
+	if t.page.Mode == structs.ModeLinkSelect {
+		t.view.ScrollToHighlight()
+	}
+	t.applyBottomBar()
+	App.Draw()
+}
@@ -0,0 +1,168 @@
+package display
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// CacheInfo displays how much of the page cache is being used,
+// and the pages in it.
+func CacheInfo(t *tab) {
+	maxPages := "no limit"
+	if cache.MaxPages() > 0 {
+		maxPages = strconv.Itoa(cache.MaxPages())
+	}
+	maxSize := "no limit"
+	if cache.MaxSize() > 0 {
+		maxSize = humanize.Bytes(uint64(cache.MaxSize()))
+	}
+
+	rawPage := "# Page Cache\n\n" +
+		fmt.Sprintf("* Pages: %d, out of %s\n", cache.NumPages(), maxPages) +
+		fmt.Sprintf("* Size: %s, out of %s\n", humanize.Bytes(uint64(cache.SizePages())), maxSize) +
+		fmt.Sprintf("* Pages that are never removed: %d\n\n", cache.NumForever()) +
+		"When the cache is full, the least recently used pages are removed first.\n\n" +
+		"## Pages\n\n"
+
+	urls := cache.URLs()
+	if len(urls) == 0 {
+		rawPage += "No pages are cached.\n"
+	}
+	for _, u := range urls {
+		rawPage += "=> " + u + "\n"
+	}
+
+	rawPage += "\n## TLS Sessions\n\n" +
+		"Connecting to a server again can resume the TLS session from before, which skips most of the handshake. " +
+		"This is done for Gemini requests and Titan uploads that don't use a client certificate.\n\n"
+	stats := client.GetHandshakeStats()
+	if stats.Full+stats.Resumed == 0 {
+		rawPage += "No handshakes have been made yet.\n"
+	} else {
+		rawPage += fmt.Sprintf("* Full handshakes: %d, %s on average\n", stats.Full, averageTime(stats.FullTime, stats.Full)) +
+			fmt.Sprintf("* Resumed handshakes: %d, %s on average\n", stats.Resumed, averageTime(stats.ResumedTime, stats.Resumed))
+		saved := time.Duration(0)
+		if stats.Full > 0 && stats.Resumed > 0 {
+			saved = stats.FullTime/time.Duration(stats.Full) - stats.ResumedTime/time.Duration(stats.Resumed)
+		}
+		if saved > 0 {
+			rawPage += fmt.Sprintf("* Time saved by resuming: about %s\n",
+				(saved * time.Duration(stats.Resumed)).Round(time.Millisecond))
+		}
+	}
+
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	page := structs.Page{
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        "about:cache",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
+
+// averageTime returns the average of n durations that add up to total, to display.
+func averageTime(total time.Duration, n int) string {
+	if n == 0 {
+		return "none"
+	}
+	return (total / time.Duration(n)).Round(time.Millisecond).String()
+}
+
+// clearStores are the things the clear command can clear, in the order
+// they're cleared by clear all.
+var clearStores = []string{"cache", "favicons", "tofu"}
+
+// clearTargets returns the stores to clear for the argument of the clear
+// command, and false if there's no such store.
+func clearTargets(arg string) ([]string, bool) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	if arg == "all" {
+		return clearStores, true
+	}
+	for _, s := range clearStores {
+		if arg == s {
+			return []string{s}, true
+		}
+	}
+	return nil, false
+}
+
+// clearCommand clears the page cache, the favicon cache, or the TOFU database,
+// for the clear command. The tabs keep the pages they're showing.
+func clearCommand(arg string) {
+	targets, ok := clearTargets(arg)
+	if !ok {
+		Error("Command Error", "Type what to clear: clear cache, clear favicons, clear tofu, or clear all.")
+		return
+	}
+
+	prompts := map[string]string{
+		"cache":    "the cached pages",
+		"favicons": "the cached favicons",
+		"tofu":     "the trusted server certificates",
+	}
+	names := make([]string, len(targets))
+	for i, s := range targets {
+		names[i] = prompts[s]
+	}
+
+	go func() {
+		if !YesNo("Remove " + joinList(names) + "?") {
+			return
+		}
+		removed := make([]string, 0, len(targets))
+		for _, s := range targets {
+			switch s {
+			case "cache":
+				n := cache.ClearPages()
+				cache.ClearRedirs()
+				removed = append(removed, fmt.Sprintf("%d pages from the cache", n))
+			case "favicons":
+				removed = append(removed, fmt.Sprintf("%d favicons", cache.ClearFavicons()))
+			case "tofu":
+				n, err := client.ClearTofu()
+				if err != nil {
+					Error("TOFU Error", "Error saving the TOFU database: "+err.Error())
+					return
+				}
+				removed = append(removed, fmt.Sprintf("%d server certificates", n))
+			}
+		}
+
+		if t := tabs[curTab]; t.mode == tabModeDone {
+			switch t.page.URL {
+			case "about:cache":
+				CacheInfo(t) // Reload
+			case "about:tofu":
+				TofuPage(t, "about:tofu")
+			}
+		}
+		Info("Removed " + joinList(removed) + ".")
+	}()
+}
+
+// joinList joins the items like "a, b, and c".
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " and " + items[1]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + ", and " + items[len(items)-1]
+}
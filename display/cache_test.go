@@ -0,0 +1,39 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClearTargets(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want []string
+		ok   bool
+	}{
+		{"cache", []string{"cache"}, true},
+		{"Favicons", []string{"favicons"}, true},
+		{" tofu ", []string{"tofu"}, true},
+		{"all", []string{"cache", "favicons", "tofu"}, true},
+		{"", nil, false},
+		{"history", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := clearTargets(tt.arg)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("clearTargets(%q) = %v, %v, want %v, %v", tt.arg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestJoinList(t *testing.T) {
+	for want, items := range map[string][]string{
+		"a":           {"a"},
+		"a and b":     {"a", "b"},
+		"a, b, and c": {"a", "b", "c"},
+	} {
+		if got := joinList(items); got != want {
+			t.Errorf("joinList(%v) = %q, want %q", items, got, want)
+		}
+	}
+}
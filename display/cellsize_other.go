@@ -0,0 +1,9 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package display
+
+// cellSize returns the width and height of a terminal cell in pixels.
+// It's not supported on this OS, so zeros are always returned.
+func cellSize() (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,154 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// For choosing which client certificate (identity) to use for a host
+var certModal = cview.NewModal()
+
+// Channel to indicate which button was chosen, by index
+var certCh = make(chan int)
+
+func certInit() {
+	panels.AddPanel("cert", certModal, false, false)
+
+	m := certModal
+	certColors()
+
+	m.SetBorder(true)
+	frame := m.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" Client Certificate ")
+	m.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		certCh <- buttonIndex
+	})
+}
+
+// certColors sets the colors of the client certificate modal.
+func certColors() {
+	m := certModal
+	if viper.GetBool("a-general.color") {
+		m.SetButtonBackgroundColor(config.GetColor("btn_bg"))
+		m.SetButtonTextColor(config.GetColor("btn_text"))
+		m.SetBackgroundColor(config.GetColor("yesno_modal_bg"))
+		m.SetTextColor(config.GetColor("yesno_modal_text"))
+		form := m.GetForm()
+		form.SetButtonBackgroundColorFocused(config.GetColor("btn_text"))
+		form.SetButtonTextColorFocused(config.GetColor("btn_bg"))
+		frame := m.GetFrame()
+		frame.SetBorderColor(config.GetColor("yesno_modal_text"))
+		frame.SetTitleColor(config.GetColor("yesno_modal_text"))
+	} else {
+		m.SetButtonBackgroundColor(tcell.ColorWhite)
+		m.SetButtonTextColor(tcell.ColorBlack)
+		m.SetBackgroundColor(tcell.ColorBlack)
+		m.SetTextColor(tcell.ColorWhite)
+		form := m.GetForm()
+		form.SetButtonBackgroundColorFocused(tcell.ColorBlack)
+		form.SetButtonTextColorFocused(tcell.ColorWhite)
+		frame := m.GetFrame()
+		frame.SetBorderColor(tcell.ColorWhite)
+		frame.SetTitleColor(tcell.ColorWhite)
+	}
+}
+
+// chooseIdentity asks the user which identity to use for the host, after
+// it responded with status 60 or 61. A new identity can be generated too.
+// The choice is remembered for the host.
+//
+// It returns false if the user cancelled, or there was an error,
+// which has already been displayed.
+func chooseIdentity(host, meta string) bool {
+	ids := client.Identities()
+	buttons := make([]string, 0, len(ids)+2)
+	for i := range ids {
+		buttons = append(buttons, ids[i].Name)
+	}
+	buttons = append(buttons, "New", "Cancel")
+
+	text := host + " is asking for a client certificate. Which identity would you like to use?"
+	if meta = strings.TrimSpace(meta); meta != "" {
+		text = escapeMeta(meta) + "\n\n" + text
+	}
+
+	certModal.ClearButtons()
+	certModal.AddButtons(buttons)
+	certModal.SetText(text)
+	panels.ShowPanel("cert")
+	panels.SendToFront("cert")
+	App.SetFocus(certModal)
+	App.Draw()
+
+	choice := <-certCh
+	panels.HidePanel("cert")
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+
+	var id client.Identity
+	switch {
+	case choice >= 0 && choice < len(ids):
+		id = ids[choice]
+	case choice == len(ids):
+		name, ok := Input("Name for the new identity:", false)
+		if !ok {
+			return false
+		}
+		var err error
+		id, err = client.NewIdentity(name)
+		if err != nil {
+			Error("Certificate Error", "Couldn't create identity: "+err.Error())
+			return false
+		}
+	default:
+		// Cancel
+		return false
+	}
+
+	err := client.SetIdentity(host, id)
+	if err != nil {
+		Error("Certificate Error", "Couldn't save identity choice: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// Certs displays the identity used for each host.
+func Certs(t *tab) {
+	rawPage := "# Client Certificates\n\n"
+
+	ids, hosts := client.HostIdentities()
+	if len(hosts) == 0 {
+		rawPage += "No identities have been chosen yet. When a site asks for a client certificate, " +
+			"you can choose one of your identities or generate a new one.\n"
+	} else {
+		rawPage += "These identities are used automatically when visiting each site. " +
+			"Certificates set in the config are not shown, and are used instead.\n\n"
+	}
+	for _, host := range hosts {
+		rawPage += fmt.Sprintf("## %s\n\n=> gemini://%s/\n* Identity: %s\n* Certificate: %s\n* Key: %s\n\n",
+			host, host, ids[host].Name, ids[host].Cert, ids[host].Key)
+	}
+
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	page := structs.Page{
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        "about:certs",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
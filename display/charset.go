@@ -0,0 +1,56 @@
+package display
+
+import (
+	"errors"
+
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"gitlab.com/tslocum/cview"
+)
+
+// setCharset decodes the text of the current page again with the charset,
+// for the charset command. Without a charset it tells the one the page was
+// decoded from.
+func setCharset(arg string) {
+	t := tabs[curTab]
+	if t.page.Graphic || t.page.Raw == "" || t.mode != tabModeDone {
+		Error("Command Error", "There's no text on this page to decode.")
+		return
+	}
+	if arg == "" {
+		charset := t.page.Charset
+		if charset == "" {
+			charset = "utf-8"
+		}
+		Info("This page was decoded from " + charset + ". Type another charset after the command " +
+			"to decode it with that one instead, like charset iso-8859-1.")
+		return
+	}
+
+	reformatMu.Lock()
+	if err := renderer.Redecode(t.page, arg); err != nil {
+		reformatMu.Unlock()
+		if errors.Is(err, renderer.ErrBadEncoding) {
+			Error("Command Error", "Unknown or unsupported charset: "+cview.Escape(arg))
+		} else {
+			Error("Charset Error", err.Error())
+		}
+		return
+	}
+
+	row, _ := t.view.GetScrollOffset()
+	t.clearSelected()
+	if t.page.Source {
+		t.page.MaxPreCols = renderer.MaxPreCols(t.page.Raw, structs.TextPlain)
+	} else {
+		t.page.MaxPreCols = renderer.MaxPreCols(t.page.Raw, t.page.Mediatype)
+	}
+	t.page.TermWidth = -1 // Force reformatting
+	reformatPage(t.page)
+	t.view.SetText(t.page.Content)
+	t.page.Row = row
+	t.applyScroll()
+	t.applyBottomBar()
+	reformatMu.Unlock()
+	App.Draw()
+}
@@ -0,0 +1,221 @@
+package display
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// The command palette lets actions be run by typing their name in the bottomBar.
+
+// commands maps the names that can be typed in the command palette to the
+// functions that run them. They are the same functions used by the keybindings.
+var commands = map[string]func(){
+	"back":     func() { histBack(tabs[curTab]) },
+	"bookmark": func() { go addBookmark() },
+	"bookmarks": func() {
+		Bookmarks(tabs[curTab])
+		tabs[curTab].addToHistory("about:bookmarks")
+	},
+	"close-tab":     CloseTab,
+	"copy-link":     func() { go copyTargetURL() },
+	"copy-url":      func() { go copyPageURL() },
+	"duplicate-tab": func() { duplicateTab(tabs[curTab]) },
+	"forward":       func() { histForward(tabs[curTab]) },
+	"help":          Help,
+	"home":          func() { URL(viper.GetString("a-general.home")) },
+	"incognito":     NewIncognitoTab,
+	"link-numbers":  toggleLinkNumbers,
+	"links":         Links,
+	"new-tab":       OpenNewTab,
+	"quit":          Stop,
+	"reader":        func() { go tabs[curTab].toggleReader() },
+	"reload":        Reload,
+	"reload-all":    ReloadAll,
+	"root":          func() { goToRoot(tabs[curTab]) },
+	"select":        func() { tabs[curTab].startTextSelect() },
+	"sessions":      listSessions,
+	"source":        func() { go tabs[curTab].toggleSource() },
+	"source-editor": func() { go viewInEditor(tabs[curTab]) },
+	"split":         toggleSplit,
+	"subscribe":     func() { go addSubscription() },
+	"subscriptions": func() {
+		Subscriptions(tabs[curTab], "about:subscriptions")
+		tabs[curTab].addToHistory("about:subscriptions")
+	},
+	"toc":         TOC,
+	"up":          func() { goUp(tabs[curTab]) },
+	"upload":      func() { go editAndUpload(tabs[curTab]) },
+	"upload-text": func() { go typeAndUpload(tabs[curTab]) },
+	"wipe":        wipeSession,
+	"wrap":        toggleWrap,
+}
+
+// commandsWithArg are like commands, but need a value typed after their name,
+// like "left-margin 0.1".
+var commandsWithArg = map[string]func(arg string){
+	"auto-reload":  autoReloadCommand,
+	"charset":      func(arg string) { go setCharset(arg) },
+	"clear":        clearCommand,
+	"export-tofu":  exportTofu,
+	"goto":         gotoLine,
+	"import-tofu":  importTofu,
+	"left-margin":  setLeftMargin,
+	"open-session": openNamedSession,
+	"save-session": saveNamedSession,
+	"sessions":     sessionsCommand,
+	"theme":        setTheme,
+	"title":        func(arg string) { tabs[curTab].setTitle(arg) },
+}
+
+// commandPalette opens the bottomBar to type a command.
+func commandPalette() {
+	setBottomBarMode(barCommand)
+	bottomBar.SetLabel("[::b]Command: [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+}
+
+// findCommand returns the function for the command typed in the text,
+// and false if there's no such command.
+func findCommand(text string) (func(), bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	name := strings.ToLower(fields[0])
+	if f, ok := commands[name]; ok && len(fields) == 1 {
+		return f, true
+	}
+	f, ok := commandsWithArg[name]
+	if !ok {
+		return nil, false
+	}
+	arg := strings.TrimSpace(strings.TrimSpace(text)[len(fields[0]):])
+	return func() { f(arg) }, true
+}
+
+// setLeftMargin changes the left margin for the rest of the session,
+// and reformats the tabs for it.
+func setLeftMargin(arg string) {
+	margin, err := strconv.ParseFloat(arg, 64)
+	if err != nil || margin < 0 {
+		Error("Command Error", "The left margin must be a number of columns, or a fraction of the terminal width.")
+		return
+	}
+	viper.Set("a-general.left_margin", margin)
+	go reformatTabs(tabs[curTab])
+}
+
+// wipeSession forgets the URLs visited and the inputs sent this session,
+// which are kept to be suggested again.
+func wipeSession() {
+	visitedURLsMu.Lock()
+	visitedURLs = make([]string, 0)
+	visitedURLsMu.Unlock()
+	urlSuggestions = nil
+	clearInputHistory()
+	clearRecentVisits()
+	go Info("The URLs visited and the inputs sent this session have been forgotten. " +
+		"The history of open tabs is kept.")
+}
+
+// toggleLinkNumbers hides or shows link numbers for the rest of the session,
+// and reformats the tabs for it.
+func toggleLinkNumbers() {
+	viper.Set("a-general.show_link_numbers", !viper.GetBool("a-general.show_link_numbers"))
+	go reformatTabs(tabs[curTab])
+}
+
+// toggleWrap wraps or stops wrapping the long lines of plain text and ANSI
+// documents for the rest of the session, and reformats the tabs for it.
+// Preformatted blocks of gemtext are never wrapped.
+func toggleWrap() {
+	viper.Set("a-general.wrap_text", !viper.GetBool("a-general.wrap_text"))
+	go reformatTabs(tabs[curTab])
+}
+
+// completeCommand returns the text with as much of a command name filled in
+// as possible. If several commands start with the text, it is only completed
+// up to where their names differ.
+func completeCommand(text string) string {
+	prefix := strings.ToLower(strings.TrimSpace(text))
+	matches := make([]string, 0)
+	for name := range commands {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	for name := range commandsWithArg {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return text
+	}
+	sort.Strings(matches)
+
+	// The common prefix of the first and last names is shared by all of them
+	first, last := matches[0], matches[len(matches)-1]
+	i := 0
+	for i < len(first) && i < len(last) && first[i] == last[i] {
+		i++
+	}
+	return first[:i]
+}
+
+// gotoRow returns the row to scroll to for the text typed after the goto
+// command, which is a line number or a percentage of the lines.
+// The row is zero-indexed, and it's always within the lines.
+func gotoRow(arg string, lines int) (int, error) {
+	arg = strings.TrimSpace(arg)
+	var row int
+	if strings.HasSuffix(arg, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(arg[:len(arg)-1]), 64)
+		if err != nil {
+			return 0, errors.New("invalid percentage")
+		}
+		row = int(percent / 100 * float64(lines))
+	} else {
+		line, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, errors.New("not a line number or percentage")
+		}
+		row = line - 1
+	}
+	if row >= lines {
+		row = lines - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	return row, nil
+}
+
+// gotoLine scrolls the current tab to the line number or percentage typed
+// after the goto command.
+func gotoLine(arg string) {
+	t := tabs[curTab]
+	if t.page.Graphic {
+		return
+	}
+	_, lines := t.view.TextDimensions()
+	row, err := gotoRow(arg, lines)
+	if err != nil {
+		Error("Command Error", "Type a line number like 120, or a percentage like 50%.")
+		return
+	}
+	_, col := t.view.GetScrollOffset()
+	t.view.ScrollTo(row, col)
+	t.saveScroll()
+}
+
+// gotoPalette opens the command palette with the goto command already typed.
+func gotoPalette() {
+	commandPalette()
+	bottomBar.SetText("goto ")
+}
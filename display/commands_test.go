@@ -0,0 +1,72 @@
+package display
+
+import "testing"
+
+var completeCommandTests = []struct {
+	text string
+	want string
+}{
+	{"", ""},
+	{"rel", "reload"},
+	{"Rel", "reload"},
+	{"b", "b"},
+	{"book", "bookmark"},
+	{"bookmarks", "bookmarks"},
+	{"n", "new-tab"},
+	{"su", "subscri"},
+	{"l", "l"},
+	{"le", "left-margin"},
+	{"lin", "link"},
+	{"link-", "link-numbers"},
+	{"xyz", "xyz"},
+}
+
+func TestCompleteCommand(t *testing.T) {
+	for _, tt := range completeCommandTests {
+		if got := completeCommand(tt.text); got != tt.want {
+			t.Errorf("completeCommand(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	if _, ok := findCommand(" Reload "); !ok {
+		t.Error("reload command not found")
+	}
+	if _, ok := findCommand("reload now"); ok {
+		t.Error("command without an argument was found with one")
+	}
+	if _, ok := findCommand("left-margin 4"); !ok {
+		t.Error("left-margin command not found")
+	}
+	if _, ok := findCommand("xyz"); ok {
+		t.Error("unknown command was found")
+	}
+}
+
+var gotoRowTests = []struct {
+	arg   string
+	lines int
+	row   int
+	err   bool
+}{
+	{"1", 200, 0, false},
+	{"120", 200, 119, false},
+	{"500", 200, 199, false},
+	{"0", 200, 0, false},
+	{"50%", 200, 100, false},
+	{" 25 % ", 200, 50, false},
+	{"100%", 200, 199, false},
+	{"12.5%", 8, 1, false},
+	{"abc", 200, 0, true},
+	{"x%", 200, 0, true},
+}
+
+func TestGotoRow(t *testing.T) {
+	for _, tt := range gotoRowTests {
+		row, err := gotoRow(tt.arg, tt.lines)
+		if (err != nil) != tt.err || row != tt.row {
+			t.Errorf("gotoRow(%q, %d) = %d, %v, want %d", tt.arg, tt.lines, row, err, tt.row)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package display
+
+import (
+	"net/url"
+
+	"github.com/atotto/clipboard"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"gitlab.com/tslocum/cview"
+)
+
+// copyText copies the text to the clipboard, and shows the label in the
+// bottomBar once it's copied. what is what the text is, like "URL", for the
+// messages if it can't be copied. It should be called in a goroutine.
+// If there's no clipboard, the text is displayed so it can be copied by hand.
+func copyText(text, what, label string) {
+	if clipboard.Unsupported {
+		// No clipboard program is installed, like on many servers
+		Info("No clipboard is available, so the " + what + " couldn't be copied. Here it is:\n\n" +
+			cview.Escape(text))
+		return
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		Error("Copy Error", "The "+what+" couldn't be copied: "+err.Error()+"\n\n"+cview.Escape(text))
+		return
+	}
+	App.QueueUpdateDraw(func() {
+		// Not saved, so it goes away when the bottomBar changes
+		bottomBar.SetLabel(label)
+	})
+}
+
+// copyURL copies the URL to the clipboard, and says so in the bottomBar.
+// It should be called in a goroutine.
+func copyURL(u string) {
+	copyText(u, "URL", "[::b]Copied URL. [::-]")
+}
+
+// copyPageURL copies the URL of the current tab's page to the clipboard.
+func copyPageURL() {
+	t := tabs[curTab]
+	if t.page.URL == "" {
+		return
+	}
+	copyURL(t.page.URL)
+}
+
+// copyTargetURL copies the absolute URL of the selected link to the clipboard.
+// If no link is selected, the first link on the screen is copied.
+func copyTargetURL() {
+	t := tabs[curTab]
+	link := ""
+	if t.page.Mode == structs.ModeLinkSelect && t.page.Selected != "" {
+		link = t.page.Selected
+	} else if t.mode == tabModeDone && !t.page.Source && t.page.Mode == structs.ModeOff {
+		top, _ := t.view.GetScrollOffset()
+		_, _, _, height := t.view.GetInnerRect()
+		if i := screenLink(linkRows(t.page.Content, len(t.page.Links)), top, top+height-1); i >= 0 {
+			link = t.page.Links[i]
+		}
+	}
+	if link == "" {
+		Info("There's no link on the screen to copy. Press Tab to select one.")
+		return
+	}
+	u, err := resolveRelLink(t, t.page.URL, link)
+	if err != nil {
+		Error("URL Error", err.Error())
+		return
+	}
+	copyURL(u)
+}
+
+// copyHeadingURL copies the URL of the current page to the clipboard, with a
+// fragment for the heading at the top of the screen. If the page is scrolled
+// above its first heading, that one is used. The fragment is the slug of the
+// heading, see renderer.HeadingSlug, so opening the URL scrolls to it.
+func copyHeadingURL() {
+	t := tabs[curTab]
+	if t.page.URL == "" || len(t.page.Headings) == 0 {
+		Info("This page has no headings.")
+		return
+	}
+	row, _ := t.view.GetScrollOffset()
+	i := headingIndex(t.page.Headings, row)
+	if i < 0 {
+		i = 0
+	}
+	u, ok := headingURL(t.page.URL, t.page.Headings[i].Slug)
+	if !ok {
+		Info("That heading can't be linked to, because it has no letters or numbers.")
+		return
+	}
+	copyURL(u)
+}
+
+// headingURL returns the page URL with its fragment set to the slug.
+// It returns false if the slug is empty or the URL can't be parsed.
+func headingURL(pageURL, slug string) (string, bool) {
+	if slug == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return "", false
+	}
+	parsed.Fragment = slug
+	return parsed.String(), true
+}
@@ -1,6 +1,7 @@
 package display
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -28,6 +29,33 @@ var termH int
 // The user input and URL display bar at the bottom
 var bottomBar = cview.NewInputField()
 
+// What the bottomBar is being used for.
+type bottomBarMode int
+
+const (
+	barURL         bottomBarMode = iota // Typing a URL, link number, or search, or showing the URL
+	barSearch                           // Typing a search for the current page
+	barCommand                          // The command palette
+	barConfirmHTTP                      // Asking whether to open bottomBarHTTP
+	barConfirmQuit                      // Asking to confirm quitting, see quit_confirm
+	barFindLink                         // Typing the text of a link to follow, see findlink.go
+)
+
+// What the bottomBar is being used for, see setBottomBarMode.
+var barMode bottomBarMode
+
+// The HTTP(S) URL that the bottomBar is asking to open in the browser, in the
+// barConfirmHTTP mode. It's also used for mailto: URLs, to open them with the
+// mailto_command.
+var bottomBarHTTP string
+
+// setBottomBarMode sets what the bottomBar is being used for, and clears
+// bottomBarHTTP, so it must be set after this for the barConfirmHTTP mode.
+func setBottomBarMode(mode bottomBarMode) {
+	barMode = mode
+	bottomBarHTTP = ""
+}
+
 // When the bottom bar string has a space, this regex decides whether it's
 // a non-encoded URL or a search string.
 // See this comment for details:
@@ -57,78 +85,53 @@ var reformatMu = sync.Mutex{}
 
 var App = cview.NewApplication()
 
+var ErrUnsupportedScheme = errors.New("URL scheme can't be displayed in a tab")
+
 func Init(version, commit, builtBy string) {
 	aboutInit(version, commit, builtBy)
 
-	App.EnableMouse(false)
+	cache.SetKeyFunc(urlKey)
+	graphics = detectGraphics()
+	hyperlinks = detectHyperlinks()
+
+	App.EnableMouse(viper.GetBool("a-general.mouse"))
+	App.SetMouseCapture(mouseCapture)
 	App.SetRoot(layout, true)
+	App.SetAfterDrawFunc(func(screen tcell.Screen) {
+		drawGraphics(screen)
+		drawHyperlinks(screen)
+	})
 	App.SetAfterResizeFunc(func(width int, height int) {
 		// Store for calculations
-		termW = width
+		screenW = width
+		termW = paneWidth(width)
 		termH = height
+		// The whole screen is redrawn, so any image needs to be drawn again
+		shownImage = nil
 
 		// Make sure the current tab content is reformatted when the terminal size changes
-		go func(t *tab) {
-			reformatMu.Lock() // Only allow one reformat job at a time
-			for i := range tabs {
-				// Overwrite all tabs with a new, differently sized, left margin
-				browser.AddTab(
-					strconv.Itoa(i),
-					makeTabLabel(strconv.Itoa(i+1)),
-					makeContentLayout(tabs[i].view, leftMargin()),
-				)
-				if tabs[i] == t {
-					// Reformat page ASAP, in the middle of loop
-					reformatPageAndSetView(t, t.page)
-				}
-			}
-			App.Draw()
-			reformatMu.Unlock()
-		}(tabs[curTab])
+		go reformatTabs(tabs[curTab])
 	})
 
 	panels.AddPanel("browser", browser, true, true)
 
 	helpInit()
+	tocInit()
+	linksInit()
+	recentInit()
+	scrollIndicatorInit()
 
 	layout.SetDirection(cview.FlexRow)
 	layout.AddItem(panels, 0, 1, true)
-	layout.AddItem(bottomBar, 1, 1, false)
-
-	if viper.GetBool("a-general.color") {
-		layout.SetBackgroundColor(config.GetColor("bg"))
+	layout.AddItem(bottomRow, 1, 1, false)
 
-		bottomBar.SetBackgroundColor(config.GetColor("bottombar_bg"))
-		bottomBar.SetLabelColor(config.GetColor("bottombar_label"))
-		bottomBar.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
-		bottomBar.SetFieldTextColor(config.GetColor("bottombar_text"))
+	uiColors()
 
-		browser.SetTabBackgroundColor(config.GetColor("bg"))
-		browser.SetTabBackgroundColorFocused(config.GetColor("tab_num"))
-		browser.SetTabTextColor(config.GetColor("tab_num"))
-		browser.SetTabTextColorFocused(config.GetColor("bg"))
-		browser.SetTabSwitcherDivider(
-			"",
-			fmt.Sprintf("[%s:%s]|[-]", config.GetColorString("tab_divider"), config.GetColorString("bg")),
-			fmt.Sprintf("[%s:%s]|[-]", config.GetColorString("tab_divider"), config.GetColorString("bg")),
-		)
-		browser.Switcher.SetBackgroundColor(config.GetColor("bg"))
-	} else {
-		bottomBar.SetBackgroundColor(tcell.ColorWhite)
-		bottomBar.SetLabelColor(tcell.ColorBlack)
-		bottomBar.SetFieldBackgroundColor(tcell.ColorWhite)
-		bottomBar.SetFieldTextColor(tcell.ColorBlack)
-
-		browser.SetTabBackgroundColor(tcell.ColorBlack)
-		browser.SetTabBackgroundColorFocused(tcell.ColorWhite)
-		browser.SetTabTextColor(tcell.ColorWhite)
-		browser.SetTabTextColorFocused(tcell.ColorBlack)
-		browser.SetTabSwitcherDivider(
-			"",
-			"[#ffffff:#000000]|[-]",
-			"[#ffffff:#000000]|[-]",
-		)
-	}
+	bottomBar.SetChangedFunc(func(text string) {
+		if barMode == barFindLink {
+			findLinkChanged(text)
+		}
+	})
 
 	bottomBar.SetDoneFunc(func(key tcell.Key) {
 		tab := curTab
@@ -136,6 +139,7 @@ func Init(version, commit, builtBy string) {
 		// Reset func to set the bottomBar back to what it was before
 		// Use for errors.
 		reset := func() {
+			setBottomBarMode(barURL)
 			bottomBar.SetLabel("")
 			tabs[tab].applyAll()
 			App.SetFocus(tabs[tab].view)
@@ -154,6 +158,58 @@ func Init(version, commit, builtBy string) {
 				reset()
 				return
 			}
+			if barMode == barFindLink {
+				u, ok := foundLink(query)
+				if !ok {
+					// Let the text be fixed and tried again
+					bottomBar.SetLabel("[::b]No links match: [::-]")
+					return
+				}
+				reset()
+				followLink(tabs[tab], tabs[tab].page.URL, u)
+				return
+			}
+			if barMode == barConfirmQuit {
+				reset()
+				if a := strings.ToLower(strings.TrimSpace(query)); a == "y" || a == "yes" {
+					Stop()
+				}
+				return
+			}
+			if barMode == barConfirmHTTP {
+				// Confirming whether to open the URL in the browser
+				u := bottomBarHTTP
+				reset()
+				if a := strings.ToLower(strings.TrimSpace(query)); a == "y" || a == "yes" {
+					if strings.HasPrefix(u, "mailto:") {
+						go openMailto(u)
+					} else {
+						go handleHTTP(u, true)
+					}
+				}
+				return
+			}
+			if barMode == barSearch {
+				// Searching the page
+				setBottomBarMode(barURL)
+				App.SetFocus(tabs[tab].view)
+				tabs[tab].search(query)
+				return
+			}
+			if barMode == barCommand || query[0] == ':' {
+				// A command, which can also be typed in the URL bar after a colon
+				f, ok := findCommand(strings.TrimPrefix(query, ":"))
+				if !ok {
+					// Let the command be fixed and tried again
+					setBottomBarMode(barCommand)
+					bottomBar.SetLabel("[::b]Unknown command: [::-]")
+					bottomBar.SetText(strings.TrimPrefix(query, ":"))
+					return
+				}
+				reset()
+				f()
+				return
+			}
 			if query[0] == '.' && tabs[tab].hasContent() {
 				// Relative url
 				current, err := url.Parse(tabs[tab].page.URL)
@@ -218,12 +274,12 @@ func Init(version, commit, builtBy string) {
 
 						u := viper.GetString("a-general.search") + "?" + gemini.QueryEscape(query)
 						// Don't use the cached version of the search
-						cache.RemovePage(normalizeURL(u))
+						tabs[tab].pageCache().Remove(normalizeURL(u))
 						URL(u)
 					} else {
 						// Full URL
 						// Don't use cached version for manually entered URL
-						cache.RemovePage(normalizeURL(fixUserURL(query)))
+						tabs[tab].pageCache().Remove(normalizeURL(fixUserURL(query)))
 						URL(query)
 					}
 					return
@@ -242,8 +298,17 @@ func Init(version, commit, builtBy string) {
 			// Set back to what it was
 			reset()
 			return
+		case tcell.KeyTab, tcell.KeyBacktab:
+			if barMode == barCommand {
+				if key == tcell.KeyTab {
+					bottomBar.SetText(completeCommand(bottomBar.GetText()))
+				}
+			} else if barMode == barURL {
+				// Typing a URL
+				suggestURL(key == tcell.KeyBacktab)
+			}
+			return
 		}
-		// Other potential keys are Tab and Backtab, they are ignored
 	})
 
 	// Render the default new tab content ONCE and store it for later
@@ -284,12 +349,29 @@ func Init(version, commit, builtBy string) {
 			// It's focused on help right now
 			return event
 		}
+		_, ok = App.GetFocus().(*cview.List)
+		if ok {
+			// It's focused on the table of contents or link list right now
+			return event
+		}
+		if App.GetFocus() == multilineText {
+			// Typing in the multi-line input
+			return event
+		}
 
 		// To add a configurable global key command, you'll need to update one of
 		// the two switch statements here.  You'll also need to add an enum entry in
 		// config/keybindings.go, update KeyInit() in config/keybindings.go, add a default
 		// keybinding in config/config.go and update the help panel in display/help.go
 
+		if tabs[curTab].mode == tabModeDone && tabs[curTab].page.Mode == structs.ModeLinkSelect &&
+			event.Key() == tcell.KeyRune && event.Modifiers() == tcell.ModNone &&
+			event.Rune() >= '0' && event.Rune() <= '9' {
+			// Digits select a link by its number, instead of following it right away
+			tabs[curTab].typeLinkNumber(event.Rune())
+			return nil
+		}
+
 		cmd := config.TranslateKeyEvent(event)
 		if tabs[curTab].mode == tabModeDone {
 			// All the keys and operations that can only work while NOT loading
@@ -298,6 +380,9 @@ func Init(version, commit, builtBy string) {
 			case config.CmdReload:
 				Reload()
 				return nil
+			case config.CmdReloadAll:
+				ReloadAll()
+				return nil
 			case config.CmdHome:
 				URL(viper.GetString("a-general.home"))
 				return nil
@@ -308,6 +393,12 @@ func Init(version, commit, builtBy string) {
 			case config.CmdAddBookmark:
 				go addBookmark()
 				return nil
+			case config.CmdRenameBkmk, config.CmdRemoveBkmk, config.CmdBkmkUp, config.CmdBkmkDown:
+				if tabs[curTab].page.URL == "about:bookmarks" {
+					go editBookmark(tabs[curTab], cmd)
+					return nil
+				}
+				// Otherwise the key can be used by something else
 			case config.CmdPgup:
 				tabs[curTab].pageUp()
 				return nil
@@ -328,17 +419,91 @@ func Init(version, commit, builtBy string) {
 				return nil
 			case config.CmdBottom:
 				// Space starts typing, like Bombadillo
+				setBottomBarMode(barURL)
 				bottomBar.SetLabel("[::b]URL/Num./Search: [::-]")
 				bottomBar.SetText("")
+				urlSuggestions = nil
 				// Don't save bottom bar, so that whenever you switch tabs, it's not in that mode
 				App.SetFocus(bottomBar)
 				return nil
 			case config.CmdEdit:
 				// Letter e allows to edit current URL
+				setBottomBarMode(barURL)
 				bottomBar.SetLabel("[::b]Edit URL: [::-]")
 				bottomBar.SetText(tabs[curTab].page.URL)
+				urlSuggestions = nil
 				App.SetFocus(bottomBar)
 				return nil
+			case config.CmdSearch:
+				// Search within the page
+				setBottomBarMode(barSearch)
+				bottomBar.SetLabel("[::b]Search page: [::-]")
+				bottomBar.SetText("")
+				App.SetFocus(bottomBar)
+				return nil
+			case config.CmdFindLink:
+				startFindLink(tabs[curTab])
+				return nil
+			case config.CmdNextMatch:
+				tabs[curTab].nextSearchMatch(false)
+				return nil
+			case config.CmdPrevMatch:
+				tabs[curTab].nextSearchMatch(true)
+				return nil
+			case config.CmdReader:
+				go tabs[curTab].toggleReader()
+				return nil
+			case config.CmdCommand:
+				commandPalette()
+				return nil
+			case config.CmdUpload:
+				go editAndUpload(tabs[curTab])
+				return nil
+			case config.CmdGoto:
+				gotoPalette()
+				return nil
+			case config.CmdSelect:
+				tabs[curTab].startTextSelect()
+				return nil
+			case config.CmdCopyPageURL:
+				go copyPageURL()
+				return nil
+			case config.CmdCopyTargetURL:
+				go copyTargetURL()
+				return nil
+			case config.CmdCopyHeadingURL:
+				go copyHeadingURL()
+				return nil
+			case config.CmdFold:
+				go toggleFold(tabs[curTab])
+				return nil
+			case config.CmdSource:
+				go tabs[curTab].toggleSource()
+				return nil
+			case config.CmdSourceEditor:
+				go viewInEditor(tabs[curTab])
+				return nil
+			case config.CmdTOC:
+				TOC()
+				return nil
+			case config.CmdLinks:
+				Links()
+				return nil
+			case config.CmdRecent:
+				Recent()
+				return nil
+			case config.CmdNextLink:
+				tabs[curTab].selectNearLink(true)
+				return nil
+			case config.CmdPrevLink:
+				tabs[curTab].selectNearLink(false)
+				return nil
+			case config.CmdRoot:
+				goToRoot(tabs[curTab])
+				return nil
+			case config.CmdUp:
+				goUp(tabs[curTab])
+				return nil
 			case config.CmdBack:
 				histBack(tabs[curTab])
 				return nil
@@ -355,7 +520,7 @@ func Init(version, commit, builtBy string) {
 			}
 
 			// Number key: 1-9, 0, LINK1-LINK10
-			if cmd >= config.CmdLink1 && cmd <= config.CmdLink0 {
+			if cmd >= config.CmdLink1 && cmd <= config.CmdLink0 && !tabs[curTab].page.Source {
 				if int(cmd) <= len(tabs[curTab].page.Links) {
 					// It's a valid link number
 					followLink(tabs[curTab], tabs[curTab].page.URL, tabs[curTab].page.Links[cmd-1])
@@ -369,6 +534,7 @@ func Init(version, commit, builtBy string) {
 		switch cmd {
 		case config.CmdNewTab:
 			if tabs[curTab].page.Mode == structs.ModeLinkSelect {
+				linkFollowed(tabs[curTab].page, tabs[curTab].page.Selected)
 				next, err := resolveRelLink(tabs[curTab], tabs[curTab].page.URL, tabs[curTab].page.Selected)
 				if err != nil {
 					Error("URL Error", err.Error())
@@ -377,14 +543,40 @@ func Init(version, commit, builtBy string) {
 				NewTab()
 				URL(next)
 			} else {
-				NewTab()
+				OpenNewTab()
 			}
 			return nil
+		case config.CmdBackgroundTab:
+			if tabs[curTab].page.Mode != structs.ModeLinkSelect {
+				return nil
+			}
+			linkFollowed(tabs[curTab].page, tabs[curTab].page.Selected)
+			next, err := resolveRelLink(tabs[curTab], tabs[curTab].page.URL, tabs[curTab].page.Selected)
+			if err != nil {
+				Error("URL Error", err.Error())
+				return nil
+			}
+			if !isDisplayable(next) {
+				// It's opened in another application, there's no page for a tab
+				followLink(tabs[curTab], tabs[curTab].page.URL, tabs[curTab].page.Selected)
+				return nil
+			}
+			NewBackgroundTab(next)
+			return nil
+		case config.CmdDuplicateTab:
+			duplicateTab(tabs[curTab])
+			return nil
+		case config.CmdNewIncognitoTab:
+			NewIncognitoTab()
+			return nil
+		case config.CmdWrap:
+			toggleWrap()
+			return nil
 		case config.CmdCloseTab:
 			CloseTab()
 			return nil
 		case config.CmdQuit:
-			Stop()
+			confirmQuit("Quit Amfora?")
 			return nil
 		case config.CmdPrevTab:
 			// Wrap around, allow for modulo with negative numbers
@@ -394,9 +586,26 @@ func Init(version, commit, builtBy string) {
 		case config.CmdNextTab:
 			SwitchTab((curTab + 1) % NumTabs())
 			return nil
+		case config.CmdMoveTabLeft:
+			moveTab(-1)
+			return nil
+		case config.CmdMoveTabRight:
+			moveTab(1)
+			return nil
+		case config.CmdSplit:
+			toggleSplit()
+			return nil
+		case config.CmdSplitFocus:
+			focusSplit()
+			return nil
 		case config.CmdHelp:
 			Help()
 			return nil
+		case config.CmdStop:
+			if tabs[curTab].stopLoading() ||
+				(tabs[curTab].page.Mode == structs.ModeOff && tabs[curTab].stopStream()) {
+				return nil
+			}
 		}
 
 		if cmd >= config.CmdTab1 && cmd <= config.CmdTab0 {
@@ -414,12 +623,102 @@ func Init(version, commit, builtBy string) {
 	})
 }
 
+// reformatTabs resets the left margin of all tabs, and reformats the content of
+// the provided one, which should be the current tab. The other tabs are
+// reformatted when they are switched to.
+// It's used when the terminal size or the left margin changes.
+func reformatTabs(t *tab) {
+	reformatMu.Lock() // Only allow one reformat job at a time
+	for i := range tabs {
+		// Overwrite all tabs with a new, differently sized, left margin
+		browser.AddTab(
+			strconv.Itoa(i),
+			tabLabel(i),
+			makeContentLayout(tabs[i].contentView(), leftMargin()),
+		)
+		if tabs[i] == t {
+			// Reformat page ASAP, in the middle of loop
+			reformatPageAndSetView(t, t.page)
+			// The left margin was reset above, even if the page didn't need
+			// reformatting, so the horizontal scroll has to be applied again
+			t.applyHorizontalScroll()
+		}
+	}
+	if splitTab != nil {
+		// The tab in the other pane can be seen too
+		reformatPageAndSetView(splitTab, splitTab.page)
+		applySplit()
+	}
+	App.Draw()
+	reformatMu.Unlock()
+}
+
+// uiColors sets the colors of the tab bar, the bottomBar, and the help table
+// from the theme, or to black and white if colors are disabled.
+func uiColors() {
+	if viper.GetBool("a-general.color") {
+		layout.SetBackgroundColor(config.GetColor("bg"))
+
+		bottomBar.SetBackgroundColor(config.GetColor("bottombar_bg"))
+		bottomBar.SetLabelColor(config.GetColor("bottombar_label"))
+		bottomBar.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
+		bottomBar.SetFieldTextColor(config.GetColor("bottombar_text"))
+
+		browser.SetTabBackgroundColor(config.GetColor("bg"))
+		browser.SetTabBackgroundColorFocused(config.GetColor("tab_num"))
+		browser.SetTabTextColor(config.GetColor("tab_num"))
+		browser.SetTabTextColorFocused(config.GetColor("bg"))
+		browser.SetTabSwitcherDivider(
+			"",
+			fmt.Sprintf("[%s:%s]|[-]", config.GetColorString("tab_divider"), config.GetColorString("bg")),
+			fmt.Sprintf("[%s:%s]|[-]", config.GetColorString("tab_divider"), config.GetColorString("bg")),
+		)
+		browser.Switcher.SetBackgroundColor(config.GetColor("bg"))
+	} else {
+		bottomBar.SetBackgroundColor(tcell.ColorWhite)
+		bottomBar.SetLabelColor(tcell.ColorBlack)
+		bottomBar.SetFieldBackgroundColor(tcell.ColorWhite)
+		bottomBar.SetFieldTextColor(tcell.ColorBlack)
+
+		browser.SetTabBackgroundColor(tcell.ColorBlack)
+		browser.SetTabBackgroundColorFocused(tcell.ColorWhite)
+		browser.SetTabTextColor(tcell.ColorWhite)
+		browser.SetTabTextColorFocused(tcell.ColorBlack)
+		browser.SetTabSwitcherDivider(
+			"",
+			"[#ffffff:#000000]|[-]",
+			"[#ffffff:#000000]|[-]",
+		)
+	}
+
+	helpTable.SetBackgroundColor(config.GetColor("bg"))
+	helpTable.SetTextColor(config.GetColor("regular_text"))
+	helpTable.SetScrollBarColor(config.GetColor("scrollbar"))
+
+	scrollIndicatorColors()
+}
+
 // Stop stops the app gracefully.
 // In the future it will handle things like ongoing downloads, etc
 func Stop() {
+	SaveSession() //nolint:errcheck // The app is closing, there's no way to show the error
+	stopRemote()
 	App.Stop()
 }
 
+// confirmQuit asks in the bottomBar whether to quit, with the question,
+// if quit_confirm is set. Otherwise it quits right away.
+func confirmQuit(question string) {
+	if !viper.GetBool("a-general.quit_confirm") {
+		Stop()
+		return
+	}
+	setBottomBarMode(barConfirmQuit)
+	bottomBar.SetLabel("[::b]" + question + " (y/n): [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+}
+
 // NewTab opens a new tab and switches to it, displaying the
 // the default empty content because there's no URL.
 func NewTab() {
@@ -446,8 +745,8 @@ func NewTab() {
 
 	browser.AddTab(
 		strconv.Itoa(curTab),
-		makeTabLabel(strconv.Itoa(curTab+1)),
-		makeContentLayout(tabs[curTab].view, leftMargin()),
+		tabLabel(curTab),
+		makeContentLayout(tabs[curTab].contentView(), leftMargin()),
 	)
 	browser.SetCurrentTab(strconv.Itoa(curTab))
 	App.SetFocus(tabs[curTab].view)
@@ -460,6 +759,137 @@ func NewTab() {
 	App.Draw()
 }
 
+// NewBackgroundTab opens a new tab after the others and loads the URL in it,
+// without switching to it. The new tab is incognito if the current one is.
+// It's marked in the tab bar once the page has loaded, until it's switched to.
+func NewBackgroundTab(u string) {
+	t := makeNewTab()
+	t.incognito = tabs[curTab].incognito
+	tabs = append(tabs, t)
+	i := NumTabs() - 1
+
+	browser.AddTab(
+		strconv.Itoa(i),
+		tabLabel(i),
+		makeContentLayout(t.contentView(), leftMargin()),
+	)
+	temp := newTabPage // Copy
+	setPage(t, &temp)
+	t.addToHistory("about:newtab")
+	t.history.pos = 0 // Manually set as first page
+
+	go goURL(t, u)
+}
+
+// NewIncognitoTab opens a new tab like NewTab, where nothing that's loaded
+// is kept outside of the tab. See the incognito field of the tab struct.
+func NewIncognitoTab() {
+	NewTab()
+	tabs[curTab].incognito = true
+	browser.SetTabLabel(strconv.Itoa(curTab), tabLabel(curTab))
+	loadNewTabURL()
+}
+
+// OpenNewTab opens a new tab like NewTab, for when the user asks for one
+// instead of for a link to be opened in it. The newtab_url from the config
+// is loaded in the tab if it's set, otherwise the new tab page is kept.
+func OpenNewTab() {
+	NewTab()
+	loadNewTabURL()
+}
+
+// loadNewTabURL loads the newtab_url from the config in the current tab,
+// if it's set.
+func loadNewTabURL() {
+	u := strings.TrimSpace(viper.GetString("a-general.newtab_url"))
+	if u == "" || u == "about:newtab" {
+		return
+	}
+	URL(u)
+}
+
+// duplicateTab opens a new tab with a copy of the history and page of the
+// provided tab, and switches to it. The page is displayed without being fetched
+// again, and nothing is shared, so navigating in one tab doesn't affect the other.
+func duplicateTab(src *tab) {
+	if src.mode != tabModeDone {
+		// Page is loading, or isn't loaded yet
+		return
+	}
+
+	// Save bottomBar state of the current tab before switching
+	tabs[curTab].saveBottomBar()
+
+	t := makeNewTab()
+	t.history.urls = make([]string, len(src.history.urls))
+	copy(t.history.urls, src.history.urls)
+	t.history.pos = src.history.pos
+	t.title = src.title
+	t.incognito = src.incognito
+	t.history.states = make([]histState, len(src.history.states))
+	copy(t.history.states, src.history.states)
+
+	page := copyPage(src.page)
+	// Selections aren't copied
+	page.Mode = structs.ModeOff
+	page.Selected = ""
+	page.SelectedID = ""
+
+	curTab = NumTabs()
+	tabs = append(tabs, t)
+	setPage(t, page)
+	t.applyScroll()
+	browser.SetCurrentTab(strconv.Itoa(curTab))
+	t.applyBottomBar()
+
+	App.Draw()
+}
+
+// copyPage returns a copy of the page for another tab, which doesn't share
+// what can change on either of them later.
+func copyPage(src *structs.Page) *structs.Page {
+	page := *src
+	page.Links = make([]string, len(src.Links))
+	copy(page.Links, src.Links)
+	page.Prompts = make([]string, len(src.Prompts))
+	copy(page.Prompts, src.Prompts)
+	if src.NewLinks != nil {
+		page.NewLinks = make([]bool, len(src.NewLinks))
+		copy(page.NewLinks, src.NewLinks)
+	}
+	if src.PreBlocks != nil {
+		page.PreBlocks = make([]structs.PreBlock, len(src.PreBlocks))
+		copy(page.PreBlocks, src.PreBlocks)
+	}
+	if src.Folds != nil {
+		page.Folds = make(map[int]bool, len(src.Folds))
+		for n, folded := range src.Folds {
+			page.Folds[n] = folded
+		}
+	}
+	return &page
+}
+
+// NewTabWithURL opens a new tab, switches to it, and loads the provided URL.
+// Unlike NewTab, it is safe to call from any goroutine.
+//
+// ErrUnsupportedScheme is returned if the URL can't be displayed in a tab.
+func NewTabWithURL(u string) error {
+	u = strings.TrimSpace(u)
+	if !strings.HasPrefix(u, "about:") {
+		u = fixUserURL(u)
+	}
+	if !isDisplayable(u) {
+		return ErrUnsupportedScheme
+	}
+
+	App.QueueUpdateDraw(func() {
+		NewTab()
+		URL(u)
+	})
+	return nil
+}
+
 // CloseTab closes the current tab and switches to the one to its left.
 func CloseTab() {
 	// Basically the NewTab() func inverted
@@ -473,10 +903,16 @@ func CloseTab() {
 
 	if NumTabs() <= 1 {
 		// There's only one tab open, close the app instead
-		Stop()
+		confirmQuit("Close the last tab and quit?")
 		return
 	}
 
+	t := tabs[curTab]
+	t.stopAutoReload()
+	if t.incognito {
+		// Discard the history, in case anything still refers to the tab
+		t.history = &tabHistory{}
+	}
 	tabs = tabs[:len(tabs)-1]
 	browser.RemoveTab(strconv.Itoa(curTab))
 
@@ -489,6 +925,8 @@ func CloseTab() {
 	browser.SetCurrentTab(strconv.Itoa(curTab)) // Go to previous page
 	// Restore previous tab's state
 	tabs[curTab].applyAll()
+	// Closing a pane goes back to a single one
+	unsplit()
 
 	App.SetFocus(tabs[curTab].view)
 
@@ -511,21 +949,58 @@ func SwitchTab(tab int) {
 	if curTab > -1 {
 		// Save bottomBar state
 		tabs[curTab].saveBottomBar()
+		// The current tab goes to the other pane, if the new one is there
+		swapSplit(tabs[tab%NumTabs()])
 	}
 
 	curTab = tab % NumTabs()
+	if tabs[curTab].unseen {
+		tabs[curTab].unseen = false
+		browser.SetTabLabel(strconv.Itoa(curTab), tabLabel(curTab))
+	}
 
 	// Display tab
 	reformatPageAndSetView(tabs[curTab], tabs[curTab].page)
 	browser.SetCurrentTab(strconv.Itoa(curTab))
+	applySplit()
 	tabs[curTab].applyAll()
 
 	App.SetFocus(tabs[curTab].view)
 
+	if tabs[curTab].mode == tabModeUnloaded {
+		// Restored from a session, and not loaded until now
+		go applyHist(tabs[curTab])
+	}
+
 	// Just in case
 	App.Draw()
 }
 
+// moveTab moves the current tab left or right by swapping it with its neighbour,
+// and keeps it as the current tab. The tab numbers are the positions of the tabs,
+// so they stay in order. It does nothing if the tab can't move further.
+func moveTab(delta int) {
+	i := curTab
+	j := curTab + delta
+	if j < 0 || j >= NumTabs() {
+		return
+	}
+
+	tabs[i].saveBottomBar()
+	tabs[i], tabs[j] = tabs[j], tabs[i]
+	curTab = j
+
+	// Each tab is re-added under its new number, with its page and margin
+	tabs[i].applyHorizontalScroll()
+	tabs[j].applyHorizontalScroll()
+	browser.SetCurrentTab(strconv.Itoa(curTab))
+	tabs[curTab].applyAll()
+	applySplit() // The tab number in the other pane might have changed
+
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+}
+
 func Reload() {
 	if tabs[curTab].page.URL == "about:newtab" && config.CustomNewTab {
 		// Re-render new tab, similar to Init()
@@ -533,12 +1008,13 @@ func Reload() {
 		tmpTermW := termW
 		renderedNewTabContent, newTabLinks := renderer.RenderGemini(newTabContent, textWidth(), false)
 		newTabPage = structs.Page{
-			Raw:       newTabContent,
-			Content:   renderedNewTabContent,
-			Links:     newTabLinks,
-			URL:       "about:newtab",
-			TermWidth: tmpTermW,
-			Mediatype: structs.TextGemini,
+			Raw:        newTabContent,
+			Content:    renderedNewTabContent,
+			Links:      newTabLinks,
+			URL:        "about:newtab",
+			TermWidth:  tmpTermW,
+			LeftMargin: leftMargin(),
+			Mediatype:  structs.TextGemini,
 		}
 		temp := newTabPage // Copy
 		setPage(tabs[curTab], &temp)
@@ -548,17 +1024,83 @@ func Reload() {
 	if !tabs[curTab].hasContent() {
 		return
 	}
+	go reloadTab(tabs[curTab], tabs[curTab].page.URL)
+}
+
+// The number of tabs that ReloadAll and open-session load at the same time.
+const reloadAllWorkers = 4
 
-	parsed, _ := url.Parse(tabs[curTab].page.URL)
-	go func(t *tab) {
-		cache.RemovePage(tabs[curTab].page.URL)
+// loadInWorkers runs the funcs in the background, a few at a time,
+// in the order they're in.
+func loadInWorkers(loads []func()) {
+	ch := make(chan func(), len(loads))
+	for _, load := range loads {
+		ch <- load
+	}
+	close(ch)
+
+	for i := 0; i < reloadAllWorkers && i < len(loads); i++ {
+		go func() {
+			for load := range ch {
+				load()
+			}
+		}()
+	}
+}
+
+// ReloadAll loads the pages of all the tabs again, starting with the current one.
+// Only a few are loaded at a time, and each tab is updated once its page is loaded.
+// Tabs that are loading already or haven't been loaded since the session was restored
+// are left alone.
+func ReloadAll() {
+	// Reload the current tab first, it's the one being looked at
+	loads := make([]func(), 0, len(tabs))
+	for i := range tabs {
+		t := tabs[(curTab+i)%len(tabs)]
+		if t.mode == tabModeDone && t.hasContent() {
+			// The URL is read here, because the tab can be changed while it waits
+			u := t.page.URL
+			loads = append(loads, func() { reloadTab(t, u) })
+		}
+	}
+	loadInWorkers(loads)
+}
+
+// reloadTab loads the page at u into the tab again, skipping the cache.
+// u is the URL of the tab's page, read before this is called.
+// It should be called in a goroutine.
+func reloadTab(t *tab, u string) {
+	if !isValidTab(t) || t.mode != tabModeDone || t.page.URL != u {
+		// The tab changed since it was picked to be reloaded
+		return
+	}
+	// To find the links that are new
+	oldPage := t.page
+	// Removing the page means it's always downloaded again,
+	// whatever its age and the cache size
+	t.pageCache().Remove(u)
+	if parsed, err := url.Parse(u); err == nil {
 		cache.RemoveFavicon(parsed.Host)
-		handleURL(t, t.page.URL, 0) // goURL is not used bc history shouldn't be added to
-		if t == tabs[curTab] {
-			// Display the bottomBar state that handleURL set
-			t.applyBottomBar()
+	}
+	_, displayed := handleURL(t, u, 0) // goURL is not used bc history shouldn't be added to
+	if displayed && t.page != oldPage && t.page.URL == u && t.page.Mediatype == structs.TextGemini {
+		t.page.NewLinks = renderer.NewLinks(oldPage.Links, t.page.Links)
+		if t.page.NewLinks != nil {
+			t.page.TermWidth = -1
+			reformatPageAndSetView(t, t.page)
 		}
-	}(tabs[curTab])
+	}
+	if displayed && t.fragment != "" {
+		t.scrollToFragment(t.fragment)
+	}
+	if t == tabs[curTab] {
+		// Display the bottomBar state that handleURL set
+		t.applyBottomBar()
+	} else if displayed && isValidTab(t) {
+		// Loaded in the background, so show that in the tab bar
+		t.unseen = true
+		browser.SetTabLabel(strconv.Itoa(tabNumber(t)), tabLabel(tabNumber(t)))
+	}
 }
 
 // URL loads and handles the provided URL for the current tab.
@@ -575,6 +1117,44 @@ func URL(u string) {
 	go goURL(t, fixUserURL(u))
 }
 
+// goToRoot goes to the root of the site of the selected link, or of the
+// current page if no link is selected.
+func goToRoot(t *tab) {
+	u := t.page.URL
+	if t.page.Mode == structs.ModeLinkSelect && t.page.Selected != "" {
+		var err error
+		u, err = resolveRelLink(t, t.page.URL, t.page.Selected)
+		if err != nil {
+			Error("URL Error", err.Error())
+			return
+		}
+	}
+	root, ok := rootURL(u)
+	if !ok {
+		Info("This page isn't from a server, so it has no root to go to.")
+		return
+	}
+	if normalizeURL(root) == normalizeURL(t.page.URL) {
+		Info("Already at the root of the site.")
+		return
+	}
+	URL(root)
+}
+
+// goUp goes to the parent directory of the current page.
+func goUp(t *tab) {
+	if !t.hasContent() {
+		Info("This page has no directory to go up from.")
+		return
+	}
+	parent, ok := parentURL(t.page.URL)
+	if !ok {
+		Info("Already at the root of the site.")
+		return
+	}
+	URL(parent)
+}
+
 func NumTabs() int {
 	return len(tabs)
 }
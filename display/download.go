@@ -16,6 +16,7 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/rr"
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/amfora/sysopen"
 	"github.com/makeworld-the-better-one/go-gemini"
@@ -36,6 +37,32 @@ func dlInit() {
 	panels.AddPanel("dl", dlModal, false, false)
 	panels.AddPanel("dlChoice", dlChoiceModal, false, false)
 
+	dlm := dlModal
+	chm := dlChoiceModal
+	dlColors()
+
+	chm.AddButtons([]string{"Open", "Download", "Cancel"})
+	chm.SetBorder(true)
+	chm.GetFrame().SetTitleAlign(cview.AlignCenter)
+	chm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		dlChoiceCh <- buttonLabel
+	})
+
+	dlm.SetBorder(true)
+	frame := dlm.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" Download ")
+	dlm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Ok" {
+			panels.HidePanel("dl")
+			App.SetFocus(tabs[curTab].view)
+			App.Draw()
+		}
+	})
+}
+
+// dlColors sets the colors of the download modals.
+func dlColors() {
 	dlm := dlModal
 	chm := dlChoiceModal
 	if viper.GetBool("a-general.color") {
@@ -82,25 +109,6 @@ func dlInit() {
 		frame.SetBorderColor(tcell.ColorWhite)
 		frame.SetTitleColor(tcell.ColorWhite)
 	}
-
-	chm.AddButtons([]string{"Open", "Download", "Cancel"})
-	chm.SetBorder(true)
-	chm.GetFrame().SetTitleAlign(cview.AlignCenter)
-	chm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		dlChoiceCh <- buttonLabel
-	})
-
-	dlm.SetBorder(true)
-	frame := dlm.GetFrame()
-	frame.SetTitleAlign(cview.AlignCenter)
-	frame.SetTitle(" Download ")
-	dlm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Ok" {
-			panels.HidePanel("dl")
-			App.SetFocus(tabs[curTab].view)
-			App.Draw()
-		}
-	})
 }
 
 func getMediaHandler(resp *gemini.Response) config.MediaHandler {
@@ -134,6 +142,11 @@ func getMediaHandler(resp *gemini.Response) config.MediaHandler {
 // dlChoice displays the download choice modal and acts on the user's choice.
 // It should run in a goroutine.
 func dlChoice(text, u string, resp *gemini.Response) {
+	if r, ok := resp.Body.(*rr.RestartReader); ok {
+		// The body is only read once more, and it could be large,
+		// so don't keep it all in memory
+		r.StopBuffering()
+	}
 	mediaHandler := getMediaHandler(resp)
 	var choice string
 
@@ -150,9 +163,9 @@ func dlChoice(text, u string, resp *gemini.Response) {
 
 	if choice == "Download" {
 		panels.HidePanel("dlChoice")
+		App.SetFocus(tabs[curTab].view)
 		App.Draw()
-		downloadURL(config.DownloadsDir, u, resp)
-		resp.Body.Close() // Only close when the file is downloaded
+		downloadInBackground(config.DownloadsDir, u, resp) // Closes the body when it's done
 		return
 	}
 	if choice == "Open" {
@@ -252,6 +265,7 @@ func downloadURL(dir, u string, resp *gemini.Response) string {
 		return ""
 	}
 	defer f.Close()
+	d := addDownload(u, savePath)
 
 	done := false
 
@@ -272,8 +286,9 @@ func downloadURL(dir, u string, resp *gemini.Response) string {
 	App.SetFocus(dlModal)
 	App.Draw()
 
-	_, err = io.Copy(io.MultiWriter(f, bar), resp.Body)
+	_, err = io.Copy(io.MultiWriter(f, bar, d), resp.Body)
 	done = true
+	d.finish(err)
 	if err != nil {
 		panels.HidePanel("dl")
 		Error("Download Error", err.Error())
@@ -300,6 +315,12 @@ func downloadPage(p *structs.Page) (string, error) {
 
 	if p.Mediatype == structs.TextGemini {
 		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".gmi")
+	} else if p.Mediatype == structs.TextMarkdown {
+		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".md")
+	} else if p.Mediatype == structs.ImagePNG {
+		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".png")
+	} else if p.Mediatype == structs.ImageJPEG {
+		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".jpg")
 	} else {
 		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".txt")
 	}
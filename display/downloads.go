@@ -0,0 +1,219 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// Downloads made this session are tracked, so they can be listed on the
+// about:downloads page with their progress.
+//
+// Gemini has no way to request part of a file, so failed downloads can't be
+// resumed. Retrying a download loads the URL again, and starts from the beginning.
+
+type dlStatus int
+
+const (
+	dlActive dlStatus = iota
+	dlDone
+	dlFailed
+)
+
+// download is a download made this session. It counts the bytes written to it.
+type download struct {
+	URL  string
+	Path string
+
+	mu     sync.Mutex
+	bytes  int64
+	status dlStatus
+	err    error
+}
+
+func (d *download) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	d.bytes += int64(len(p))
+	d.mu.Unlock()
+	return len(p), nil
+}
+
+// finish marks the download as done, or failed if err isn't nil.
+func (d *download) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.status = dlFailed
+		d.err = err
+	} else {
+		d.status = dlDone
+	}
+}
+
+// state returns the bytes downloaded so far, and the status with its error.
+func (d *download) state() (int64, dlStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.bytes, d.status, d.err
+}
+
+var downloads = make([]*download, 0)
+var downloadsMu = sync.Mutex{}
+
+// addDownload starts tracking a download of the URL to the path.
+func addDownload(u, path string) *download {
+	d := &download{URL: u, Path: path}
+	downloadsMu.Lock()
+	downloads = append(downloads, d)
+	downloadsMu.Unlock()
+	return d
+}
+
+// refreshDownloadsPage redisplays about:downloads if it's in the current tab,
+// so the progress shown is current. It's safe to call from any goroutine.
+func refreshDownloadsPage() {
+	App.QueueUpdateDraw(func() {
+		t := tabs[curTab]
+		if t.page.URL != "about:downloads" || t.mode != tabModeDone || t.page.Mode != structs.ModeOff ||
+			App.GetFocus() != t.view {
+			// Don't interrupt link selection, searching, or a modal
+			return
+		}
+		t.saveScroll()
+		row, col := t.page.Row, t.page.Column
+		DownloadsPage(t, "about:downloads")
+		t.page.Row, t.page.Column = row, col
+		t.applyScroll()
+	})
+}
+
+// downloadInBackground saves the response body in the directory, without
+// blocking the UI. Progress can be seen on the about:downloads page.
+// The response body is closed when the download is done.
+func downloadInBackground(dir, u string, resp *gemini.Response) {
+	savePath, err := downloadNameFromURL(dir, u, "")
+	if err != nil {
+		resp.Body.Close()
+		Error("Download Error", "Error deciding on file name: "+err.Error())
+		return
+	}
+	f, err := os.OpenFile(savePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		resp.Body.Close()
+		Error("Download Error", "Error creating download file: "+err.Error())
+		return
+	}
+	d := addDownload(u, savePath)
+	Info(fmt.Sprintf("Downloading to %s. The progress can be seen at about:downloads.", savePath))
+
+	go func() {
+		defer resp.Body.Close()
+
+		done := make(chan struct{})
+		go func() {
+			// Keep the page up to date while downloading
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					refreshDownloadsPage()
+				}
+			}
+		}()
+
+		_, err := io.Copy(io.MultiWriter(f, d), resp.Body)
+		close(done)
+		f.Close()
+		if err != nil {
+			os.Remove(savePath) // Remove partial file
+		}
+		d.finish(err)
+		refreshDownloadsPage()
+
+		if err != nil {
+			Error("Download Error", fmt.Sprintf("Downloading %s failed: %v. It can be retried from about:downloads",
+				filepath.Base(savePath), err))
+		}
+	}()
+}
+
+// DownloadsPage displays the downloads made this session in the tab.
+// `u` is the URL entered by the user, which can ask for a download to be retried.
+func DownloadsPage(t *tab, u string) {
+	if strings.HasPrefix(u, "about:downloads?retry=") {
+		retryDownload(t, strings.TrimPrefix(u, "about:downloads?retry="))
+		return
+	}
+
+	rawPage := "# Downloads\n\n"
+
+	downloadsMu.Lock()
+	list := make([]*download, len(downloads))
+	copy(list, downloads)
+	downloadsMu.Unlock()
+
+	if len(list) == 0 {
+		rawPage += "Nothing has been downloaded yet.\n"
+	}
+	// Most recent first
+	for i := len(list) - 1; i >= 0; i-- {
+		d := list[i]
+		bytes, status, err := d.state()
+		size := humanize.Bytes(uint64(bytes))
+
+		rawPage += fmt.Sprintf("## %s\n\n=> %s\n", filepath.Base(d.Path), d.URL)
+		switch status {
+		case dlActive:
+			rawPage += fmt.Sprintf("* Downloading, %s so far\n", size)
+		case dlDone:
+			rawPage += fmt.Sprintf("* Done, %s saved to %s\n", size, d.Path)
+		case dlFailed:
+			rawPage += fmt.Sprintf("* Failed after %s: %v\n=> about:downloads?retry=%d Retry\n", size, err, i)
+		}
+		rawPage += "\n"
+	}
+
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	page := structs.Page{
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        "about:downloads",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
+
+// retryDownload loads the URL of a failed download again, so it can be downloaded.
+func retryDownload(t *tab, n string) {
+	i, err := strconv.Atoi(n)
+
+	downloadsMu.Lock()
+	var d *download
+	if err == nil && i >= 0 && i < len(downloads) {
+		d = downloads[i]
+	}
+	downloadsMu.Unlock()
+
+	if d == nil {
+		Error("Download Error", "There's no download with that number.")
+		return
+	}
+	go goURL(t, d.URL)
+}
@@ -0,0 +1,93 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// runEditor opens the file in the user's editor, and waits for it to exit.
+// The editor is taken from $VISUAL or $EDITOR.
+func runEditor(path string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// The editor takes over the terminal until it exits. The screen is
+	// released for it and set up again afterward, so it's redrawn properly.
+	var err error
+	if !App.Suspend(func() {
+		err = cmd.Run()
+	}) {
+		return errors.New("the terminal is already being used by another program") //nolint:goerr113
+	}
+	if err != nil {
+		return fmt.Errorf("the editor exited with an error: %w", err)
+	}
+	return nil
+}
+
+// viewInEditor opens the raw content of the tab's page in the user's editor,
+// exactly as it was received, to be read and searched there. The file is
+// read-only, and it's removed once the editor exits.
+//
+// It should be called in a goroutine.
+func viewInEditor(t *tab) {
+	p := t.page
+	if !t.hasContent() || p.Graphic {
+		Info("Only text pages can be opened in your editor.")
+		return
+	}
+
+	var ext string
+	switch p.Mediatype {
+	case structs.TextGemini:
+		ext = ".gmi"
+	case structs.TextMarkdown:
+		ext = ".md"
+	default:
+		ext = ".txt"
+	}
+	f, err := ioutil.TempFile("", "amfora-*"+ext)
+	if err != nil {
+		Error("Editor Error", err.Error())
+		return
+	}
+	defer func() {
+		// Read-only files can't be removed on Windows
+		os.Chmod(f.Name(), 0600) //nolint:errcheck
+		os.Remove(f.Name())      //nolint:errcheck
+	}()
+	_, err = f.WriteString(p.Raw)
+	f.Close()
+	if err == nil {
+		err = os.Chmod(f.Name(), 0400)
+	}
+	if err != nil {
+		Error("Editor Error", err.Error())
+		return
+	}
+
+	if err := runEditor(f.Name()); err != nil {
+		Error("Editor Error", err.Error())
+	}
+}
@@ -45,6 +45,8 @@ func handleFile(u string) (*structs.Page, bool) {
 		mimetype := mime.TypeByExtension(filepath.Ext(uri.Path))
 		if strings.HasSuffix(u, ".gmi") || strings.HasSuffix(u, ".gemini") {
 			mimetype = "text/gemini"
+		} else if strings.HasSuffix(u, ".md") {
+			mimetype = "text/markdown"
 		}
 
 		if !strings.HasPrefix(mimetype, "text/") {
@@ -58,24 +60,47 @@ func handleFile(u string) (*structs.Page, bool) {
 			return page, false
 		}
 
+		if mimetype == "text/markdown" {
+			rendered, links, err := renderer.RenderMarkdown(string(content), textWidth(), false, "")
+			if err == nil {
+				return &structs.Page{
+					Mediatype:  structs.TextMarkdown,
+					URL:        u,
+					Raw:        string(content),
+					Content:    rendered,
+					Links:      links,
+					TermWidth:  termW,
+					LeftMargin: leftMargin(),
+					MaxPreCols: renderer.MaxPreCols(string(content), structs.TextMarkdown),
+				}, true
+			}
+			// Otherwise it's displayed as plaintext below
+		}
+
 		if mimetype == "text/gemini" {
-			rendered, links := renderer.RenderGemini(string(content), textWidth(), false)
+			r := renderer.RenderGemtext(string(content), renderer.PageOptions(textWidth(), false, "", nil))
 			page = &structs.Page{
-				Mediatype: structs.TextGemini,
-				URL:       u,
-				Raw:       string(content),
-				Content:   rendered,
-				Links:     links,
-				TermWidth: termW,
+				Mediatype:  structs.TextGemini,
+				URL:        u,
+				Raw:        string(content),
+				Content:    r.Content,
+				Links:      r.Links,
+				Headings:   r.Headings,
+				PreBlocks:  r.PreBlocks,
+				TermWidth:  termW,
+				LeftMargin: leftMargin(),
+				MaxPreCols: renderer.MaxPreCols(string(content), structs.TextGemini),
 			}
 		} else {
 			page = &structs.Page{
-				Mediatype: structs.TextPlain,
-				URL:       u,
-				Raw:       string(content),
-				Content:   renderer.RenderPlainText(string(content)),
-				Links:     []string{},
-				TermWidth: termW,
+				Mediatype:  structs.TextPlain,
+				URL:        u,
+				Raw:        string(content),
+				Content:    renderer.RenderPlainText(string(content)),
+				Links:      []string{},
+				TermWidth:  termW,
+				LeftMargin: leftMargin(),
+				MaxPreCols: renderer.MaxPreCols(string(content), structs.TextPlain),
 			}
 		}
 	}
@@ -109,12 +134,13 @@ func createDirectoryListing(u string) (*structs.Page, bool) {
 
 	rendered, links := renderer.RenderGemini(content, textWidth(), false)
 	page = &structs.Page{
-		Mediatype: structs.TextGemini,
-		URL:       u,
-		Raw:       content,
-		Content:   rendered,
-		Links:     links,
-		TermWidth: termW,
+		Mediatype:  structs.TextGemini,
+		URL:        u,
+		Raw:        content,
+		Content:    rendered,
+		Links:      links,
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
 	}
 	return page, true
 }
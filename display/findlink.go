@@ -0,0 +1,103 @@
+package display
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Finding a link types part of its text or URL in the bottomBar. The best
+// match is highlighted on the page as the text changes, and Enter follows it.
+// Nothing about the page is saved until then, so Esc puts it back with applyAll.
+
+// The tab the links are being found on, and the text and resolved URL of each
+// of its links.
+var findLinkTab *tab
+var findLinkTexts, findLinkURLs []string
+
+// startFindLink starts finding a link on the page of the tab.
+func startFindLink(t *tab) {
+	if t.page.Mode == structs.ModeTextSelect {
+		return
+	}
+	if !t.hasContent() || len(t.page.Links) == 0 || t.page.Source {
+		Info("This page has no links.")
+		return
+	}
+	t.saveScroll()
+	findLinkTab = t
+	findLinkTexts, findLinkURLs = pageLinks(t)
+
+	setBottomBarMode(barFindLink)
+	bottomBar.SetLabel("[::b]Find link: [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+}
+
+// findLink returns the index of the link that best matches the query, ignoring
+// case, and how many links match it. Links whose text starts with the query are
+// best, then those with a word in their text that starts with it, then those
+// with it anywhere in their text or URL. Earlier links are better than later
+// ones that match the same way. The index is -1 if no links match.
+func findLink(texts, urls []string, query string) (int, int) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return -1, 0
+	}
+	best, bestScore, n := -1, 0, 0
+	for i := range texts {
+		text := strings.ToLower(texts[i])
+		score := 0
+		switch {
+		case strings.HasPrefix(text, query):
+			score = 3
+		case strings.Contains(" "+text, " "+query):
+			score = 2
+		case strings.Contains(text, query) || (i < len(urls) && strings.Contains(strings.ToLower(urls[i]), query)):
+			score = 1
+		default:
+			continue
+		}
+		n++
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best, n
+}
+
+// findLinkChanged highlights the best match for the text typed so far.
+func findLinkChanged(text string) {
+	t := findLinkTab
+	if t == nil || t != tabs[curTab] {
+		return
+	}
+	i, n := findLink(findLinkTexts, findLinkURLs, text)
+	if i == -1 {
+		t.view.Highlight("")
+		if strings.TrimSpace(text) == "" {
+			bottomBar.SetLabel("[::b]Find link: [::-]")
+		} else {
+			bottomBar.SetLabel("[::b]No links match: [::-]")
+		}
+		return
+	}
+	t.view.Highlight(strconv.Itoa(i))
+	t.view.ScrollToHighlight()
+	bottomBar.SetLabel("[::b]Find link (" + strconv.Itoa(n) + "): [::-]")
+}
+
+// foundLink returns the page URL of the link that best matches the query,
+// to be followed.
+func foundLink(query string) (string, bool) {
+	t := findLinkTab
+	if t == nil || t != tabs[curTab] {
+		return "", false
+	}
+	i, _ := findLink(findLinkTexts, findLinkURLs, query)
+	if i == -1 || i >= len(t.page.Links) {
+		return "", false
+	}
+	return t.page.Links[i], true
+}
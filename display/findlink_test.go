@@ -0,0 +1,35 @@
+package display
+
+import "testing"
+
+var findLinkTests = []struct {
+	query string
+	i     int
+	n     int
+}{
+	{"", -1, 0},
+	{"nothing", -1, 0},
+	{"about", 1, 2},       // Starts the text of the second, and is a word of the first
+	{"  ABOUT ", 1, 2},    // Spaces and case are ignored
+	{"page", 0, 1},        // A word in the text
+	{"log", 2, 2},         // Starts the text of the third, but only in the URL of the fourth
+	{"example.org", 3, 1}, // Just the URL
+	{"bout", 0, 2},        // Inside the text of both, so the first one wins
+	{"gemini://example.com", 0, 3},
+}
+
+func TestFindLink(t *testing.T) {
+	texts := []string{"The about page", "About me", "Log", "Elsewhere"}
+	urls := []string{
+		"gemini://example.com/about.gmi",
+		"gemini://example.com/me.gmi",
+		"gemini://example.com/log/",
+		"gemini://example.org/log/",
+	}
+	for _, tt := range findLinkTests {
+		i, n := findLink(texts, urls, tt.query)
+		if i != tt.i || n != tt.n {
+			t.Errorf("findLink(%q) = %d, %d, want %d, %d", tt.query, i, n, tt.i, tt.n)
+		}
+	}
+}
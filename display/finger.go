@@ -0,0 +1,27 @@
+package display
+
+import (
+	"context"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+)
+
+// handleFinger is used by handleURL for finger:// URLs, once the bottomBar
+// is showing that the page is loading. It returns the same values as handleURL.
+// The context stops loading the page, until it's displayed.
+//
+// It's only used when there's no Gemini proxy set for Finger.
+func handleFinger(ctx context.Context, t *tab, u string) (string, bool) {
+	res, err := client.FetchFinger(ctx, u)
+
+	// Loading may have taken a while, make sure tab is still valid
+	if !isValidTab(t) || ctx.Err() != nil {
+		return "", false
+	}
+	if err != nil {
+		fetchError(err)
+		return "", false
+	}
+
+	return handlePlain(ctx, t, u, res)
+}
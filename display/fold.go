@@ -0,0 +1,60 @@
+package display
+
+import (
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// preBlockAt returns the index of the preformatted block to fold or unfold,
+// for a screen showing height rows starting at row top. That's the unfolded
+// block the top row is in, or else the first one that starts on the screen.
+// It returns -1 if there's no such block.
+func preBlockAt(blocks []structs.PreBlock, top, height int) int {
+	for i, b := range blocks {
+		if !b.Folded && b.Row < top && top <= b.Row+b.Lines {
+			return i
+		}
+	}
+	for i, b := range blocks {
+		if b.Row >= top && b.Row < top+height {
+			return i
+		}
+	}
+	return -1
+}
+
+// toggleFold folds or unfolds the preformatted block at the top of the screen,
+// and renders the page again. It should be called in a goroutine.
+func toggleFold(t *tab) {
+	if t.page.Mediatype != structs.TextGemini || t.page.Source || t.mode != tabModeDone {
+		return
+	}
+	reformatMu.Lock()
+	row, _ := t.view.GetScrollOffset()
+	_, _, _, height := t.view.GetInnerRect()
+	i := preBlockAt(t.page.PreBlocks, row, height)
+	if i < 0 {
+		reformatMu.Unlock()
+		Info("There's no preformatted block to fold on the screen.")
+		return
+	}
+
+	b := t.page.PreBlocks[i]
+	if t.page.Folds == nil {
+		t.page.Folds = make(map[int]bool)
+	}
+	t.page.Folds[b.N] = !b.Folded
+	if b.Row < row {
+		// Folding the block the screen is in, so its first line should be kept on the screen
+		row = b.Row
+	}
+
+	t.clearSelected()
+	t.page.TermWidth = -1 // Force reformatting
+	reformatPage(t.page)
+	t.view.SetText(t.page.Content)
+	t.page.Row = row
+	t.applyScroll()
+	t.applyBottomBar()
+	reformatMu.Unlock()
+	App.Draw()
+}
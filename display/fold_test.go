@@ -0,0 +1,31 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+func TestPreBlockAt(t *testing.T) {
+	blocks := []structs.PreBlock{
+		{N: 0, Row: 2, Lines: 10, Folded: false}, // Rows 2 to 12
+		{N: 1, Row: 20, Lines: 10, Folded: true},
+		{N: 3, Row: 30, Lines: 10, Folded: true},
+	}
+	tests := []struct {
+		top, height, want int
+	}{
+		{0, 10, 0},  // First line of the block is on the screen
+		{5, 10, 0},  // Inside the unfolded block
+		{12, 10, 0}, // Last line of the unfolded block
+		{13, 10, 1}, // Next one down the screen
+		{21, 5, -1}, // Folded blocks above the screen aren't used
+		{21, 10, 2},
+		{40, 10, -1},
+	}
+	for _, tt := range tests {
+		if got := preBlockAt(blocks, tt.top, tt.height); got != tt.want {
+			t.Errorf("preBlockAt(top %d, height %d) = %d, want %d", tt.top, tt.height, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package display
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// handleGopher is used by handleURL for gopher:// URLs, once the bottomBar
+// is showing that the page is loading. It returns the same values as handleURL.
+// The context stops loading the page, until it's displayed.
+//
+// It's only used when there's no Gemini proxy set for Gopher.
+func handleGopher(ctx context.Context, t *tab, u string, numRedirects int) (string, bool) {
+	res, err := client.FetchGopher(ctx, u)
+
+	// Loading may have taken a while, make sure tab is still valid
+	if !isValidTab(t) || ctx.Err() != nil {
+		return "", false
+	}
+	if errors.Is(err, client.ErrGopherSearch) {
+		// Search servers need a query first, like a Gemini input request
+		query, ok := Input("Search", false)
+		if !ok {
+			return "", false
+		}
+		parsed, _ := url.Parse(u)
+		parsed.RawQuery = gemini.QueryEscape(query)
+		return handleURL(t, parsed.String(), numRedirects)
+	}
+	if err != nil {
+		fetchError(err)
+		return "", false
+	}
+
+	return handlePlain(ctx, t, u, res.Response)
+}
@@ -2,6 +2,7 @@ package display
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"mime"
@@ -11,6 +12,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/client"
@@ -25,21 +27,85 @@ import (
 	"github.com/spf13/viper"
 )
 
+// httpPrompt asks in the bottomBar whether to open the HTTP(S) URL in the browser,
+// as starting a browser from the terminal can be surprising.
+// If it's turned off in the config the URL is opened right away.
+func httpPrompt(u string) {
+	if !viper.GetBool("a-general.http_confirm") {
+		handleHTTP(u, true)
+		return
+	}
+	setBottomBarMode(barConfirmHTTP)
+	bottomBarHTTP = u
+	bottomBar.SetLabel("[::b]Open in browser? (y/n): [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+	App.Draw()
+}
+
+// httpCommandArgs returns the command and arguments to run to open the URL.
+// Any "%s" in the arguments is replaced with the URL, and if there isn't one
+// the URL is added as the last argument. No shell is used, so the URL
+// doesn't need to be escaped.
+func httpCommandArgs(command []string, u string) (string, []string) {
+	args := make([]string, 0, len(command))
+	replaced := false
+	for _, arg := range command[1:] {
+		if strings.Contains(arg, "%s") {
+			arg = strings.ReplaceAll(arg, "%s", u)
+			replaced = true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, u)
+	}
+	return command[0], args
+}
+
+// copyURLModal displays the URL that couldn't be opened, and offers to copy it.
+func copyURLModal(reason, u string) {
+	if !YesNo(reason + "\n\n" + u + "\n\nCopy the URL?") {
+		return
+	}
+	copyURL(u)
+}
+
+// fetchError displays the error from fetching a URL. Timeouts are explained,
+// since the error doesn't say which setting to change.
+func fetchError(err error) {
+	if client.IsTLSPolicyError(err) {
+		Error("TLS Mismatch", "The server's TLS version or cipher suite isn't allowed by the [tls] section of the config, "+
+			"so the connection was closed.\n\n"+err.Error())
+		return
+	}
+	if client.IsTimeout(err) {
+		Error("Timed Out", "The server didn't respond in time. "+
+			"The timeouts can be changed in the [timeouts] section of the config.\n\n"+err.Error())
+		return
+	}
+	Error("URL Fetch Error", err.Error())
+}
+
 // handleHTTP is used by handleURL.
 // It opens HTTP links and displays Info and Error modals.
 // Returns false if there was an error.
 func handleHTTP(u string, showInfo bool) bool {
+	if len(config.HTTPCommand) == 0 {
+		copyURLModal("Opening HTTP URLs is turned off.", u)
+		return false
+	}
 	if len(config.HTTPCommand) == 1 {
 		// Possibly a non-command
 
 		switch strings.TrimSpace(config.HTTPCommand[0]) {
 		case "", "off":
-			Error("HTTP Error", "Opening HTTP URLs is turned off.")
+			copyURLModal("Opening HTTP URLs is turned off.", u)
 			return false
 		case "default":
 			s, err := webbrowser.Open(u)
 			if err != nil {
-				Error("Webbrowser Error", err.Error())
+				copyURLModal("The browser couldn't be opened: "+err.Error()+".", u)
 				return false
 			}
 			if showInfo {
@@ -50,12 +116,8 @@ func handleHTTP(u string, showInfo bool) bool {
 	}
 
 	// Custom command
-	var err error = nil
-	if len(config.HTTPCommand) > 1 {
-		err = exec.Command(config.HTTPCommand[0], append(config.HTTPCommand[1:], u)...).Start()
-	} else {
-		err = exec.Command(config.HTTPCommand[0], u).Start()
-	}
+	name, args := httpCommandArgs(config.HTTPCommand, u)
+	err := exec.Command(name, args...).Start()
 	if err != nil {
 		Error("HTTP Error", "Error executing custom browser command: "+err.Error())
 		return false
@@ -90,56 +152,103 @@ func handleOther(u string) {
 	App.Draw()
 }
 
-// handleFavicon handles getting and displaying a favicon.
-func handleFavicon(t *tab, host string) {
-	defer func() {
-		// Update display if needed
-		if t.page.Favicon != "" && isValidTab(t) {
-			browser.SetTabLabel(strconv.Itoa(tabNumber(t)), makeTabLabel(t.page.Favicon))
-			App.Draw()
-		}
-	}()
+// Hosts whose favicons are being fetched, so each one is only fetched once at a time.
+// Tabs that need one of those favicons wait for its channel to be closed instead.
+var faviconFetches = make(map[string]chan struct{})
+var faviconFetchesMu sync.Mutex
 
-	if !viper.GetBool("a-general.emoji_favicons") {
-		// Not enabled
+// handleFavicon gets the favicon of the page's host, from the cache or by
+// fetching it, and displays it in the tab bar. Only Gemini pages have favicons.
+//
+// It should be called in a goroutine, as it may make a request.
+func handleFavicon(t *tab, p *structs.Page) {
+	if !viper.GetBool("a-general.emoji_favicons") || p.Favicon != "" {
+		// Not enabled, or already known
 		return
 	}
-	if t.page.Favicon != "" {
+	parsed, err := url.Parse(p.URL)
+	if err != nil || parsed.Scheme != "gemini" || parsed.Host == "" {
 		return
 	}
-	if host == "" {
+
+	fav := getFavicon(parsed.Host)
+	if fav == "" {
 		return
 	}
+	p.Favicon = fav
+	if isValidTab(t) && t.page == p {
+		// Still the page being displayed
+		browser.SetTabLabel(strconv.Itoa(tabNumber(t)), tabLabel(tabNumber(t)))
+		App.Draw()
+	}
+}
 
-	fav := cache.GetFavicon(host)
-	if fav == cache.KnownNoFavicon {
-		// It's been cached that this host doesn't have a favicon
-		return
+// getFavicon returns the favicon of the host, or an empty string if it doesn't
+// have one. It's fetched if it isn't cached, and the result is cached.
+func getFavicon(host string) string {
+	faviconFetchesMu.Lock()
+	if done, ok := faviconFetches[host]; ok {
+		// Another tab is fetching it
+		faviconFetchesMu.Unlock()
+		<-done
+		fav := cache.GetFavicon(host)
+		if fav == cache.KnownNoFavicon {
+			return ""
+		}
+		return fav
 	}
+	fav := cache.GetFavicon(host)
 	if fav != "" {
-		t.page.Favicon = fav
-		return
+		faviconFetchesMu.Unlock()
+		if fav == cache.KnownNoFavicon {
+			// It's been cached that this host doesn't have a favicon
+			return ""
+		}
+		return fav
 	}
+	done := make(chan struct{})
+	faviconFetches[host] = done
+	faviconFetchesMu.Unlock()
 
-	// No favicon cached
+	defer func() {
+		faviconFetchesMu.Lock()
+		delete(faviconFetches, host)
+		faviconFetchesMu.Unlock()
+		close(done)
+	}()
+
+	fav = fetchFavicon(host)
+	if fav == "" {
+		// No favicon is NOT known, could be a temporary error
+		return ""
+	}
+	cache.AddFavicon(host, fav)
+	if fav == cache.KnownNoFavicon {
+		return ""
+	}
+	return fav
+}
+
+// fetchFavicon requests the favicon.txt of the host, and returns the emoji in it.
+// See gemini://mozz.us/files/rfc_gemini_favicon.gmi for details.
+// cache.KnownNoFavicon is returned if the host has no valid favicon, and an empty
+// string if there was an error that might be temporary.
+func fetchFavicon(host string) string {
 	res, err := client.Fetch("gemini://" + host + "/favicon.txt")
 	if err != nil {
 		if res != nil {
 			res.Body.Close()
 		}
-		cache.AddFavicon(host, cache.KnownNoFavicon)
-		return
+		return cache.KnownNoFavicon
 	}
 	defer res.Body.Close()
 
 	if res.Status != 20 {
-		cache.AddFavicon(host, cache.KnownNoFavicon)
-		return
+		return cache.KnownNoFavicon
 	}
 	if !strings.HasPrefix(res.Meta, "text/") && res.Meta != "" {
 		// Not a textual page
-		cache.AddFavicon(host, cache.KnownNoFavicon)
-		return
+		return cache.KnownNoFavicon
 	}
 	// It's a regular plain response
 
@@ -147,22 +256,18 @@ func handleFavicon(t *tab, host string) {
 	_, err = io.CopyN(buf, res.Body, 29+2+1) // 29 is the max emoji length, +2 for CRLF, +1 so that the right size will EOF
 	if err == nil {
 		// Content was too large
-		cache.AddFavicon(host, cache.KnownNoFavicon)
-		return
+		return cache.KnownNoFavicon
 	} else if err != io.EOF {
 		// Some network reading error
-		// No favicon is NOT known, could be a temporary error
-		return
+		return ""
 	}
 	// EOF, which is what we want.
+	// Just one emoji is allowed, with nothing else but the line ending
 	emoji := strings.TrimRight(buf.String(), "\r\n")
 	if !isemoji.IsEmoji(emoji) {
-		cache.AddFavicon(host, cache.KnownNoFavicon)
-		return
+		return cache.KnownNoFavicon
 	}
-	// Valid favicon found
-	t.page.Favicon = emoji
-	cache.AddFavicon(host, emoji)
+	return emoji
 }
 
 // handleAbout can be called to deal with any URLs that start with
@@ -203,6 +308,23 @@ func handleAbout(t *tab, u string) (string, bool) {
 		setPage(t, &temp)
 		t.applyBottomBar()
 		return u, true
+	case "about:certs":
+		Certs(t)
+		return u, true
+	case "about:tofu":
+		TofuPage(t, u)
+		return u, true
+	case "about:cache":
+		CacheInfo(t)
+		return u, true
+	case "about:home":
+		if !HomePage(t) {
+			return "", false
+		}
+		return u, true
+	case "about:downloads":
+		DownloadsPage(t, u)
+		return u, true
 	case "about:about":
 		temp := aboutPage
 		setPage(t, &temp)
@@ -210,6 +332,16 @@ func handleAbout(t *tab, u string) (string, bool) {
 		return u, true
 	}
 
+	if strings.HasPrefix(u, "about:downloads?") {
+		DownloadsPage(t, u)
+		// Don't count retries in history
+		return "", false
+	}
+	if len(u) > 11 && u[:11] == "about:tofu?" {
+		TofuPage(t, u)
+		// Don't count remove command in history
+		return "", false
+	}
 	if u == "about:subscriptions" || (len(u) > 20 && u[:20] == "about:subscriptions?") {
 		// about:subscriptions?2 views page 2
 		return Subscriptions(t, u), true
@@ -227,8 +359,97 @@ func handleAbout(t *tab, u string) (string, bool) {
 	return "", false
 }
 
+// isCrossSite returns true if the redirect from one absolute URL to the other
+// goes to a different scheme or host.
+func isCrossSite(from, to string) bool {
+	fromParsed, err := url.Parse(from)
+	if err != nil {
+		return true
+	}
+	toParsed, err := url.Parse(to)
+	if err != nil {
+		return true
+	}
+	return !strings.EqualFold(fromParsed.Scheme, toParsed.Scheme) ||
+		!strings.EqualFold(fromParsed.Host, toParsed.Host)
+}
+
+// followRedirect returns true if the redirect from u to redir should be followed,
+// as part of a request that was already redirected numRedirects times. The user is
+// prompted if needed, and an error is displayed if the redirect can't be followed.
+//
+// It blocks while prompting, so it should be called in a goroutine.
+func followRedirect(t *tab, u, redir string, numRedirects int) bool {
+	if limit := viper.GetInt("a-general.max_redirects"); numRedirects >= limit {
+		chain := strings.Join(append(t.redirects, u, redir), "\n")
+		Error("Redirect Error", "Too many redirects, the limit is "+strconv.Itoa(limit)+":\n\n"+chain)
+		return false
+	}
+	if isCrossSite(u, redir) {
+		if strings.ToLower(viper.GetString("a-general.cross_site_redirects")) == "block" {
+			Error("Redirect Error", "Redirects to a different scheme or host are blocked:\n"+redir)
+			return false
+		}
+		// Always asked, because it's somewhere the user didn't choose to go
+		return YesNo("Follow redirect to a different site?\n" + redir)
+	}
+	return viper.GetBool("a-general.auto_redirect") || YesNo("Follow redirect?\n"+redir)
+}
+
+// schemeAllowed returns true if URLs with the scheme can be opened, according
+// to the allowed_schemes config setting. All schemes are allowed if it's empty,
+// and about: pages always are.
+func schemeAllowed(scheme string) bool {
+	allowed := viper.GetStringSlice("a-general.allowed_schemes")
+	if len(allowed) == 0 || strings.EqualFold(scheme, "about") {
+		return true
+	}
+	for _, s := range allowed {
+		if strings.EqualFold(strings.TrimSpace(s), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemeNotAllowed tells the user that URLs with the scheme can't be used,
+// because of the allowed_schemes option.
+func schemeNotAllowed(scheme string) {
+	Error("Scheme Not Allowed", "Opening "+scheme+
+		" URLs isn't allowed. The allowed_schemes setting in the config lists the ones that are.")
+}
+
+// inputPrompt returns the prompt shown for a status 10 or 11 response, which
+// is the META the server sent, and whether the input is sensitive and should
+// be masked.
+func inputPrompt(status int, meta string) (string, bool) {
+	sensitive := status == 11
+	prompt := escapeMeta(strings.TrimSpace(meta))
+	if prompt == "" {
+		if sensitive {
+			prompt = "The server is asking for sensitive input:"
+		} else {
+			prompt = "The server is asking for input:"
+		}
+	}
+	return prompt, sensitive
+}
+
+// cachedPage returns the page cached for the URL. If it was cached by another
+// URL for the same page, see urlKey, a copy with this URL is returned, so the
+// URL gone to is the one displayed, and links are followed from it.
+func cachedPage(pages cache.Cache, u string) (*structs.Page, bool) {
+	p, ok := pages.Get(u)
+	if !ok || p.URL == u {
+		return p, ok
+	}
+	moved := *p
+	moved.URL = u
+	return &moved, true
+}
+
 // handleURL displays whatever action is needed for the provided URL,
-// and applies it to the current tab.
+// and applies it to the tab, which is usually the current one.
 // It loads documents, handles errors, brings up a download prompt, etc.
 //
 // The string returned is the final URL, if redirects were involved.
@@ -245,18 +466,35 @@ func handleAbout(t *tab, u string) (string, bool) {
 func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	defer App.Draw() // Just in case
 
+	// Any new request stops waiting to retry an old one, and following a stream
+	t.cancelSlowDown()
+	t.stopStream()
+
 	// Save for resetting on error
 	oldLable := t.barLabel
 	oldText := t.barText
 
+	if numRedirects == 0 {
+		t.redirects = nil
+	}
+
+	fetched := false // Whether the page was loaded from the server, not the cache
+
 	// Custom return function
 	ret := func(s string, b bool) (string, bool) {
 		if !b {
 			// Reset bottomBar if page wasn't loaded
 			t.barLabel = oldLable
 			t.barText = oldText
+		} else if len(t.redirects) > 0 {
+			// Show where the page was redirected from, until the bottomBar changes
+			t.barLabel = "[::b]Redirected: [::-]"
+			t.barText = strings.Join(append(t.redirects, s), " -> ")
+		} else if fetched && viper.GetBool("a-general.response_info") {
+			t.showResponseInfo()
 		}
 		t.mode = tabModeDone
+		t.setLoadCancel(nil)
 
 		go func(p *structs.Page) {
 			if b && t.hasContent() && viper.GetBool("subscriptions.popup") {
@@ -275,9 +513,11 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	}
 
 	t.barLabel = ""
-	bottomBar.SetLabel("")
-
-	App.SetFocus(t.view)
+	if t == tabs[curTab] {
+		// Tabs loading in the background leave the current one alone
+		bottomBar.SetLabel("")
+		App.SetFocus(t.view)
+	}
 
 	if strings.HasPrefix(u, "about:") {
 		return ret(handleAbout(t, u))
@@ -291,6 +531,11 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		Error("URL Error", err.Error())
 		return ret("", false)
 	}
+	if !schemeAllowed(parsed.Scheme) {
+		// Nothing is connected to or opened for it
+		schemeNotAllowed(parsed.Scheme)
+		return ret("", false)
+	}
 
 	proxy := strings.TrimSpace(viper.GetString("proxies." + parsed.Scheme))
 	usingProxy := false
@@ -305,7 +550,7 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	if strings.HasPrefix(u, "http") {
 		if proxy == "" || proxy == "off" {
 			// No proxy available
-			handleHTTP(u, true)
+			httpPrompt(u)
 			return ret("", false)
 		}
 		usingProxy = true
@@ -320,7 +565,20 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		return ret(u, true)
 	}
 
-	if !strings.HasPrefix(u, "http") && !strings.HasPrefix(u, "gemini") && !strings.HasPrefix(u, "file") {
+	if (strings.HasPrefix(u, "gopher") || strings.HasPrefix(u, "finger") || strings.HasPrefix(u, "nex")) &&
+		proxy != "" && proxy != "off" {
+		// The proxy is used instead of native Gopher, Finger, or Nex support
+		usingProxy = true
+	}
+
+	if strings.HasPrefix(u, "mailto:") && strings.TrimSpace(viper.GetString("url-handlers.mailto")) == "" {
+		handleMailto(u)
+		return ret("", false)
+	}
+
+	if !strings.HasPrefix(u, "http") && !strings.HasPrefix(u, "gemini") && !strings.HasPrefix(u, "file") &&
+		!strings.HasPrefix(u, "spartan") && !strings.HasPrefix(u, "gopher") && !strings.HasPrefix(u, "finger") &&
+		!strings.HasPrefix(u, "nex") {
 		// Not a Gemini URL
 		if proxy == "" || proxy == "off" {
 			// No proxy available
@@ -330,68 +588,102 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		usingProxy = true
 	}
 
-	// Gemini URL, or one with a Gemini proxy available
+	// Gemini, Spartan, Gopher, Finger, or Nex URL, or one with a Gemini proxy available
 
 	// Load page from cache if it exists,
 	// and this isn't a page that was redirected to by the server (indicates dynamic content)
 	if numRedirects == 0 {
-		page, ok := cache.GetPage(u)
+		page, ok := cachedPage(t.pageCache(), u)
 		if ok {
 			setPage(t, page)
 			return ret(u, true)
 		}
 	}
 	// Otherwise download it
-	bottomBar.SetText("Loading...")
+	if t == tabs[curTab] {
+		bottomBar.SetText("Loading...")
+	}
 	t.barText = "Loading..." // Save it too, in case the tab switches during loading
 	t.mode = tabModeLoading
 	App.Draw()
 
-	var res *gemini.Response
+	spinCtx, stopSpinner := context.WithCancel(context.Background())
+	defer stopSpinner()
+	go t.loadingSpinner(spinCtx)
+
+	// The request can be stopped by the user
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.setLoadCancel(cancel)
+
+	if strings.HasPrefix(u, "spartan") {
+		return ret(handleSpartan(ctx, t, u, numRedirects))
+	}
+	if strings.HasPrefix(u, "gopher") && !usingProxy {
+		return ret(handleGopher(ctx, t, u, numRedirects))
+	}
+	if strings.HasPrefix(u, "finger") && !usingProxy {
+		return ret(handleFinger(ctx, t, u))
+	}
+	if strings.HasPrefix(u, "nex") && !usingProxy {
+		return ret(handleNex(ctx, t, u))
+	}
+
+	var res *client.Response
 	if usingProxy {
-		res, err = client.FetchWithProxy(proxyHostname, proxyPort, u)
+		res, err = client.FetchWithProxyContext(ctx, proxyHostname, proxyPort, u)
 	} else {
-		res, err = client.Fetch(u)
+		res, err = client.FetchContext(ctx, u)
 	}
 
 	// Loading may have taken a while, make sure tab is still valid
-	if !isValidTab(t) {
+	if !isValidTab(t) || ctx.Err() != nil {
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
 		return ret("", false)
 	}
 
 	if errors.Is(err, client.ErrTofu) {
+		// The connection waits here until they decide.
+		// If they want to continue anyway, the response can be used further down,
+		// no need to reload
+		// The cert is the Gemini proxy's if one was used, from proxies or host-proxies
+		proxyName := ""
 		if usingProxy {
-			// They are using a proxy
-			if Tofu(proxy, client.GetExpiry(proxyHostname, proxyPort)) {
-				// They want to continue anyway
-				client.ResetTofuEntry(proxyHostname, proxyPort, res.Cert)
-				// Response can be used further down, no need to reload
-			} else {
-				// They don't want to continue
-				return ret("", false)
-			}
-		} else {
-			if Tofu(parsed.Host, client.GetExpiry(parsed.Hostname(), parsed.Port())) {
-				// They want to continue anyway
-				client.ResetTofuEntry(parsed.Hostname(), parsed.Port(), res.Cert)
-				// Response can be used further down, no need to reload
-			} else {
-				// They don't want to continue
-				return ret("", false)
-			}
+			proxyName = proxy
+		}
+		host, hostname, port := responseServer(parsed, res, proxyName)
+		if !Tofu(host, hostname, port, res.Cert) {
+			// They don't want to continue
+			return ret("", false)
 		}
 	} else if err != nil {
-		Error("URL Fetch Error", err.Error())
+		fetchError(err)
 		return ret("", false)
 	}
 
 	// Fetch happened successfully, use RestartReader to buffer read data
 	res.Body = rr.NewRestartReader(res.Body)
 
-	if renderer.CanDisplay(res) {
-		page, err := renderer.MakePage(u, res, textWidth(), usingProxy)
+	_, serverHostname, serverPort := responseServer(parsed, res, "")
+	security := geminiSecurity(serverHostname, serverPort)
+
+	if renderer.CanStream(res.Response) {
+		// Text that may keep arriving, see stream.go
+		if handleStream(ctx, t, u, res, usingProxy, security) {
+			fetched = true
+			return ret(u, true)
+		}
+		return ret("", false)
+	}
+	if renderer.CanDisplay(res.Response) || (graphics != graphicsNone && renderer.IsImage(res.Response)) {
+		stopWatching := closeOnCancel(ctx, res.Body)
+		page, err := renderer.MakePage(u, res.Response, textWidth(), usingProxy)
+		stopWatching()
 		// Rendering may have taken a while, make sure tab is still valid
-		if !isValidTab(t) {
+		if !isValidTab(t) || ctx.Err() != nil {
+			res.Body.Close()
 			return ret("", false)
 		}
 
@@ -400,7 +692,7 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 			// Disable read timeout and go back to start
 			res.SetReadTimeout(0) //nolint: errcheck
 			res.Body.(*rr.RestartReader).Restart()
-			go dlChoice("That page is too large. What would you like to do?", u, res)
+			go dlChoice("That page is too large. What would you like to do?", u, res.Response)
 			return ret("", false)
 		}
 		if errors.Is(err, renderer.ErrTimedOut) {
@@ -408,7 +700,14 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 			// Disable read timeout and go back to start
 			res.SetReadTimeout(0) //nolint: errcheck
 			res.Body.(*rr.RestartReader).Restart()
-			go dlChoice("Loading that page timed out. What would you like to do?", u, res)
+			go dlChoice("Loading that page timed out. What would you like to do?", u, res.Response)
+			return ret("", false)
+		}
+		if errors.Is(err, renderer.ErrCantDisplay) && renderer.IsImage(res.Response) {
+			// Image couldn't be decoded, offer to download it instead
+			res.SetReadTimeout(0) //nolint: errcheck
+			res.Body.(*rr.RestartReader).Restart()
+			go dlChoice("That image could not be displayed. What would you like to do?", u, res.Response)
 			return ret("", false)
 		}
 		if err != nil {
@@ -416,14 +715,17 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 			return ret("", false)
 		}
 
+		page.Security = security
 		page.TermWidth = termW
+		page.LeftMargin = leftMargin()
 
-		if !client.HasClientCert(parsed.Host) {
-			// Don't cache pages with client certs
-			go cache.AddPage(page)
+		if !client.HasClientCert(parsed.Host) && !t.incognito {
+			// Don't cache pages with client certs, or pages in incognito tabs
+			go t.pageCache().Set(page)
 		}
 
 		setPage(t, page)
+		fetched = true
 		return ret(u, true)
 	}
 	// Not displayable
@@ -435,12 +737,16 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		var userInput string
 		var ok bool
 
-		if res.Status == 10 {
-			// Regular input
-			userInput, ok = Input(res.Meta, false)
+		prompt, sensitive := inputPrompt(res.Status, res.Meta)
+		if !sensitive {
+			// Regular input, which is kept so it can be used again
+			userInput, ok = inputWithHistory(prompt, false, getInputHistory(u))
+			if ok && !t.incognito {
+				addInputHistory(u, userInput)
+			}
 		} else {
-			// Sensitive input
-			userInput, ok = Input(res.Meta, true)
+			// Sensitive input, masked with asterisks
+			userInput, ok = Input(prompt, true)
 		}
 		if ok {
 			// Make another request with the query string added
@@ -459,21 +765,11 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 			return ret("", false)
 		}
 		redir := parsed.ResolveReference(parsedMeta).String()
-		// Prompt before redirecting to non-Gemini protocol
-		redirect := false
-		if !strings.HasPrefix(redir, "gemini") {
-			if YesNo("Follow redirect to non-Gemini URL?\n" + redir) {
-				redirect = true
-			} else {
-				return ret("", false)
-			}
-		}
-		// Prompt before redirecting
-		autoRedirect := viper.GetBool("a-general.auto_redirect")
-		if redirect || (autoRedirect && numRedirects < 5) || YesNo("Follow redirect?\n"+redir) {
+		if followRedirect(t, u, redir, numRedirects) {
 			if res.Status == gemini.StatusRedirectPermanent {
 				go cache.AddRedir(u, redir)
 			}
+			t.redirects = append(t.redirects, u)
 			return ret(handleURL(t, redir, numRedirects+1))
 		}
 		return ret("", false)
@@ -490,8 +786,15 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		Error("Proxy Failure", escapeMeta(res.Meta))
 		return ret("", false)
 	case 44:
-		Error("Slow Down", "You should wait "+escapeMeta(res.Meta)+" seconds before making another request.")
-		return ret("", false)
+		seconds, err := strconv.Atoi(strings.TrimSpace(res.Meta))
+		if err != nil || seconds <= 0 {
+			Error("Slow Down", "You should wait "+escapeMeta(res.Meta)+" seconds before making another request.")
+			return ret("", false)
+		}
+		s, b := ret("", false)
+		// Started after ret, so the countdown isn't replaced by the old bottomBar values
+		t.slowDown(u, seconds)
+		return s, b
 	case 50:
 		Error("Permanent Failure", escapeMeta(res.Meta))
 		return ret("", false)
@@ -507,11 +810,23 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	case 59:
 		Error("Bad Request", escapeMeta(res.Meta))
 		return ret("", false)
-	case 60:
-		Error("Client Certificate Required", escapeMeta(res.Meta))
-		return ret("", false)
-	case 61:
-		Error("Certificate Not Authorised", escapeMeta(res.Meta))
+	case 60, 61:
+		if client.CertInConfig(parsed.Host) || t.identityRetried {
+			// The user's choice can't be used, or the identity they just chose wasn't accepted
+			if res.Status == 60 {
+				Error("Client Certificate Required", escapeMeta(res.Meta))
+			} else {
+				Error("Certificate Not Authorised", escapeMeta(res.Meta))
+			}
+			return ret("", false)
+		}
+		if chooseIdentity(parsed.Host, res.Meta) {
+			// Make the request again with the chosen cert, only once
+			t.identityRetried = true
+			s, b := handleURL(t, u, numRedirects)
+			t.identityRetried = false
+			return ret(s, b)
+		}
 		return ret("", false)
 	case 62:
 		Error("Certificate Not Valid", escapeMeta(res.Meta))
@@ -532,7 +847,7 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 				// Disable read timeout and go back to start
 				res.SetReadTimeout(0) //nolint: errcheck
 				res.Body.(*rr.RestartReader).Restart()
-				go dlChoice("That file could not be displayed. What would you like to do?", u, res)
+				go dlChoice("That file could not be displayed. What would you like to do?", u, res.Response)
 			}
 		}()
 		return ret("", false)
@@ -542,6 +857,6 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	// Disable read timeout and go back to start
 	res.SetReadTimeout(0) //nolint: errcheck
 	res.Body.(*rr.RestartReader).Restart()
-	go dlChoice("That file could not be displayed. What would you like to do?", u, res)
+	go dlChoice("That file could not be displayed. What would you like to do?", u, res.Response)
 	return ret("", false)
 }
@@ -0,0 +1,130 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+var httpCommandArgsTests = []struct {
+	command []string
+	u       string
+	name    string
+	args    []string
+}{
+	{[]string{"firefox"}, "https://example.com", "firefox", []string{"https://example.com"}},
+	{[]string{"browser", "--new-tab"}, "https://example.com", "browser", []string{"--new-tab", "https://example.com"}},
+	{[]string{"xdg-open", "%s"}, "https://example.com", "xdg-open", []string{"https://example.com"}},
+	{[]string{"browser", "--url=%s", "--flag"}, "https://example.com", "browser", []string{"--url=https://example.com", "--flag"}},
+	{[]string{"browser"}, "https://example.com/a b;rm -rf ~", "browser", []string{"https://example.com/a b;rm -rf ~"}},
+}
+
+func TestHTTPCommandArgs(t *testing.T) {
+	for _, tt := range httpCommandArgsTests {
+		name, args := httpCommandArgs(tt.command, tt.u)
+		if name != tt.name || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("httpCommandArgs(%q, %q) = %q, %q, want %q, %q", tt.command, tt.u, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+var isCrossSiteTests = []struct {
+	from string
+	to   string
+	want bool
+}{
+	{"gemini://example.com/a", "gemini://example.com/b", false},
+	{"gemini://example.com/", "gemini://EXAMPLE.com/b", false},
+	{"gemini://example.com/", "https://example.com/", true},
+	{"gemini://example.com/", "gemini://example.org/", true},
+	{"gemini://example.com/", "gemini://example.com:1966/", true},
+}
+
+func TestIsCrossSite(t *testing.T) {
+	for _, tt := range isCrossSiteTests {
+		if got := isCrossSite(tt.from, tt.to); got != tt.want {
+			t.Errorf("isCrossSite(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSchemeAllowed(t *testing.T) {
+	defer viper.Set("a-general.allowed_schemes", nil)
+
+	viper.Set("a-general.allowed_schemes", []string{})
+	if !schemeAllowed("https") {
+		t.Error("https isn't allowed when every scheme should be")
+	}
+
+	viper.Set("a-general.allowed_schemes", []string{"gemini", " File "})
+	for scheme, want := range map[string]bool{
+		"gemini": true,
+		"GEMINI": true,
+		"file":   true,
+		"about":  true,
+		"https":  false,
+		"gopher": false,
+		"":       false,
+	} {
+		if got := schemeAllowed(scheme); got != want {
+			t.Errorf("schemeAllowed(%q) = %v, want %v", scheme, got, want)
+		}
+	}
+}
+
+var inputPromptTests = []struct {
+	status    int
+	meta      string
+	prompt    string
+	sensitive bool
+}{
+	{10, "Search terms", "Search terms", false},
+	{11, "Password", "Password", true},
+	{10, "  Name [max 20 chars]\r\n", "Name [max 20 chars[]", false},
+	{10, "", "The server is asking for input:", false},
+	{11, "", "The server is asking for sensitive input:", true},
+}
+
+func TestInputPrompt(t *testing.T) {
+	for _, tt := range inputPromptTests {
+		prompt, sensitive := inputPrompt(tt.status, tt.meta)
+		if prompt != tt.prompt || sensitive != tt.sensitive {
+			t.Errorf("inputPrompt(%d, %q) = %q, %v, want %q, %v", tt.status, tt.meta, prompt, sensitive, tt.prompt, tt.sensitive)
+		}
+	}
+}
+
+func TestCachedPage(t *testing.T) {
+	defer viper.Set("cache.fold_trailing_slash", nil)
+	defer viper.Set("cache.lowercase_hosts", nil)
+	viper.Set("cache.fold_trailing_slash", true)
+	viper.Set("cache.lowercase_hosts", true)
+	cache.SetKeyFunc(urlKey)
+	defer cache.SetKeyFunc(nil)
+	defer cache.ClearPages()
+
+	p := &structs.Page{URL: "gemini://example.com/dir/", Content: "cached", MadeAt: time.Now()}
+	cache.AddPage(p)
+
+	got, ok := cachedPage(cache.Pages, "gemini://Example.com/dir")
+	if !ok {
+		t.Fatal("the page wasn't found by a URL with the same key")
+	}
+	if got.URL != "gemini://Example.com/dir" || got.Content != "cached" {
+		t.Errorf("the displayed page is %q with %q, want the URL gone to with the cached content", got.URL, got.Content)
+	}
+	if p.URL != "gemini://example.com/dir/" {
+		t.Errorf("the cached page URL was changed to %q", p.URL)
+	}
+
+	if got, _ := cachedPage(cache.Pages, p.URL); got != p {
+		t.Error("the cached page isn't used as it is for its own URL")
+	}
+	if _, ok := cachedPage(cache.Pages, "gemini://example.com/other"); ok {
+		t.Error("a page was found for a URL that isn't cached")
+	}
+}
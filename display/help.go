@@ -18,6 +18,7 @@ var helpCells = strings.TrimSpace(
 		"%s\tGo down a page in document\n" +
 		"g\tGo to top of document\n" +
 		"G\tGo to bottom of document\n" +
+		"%s\tGo to a line number, or a percentage of the document like 50%%.\n" +
 		"Tab\tNavigate to the next item in a popup.\n" +
 		"Shift-Tab\tNavigate to the previous item in a popup.\n" +
 		"%s\tGo back in the history\n" +
@@ -25,24 +26,92 @@ var helpCells = strings.TrimSpace(
 		"%s\tOpen bar at the bottom - type a URL, link number, search term.\n" +
 		"\tYou can also type two dots (..) to go up a directory in the URL.\n" +
 		"\tTyping new:N will open link number N in a new tab\n" +
-		"\tinstead of the current one.\n" +
+		"\tinstead of the current one. Press Tab and Shift-Tab to go through\n" +
+		"\tvisited and bookmarked URLs that match what you typed.\n" +
 		"%s\tGo to links 1-10 respectively.\n" +
+		"%s, %s\tSelect the next or previous link from where the page is scrolled to.\n" +
+		"\tPress Enter to follow it, or keep pressing them to go through the links.\n" +
+		"%s\tGo to the root of the site, like gemini://example.com/.\n" +
+		"\tIf a link is selected, this goes to the root of its site instead.\n" +
+		"%s\tGo up a directory, like from /a/b/c to /a/b/.\n" +
+		"%s\tFold or unfold the preformatted block at the top of the screen.\n" +
+		"\tLong blocks are folded when the fold_preformatted option is set.\n" +
 		"%s\tEdit current URL\n" +
+		"%s\tEdit the current page in your editor, and upload it with Titan.\n" +
+		"%s\tSearch the current page.\n" +
+		"\tPress %s and %s to go to the next and previous match.\n" +
+		"%s\tFind a link by typing part of its text or URL. The best match is\n" +
+		"\thighlighted as you type, press Enter to follow it or Esc to stop.\n" +
+		"%s\tHide or show the link lines of the current page.\n" +
+		"%s\tShow the source of the current page, or render it again.\n" +
+		"%s\tOpen the source of the current page in your editor, to read and search it.\n" +
+		"%s\tWrap the long lines of plain text documents, or stop wrapping them.\n" +
+		"%s\tShow the table of contents of the current page. Type to search\n" +
+		"\tthe headings, and press Enter to scroll to the selected one.\n" +
+		"%s\tShow a list of the links on the current page, with where they go.\n" +
+		"\tType to search them, and press Enter to follow the selected one.\n" +
+		"%s\tShow the pages visited recently in any tab. Type to search them,\n" +
+		"\tand press Enter to open the selected one, or %s to open it in a new tab.\n" +
+		"%s\tCopy the URL of the current page.\n" +
+		"%s\tCopy the URL of the selected link.\n" +
+		"%s\tCopy the URL of the heading at the top of the screen, like\n" +
+		"\tgemini://example.com/page#section, to link to that part of the page.\n" +
+		"%s\tSelect lines of the current page, starting at the top of the screen.\n" +
+		"\tPress Up and Down to change the selection, Enter or y to copy\n" +
+		"\tthe text to the clipboard, or Esc to stop.\n" +
+		"%s\tOpen the command palette, to type a command like reload or new-tab.\n" +
+		"\tPress Tab to complete the command name. Some commands take a value,\n" +
+		"\tlike left-margin 0.1, or goto 50%%. link-numbers hides or shows\n" +
+		"\tthe numbers before links. title NAME names the current tab, until\n" +
+		"\ttitle is used again with no name. wipe forgets the URLs visited\n" +
+		"\tand the inputs sent this session, so they aren't suggested.\n" +
+		"\ttheme NAME changes the colors, to a theme like light or solarized-dark.\n" +
+		"\tsave-session NAME saves the open tabs, and open-session NAME opens\n" +
+		"\tthem again later. sessions lists the saved ones, and sessions delete\n" +
+		"\tNAME deletes one. export-tofu FILE saves the trusted server certificates\n" +
+		"\tlisted on about:tofu, and import-tofu FILE adds them on another computer.\n" +
+		"\tupload-text opens a box to type or paste text, which is uploaded to\n" +
+		"\tthe current page with Titan. Press Ctrl-S to send it, or Esc to cancel.\n" +
+		"\tauto-reload 30s reloads the current page every 30 seconds, until the\n" +
+		"\ttab goes to another page or auto-reload off is used.\n" +
+		"\tclear cache, clear favicons, and clear tofu empty the page cache, the\n" +
+		"\tfavicon cache, and the trusted server certificates. clear all empties\n" +
+		"\tthem all. Open tabs keep showing their pages.\n" +
+		"\tcharset NAME decodes the current page again with a charset like\n" +
+		"\tiso-8859-1, for text sent with the wrong one. It shows the one used\n" +
+		"\twithout a name.\n" +
 		"Enter, Tab\tOn a page this will start link highlighting.\n" +
-		"\tPress Tab and Shift-Tab to pick different links.\n" +
+		"\tPress Tab and Shift-Tab to pick different links,\n" +
+		"\tor type the number of one to pick it.\n" +
 		"\tPress Enter again to go to one, or Esc to stop.\n" +
 		"%s\tGo to a specific tab. (Default: Shift-NUMBER)\n" +
 		"%s\tGo to the last tab.\n" +
 		"%s\tPrevious tab\n" +
 		"%s\tNext tab\n" +
+		"%s, %s\tMove the current tab left or right.\n" +
+		"%s\tSplit the view, to see a new tab next to the current one.\n" +
+		"\tPress it again to go back to one tab.\n" +
+		"%s\tWhen the view is split, move to the other tab.\n" +
 		"%s\tGo home\n" +
 		"%s\tNew tab, or if a link is selected,\n" +
 		"\tthis will open the link in a new tab.\n" +
+		"%s\tOpen the selected link in a new tab, without switching to it.\n" +
+		"\tThe tab is marked with a * once the page has loaded.\n" +
 		"%s\tClose tab. For now, only the right-most tab can be closed.\n" +
+		"%s\tDuplicate the current tab, including its history.\n" +
+		"%s\tNew incognito tab. Its pages aren't cached, and its history\n" +
+		"\tand inputs aren't kept anywhere once the tab is closed.\n" +
 		"%s\tReload a page, discarding the cached version.\n" +
 		"\tThis can also be used if you resize your terminal.\n" +
+		"%s\tReload the pages of all the tabs.\n" +
+		"%s\tStop loading the page in the current tab, or stop following a page\n" +
+		"\tthat keeps loading, like a log. What has loaded so far is kept.\n" +
 		"%s\tView bookmarks\n" +
 		"%s\tAdd, change, or remove a bookmark for the current page.\n" +
+		"\tOn the bookmarks page, this asks for a URL to bookmark.\n" +
+		"%s\tOn the bookmarks page, rename or remove the selected bookmark.\n" +
+		"%s\tOn the bookmarks page, remove the selected bookmark.\n" +
+		"%s, %s\tOn the bookmarks page, move the selected bookmark up or down.\n" +
 		"%s\tSave the current page to your downloads.\n" +
 		"%s\tView subscriptions\n" +
 		"%s\tAdd or update a subscription\n" +
@@ -60,8 +129,6 @@ func Help() {
 
 func helpInit() {
 	// Populate help table
-	helpTable.SetBackgroundColor(config.GetColor("bg"))
-	helpTable.SetTextColor(config.GetColor("regular_text"))
 	helpTable.SetPadding(0, 0, 1, 1)
 	helpTable.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEsc || key == tcell.KeyEnter {
@@ -70,8 +137,6 @@ func helpInit() {
 			App.Draw()
 		}
 	})
-	helpTable.SetScrollBarColor(config.GetColor("scrollbar"))
-
 	tabKeys := fmt.Sprintf("%s to %s", strings.Split(config.GetKeyBinding(config.CmdTab1), ",")[0],
 		strings.Split(config.GetKeyBinding(config.CmdTab9), ",")[0])
 	linkKeys := fmt.Sprintf("%s to %s", strings.Split(config.GetKeyBinding(config.CmdLink1), ",")[0],
@@ -80,21 +145,58 @@ func helpInit() {
 	helpCells = fmt.Sprintf(helpCells,
 		config.GetKeyBinding(config.CmdPgup),
 		config.GetKeyBinding(config.CmdPgdn),
+		config.GetKeyBinding(config.CmdGoto),
 		config.GetKeyBinding(config.CmdBack),
 		config.GetKeyBinding(config.CmdForward),
 		config.GetKeyBinding(config.CmdBottom),
 		linkKeys,
+		config.GetKeyBinding(config.CmdNextLink),
+		config.GetKeyBinding(config.CmdPrevLink),
+		config.GetKeyBinding(config.CmdRoot),
+		config.GetKeyBinding(config.CmdUp),
+		config.GetKeyBinding(config.CmdFold),
 		config.GetKeyBinding(config.CmdEdit),
+		config.GetKeyBinding(config.CmdUpload),
+		config.GetKeyBinding(config.CmdSearch),
+		config.GetKeyBinding(config.CmdNextMatch),
+		config.GetKeyBinding(config.CmdPrevMatch),
+		config.GetKeyBinding(config.CmdFindLink),
+		config.GetKeyBinding(config.CmdReader),
+		config.GetKeyBinding(config.CmdSource),
+		config.GetKeyBinding(config.CmdSourceEditor),
+		config.GetKeyBinding(config.CmdWrap),
+		config.GetKeyBinding(config.CmdTOC),
+		config.GetKeyBinding(config.CmdLinks),
+		config.GetKeyBinding(config.CmdRecent),
+		config.GetKeyBinding(config.CmdNewTab),
+		config.GetKeyBinding(config.CmdCopyPageURL),
+		config.GetKeyBinding(config.CmdCopyTargetURL),
+		config.GetKeyBinding(config.CmdCopyHeadingURL),
+		config.GetKeyBinding(config.CmdSelect),
+		config.GetKeyBinding(config.CmdCommand),
 		tabKeys,
 		config.GetKeyBinding(config.CmdTab0),
 		config.GetKeyBinding(config.CmdPrevTab),
 		config.GetKeyBinding(config.CmdNextTab),
+		config.GetKeyBinding(config.CmdMoveTabLeft),
+		config.GetKeyBinding(config.CmdMoveTabRight),
+		config.GetKeyBinding(config.CmdSplit),
+		config.GetKeyBinding(config.CmdSplitFocus),
 		config.GetKeyBinding(config.CmdHome),
 		config.GetKeyBinding(config.CmdNewTab),
+		config.GetKeyBinding(config.CmdBackgroundTab),
 		config.GetKeyBinding(config.CmdCloseTab),
+		config.GetKeyBinding(config.CmdDuplicateTab),
+		config.GetKeyBinding(config.CmdNewIncognitoTab),
 		config.GetKeyBinding(config.CmdReload),
+		config.GetKeyBinding(config.CmdReloadAll),
+		config.GetKeyBinding(config.CmdStop),
 		config.GetKeyBinding(config.CmdBookmarks),
 		config.GetKeyBinding(config.CmdAddBookmark),
+		config.GetKeyBinding(config.CmdRenameBkmk),
+		config.GetKeyBinding(config.CmdRemoveBkmk),
+		config.GetKeyBinding(config.CmdBkmkUp),
+		config.GetKeyBinding(config.CmdBkmkDown),
 		config.GetKeyBinding(config.CmdSave),
 		config.GetKeyBinding(config.CmdSub),
 		config.GetKeyBinding(config.CmdAddSub),
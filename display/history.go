@@ -1,8 +1,69 @@
 package display
 
+import (
+	"strconv"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// histState is the scroll position and link selection of a page in the history.
+// It's saved when leaving the page, so it can be restored when going back or
+// forward, even if the page isn't cached anymore and has to be loaded again.
+type histState struct {
+	row        int
+	column     int
+	selectedID string // Region ID of the selected link, empty if there's none
+}
+
+// saveHistState saves the scroll position and link selection of the tab's
+// page, for its position in the history. It should be called before leaving the page.
+func (t *tab) saveHistState() {
+	pos := t.history.pos
+	if pos < 0 || pos >= len(t.history.urls) || urlKey(t.page.URL) != urlKey(t.history.urls[pos]) {
+		// The page isn't the one in the history, like the new tab page
+		return
+	}
+	t.saveScroll()
+	t.saveSelection()
+
+	for len(t.history.states) <= pos {
+		t.history.states = append(t.history.states, histState{})
+	}
+	state := histState{row: t.page.Row, column: t.page.Column}
+	if t.page.Mode == structs.ModeLinkSelect {
+		state.selectedID = t.page.SelectedID
+	}
+	t.history.states[pos] = state
+}
+
+// applyHistState puts the saved scroll position and link selection back on
+// the tab's page. applyAll should be called after.
+func (t *tab) applyHistState() {
+	pos := t.history.pos
+	if pos >= len(t.history.states) || urlKey(t.page.URL) != urlKey(t.history.urls[pos]) {
+		return
+	}
+	state := t.history.states[pos]
+	t.page.Row = state.row
+	t.page.Column = state.column
+
+	// The page might have changed if it was loaded again, so make sure the link is still there
+	i, err := strconv.Atoi(state.selectedID)
+	if err == nil && i >= 0 && i < len(t.page.Links) {
+		t.page.Mode = structs.ModeLinkSelect
+		t.page.SelectedID = state.selectedID
+		t.page.Selected = t.page.Links[i]
+	}
+}
+
 // applyHist is a history.go internal function, to load a URL in the history.
 func applyHist(t *tab) {
-	handleURL(t, t.history.urls[t.history.pos], 0) // Load that position in history
+	// The saved scroll position is used instead of any fragment
+	t.fragment = ""
+	_, displayed := handleURL(t, t.history.urls[t.history.pos], 0) // Load that position in history
+	if displayed {
+		t.applyHistState()
+	}
 	t.applyAll()
 }
 
@@ -11,6 +72,7 @@ func histForward(t *tab) {
 		// Already on the most recent URL in the history
 		return
 	}
+	t.saveHistState()
 	t.history.pos++
 	go applyHist(t)
 }
@@ -20,6 +82,7 @@ func histBack(t *tab) {
 		// First tab in history
 		return
 	}
+	t.saveHistState()
 	t.history.pos--
 	go applyHist(t)
 }
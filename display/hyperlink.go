@@ -0,0 +1,158 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// OSC 8 hyperlinks, so links on the page can be opened by the terminal,
+// usually with Ctrl-click. They're disabled by default.
+//
+// tcell can't draw hyperlinks, so after each draw the visible links are written
+// again over themselves, in the same style, inside OSC 8 sequences. This is like
+// how images are drawn, see drawGraphics.
+
+// Whether links are written as OSC 8 hyperlinks.
+var hyperlinks bool
+
+// detectHyperlinks returns whether to write hyperlinks, from the config.
+// If it's set to "auto", it guesses whether the terminal supports them.
+func detectHyperlinks() bool {
+	switch strings.ToLower(viper.GetString("a-general.hyperlinks")) {
+	case "on":
+		return true
+	case "auto":
+	default:
+		return false
+	}
+
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		// Multiplexers might not pass the escape sequences through
+		return false
+	}
+	if vte, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		// GNOME Terminal, Tilix, and other terminals based on VTE
+		return true
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode":
+		return true
+	}
+	term := os.Getenv("TERM")
+	return os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("WT_SESSION") != "" ||
+		strings.Contains(term, "kitty") || strings.HasPrefix(term, "foot")
+}
+
+// colorSGR returns the SGR parameters for the color, after a semicolon.
+// base is 38 for the foreground, and 48 for the background.
+func colorSGR(c tcell.Color, base int) string {
+	if !c.Valid() {
+		// The terminal's default
+		return ""
+	}
+	if c.IsRGB() || c > tcell.Color255 {
+		r, g, b := c.RGB()
+		return fmt.Sprintf(";%d;2;%d;%d;%d", base, r, g, b)
+	}
+	return fmt.Sprintf(";%d;5;%d", base, c-tcell.ColorBlack)
+}
+
+// styleSGR returns the escape sequence that sets the style.
+func styleSGR(style tcell.Style) string {
+	fg, bg, attrs := style.Decompose()
+	sgr := "\x1b[0"
+	for _, a := range []struct {
+		attr tcell.AttrMask
+		n    string
+	}{
+		{tcell.AttrBold, ";1"}, {tcell.AttrDim, ";2"}, {tcell.AttrItalic, ";3"},
+		{tcell.AttrUnderline, ";4"}, {tcell.AttrBlink, ";5"}, {tcell.AttrReverse, ";7"},
+	} {
+		if attrs&a.attr != 0 {
+			sgr += a.n
+		}
+	}
+	return sgr + colorSGR(fg, 38) + colorSGR(bg, 48) + "m"
+}
+
+// validHyperlinkURL returns false if the URL has characters that would end
+// the OSC 8 sequence early.
+func validHyperlinkURL(u string) bool {
+	for i := 0; i < len(u); i++ {
+		if u[i] < 0x20 || u[i] == 0x7f {
+			return false
+		}
+	}
+	return u != ""
+}
+
+// drawHyperlinks writes the links that can be seen in the current tab as
+// hyperlinks, if they're enabled. It's used after the screen is drawn.
+func drawHyperlinks(screen tcell.Screen) {
+	if !hyperlinks || len(tabs) == 0 {
+		return
+	}
+	t := tabs[curTab]
+	focus := App.GetFocus()
+	if t.page.Graphic || t.page.Source || (focus != t.view && focus != bottomBar) ||
+		(t.page.Mode != structs.ModeOff && t.page.Mode != structs.ModeLinkSelect) {
+		// No links displayed, or something like a modal might be drawn over them
+		return
+	}
+
+	ix, iy, w, h := t.view.GetInnerRect()
+	row, col := t.view.GetScrollOffset()
+	lines := strings.Split(t.page.Content, "\n")
+
+	var b strings.Builder
+	for y := 0; y < h && row+y < len(lines); y++ {
+		for _, s := range regionSpans(strings.TrimSuffix(lines[row+y], "\r")) {
+			i, err := strconv.Atoi(s.id)
+			if err != nil || i < 0 || i >= len(t.page.Links) {
+				continue
+			}
+			start, end := s.start-col, s.end-col
+			if start < 0 {
+				start = 0
+			}
+			if end > w {
+				end = w
+			}
+			if start >= end {
+				// Scrolled out of view
+				continue
+			}
+			u, err := resolveRelLink(t, t.page.URL, t.page.Links[i])
+			if err != nil || !validHyperlinkURL(u) {
+				continue
+			}
+
+			// Move the cursor to the link and write it again inside OSC 8
+			fmt.Fprintf(&b, "\x1b[%d;%dH\x1b]8;;%s\x1b\\", iy+y+1, ix+start+1, u)
+			for x := ix + start; x < ix+end; {
+				mainc, combc, style, width := screen.GetContent(x, iy+y)
+				b.WriteString(styleSGR(style))
+				b.WriteRune(mainc)
+				for _, c := range combc {
+					b.WriteRune(c)
+				}
+				if width < 1 {
+					width = 1
+				}
+				x += width
+			}
+			b.WriteString("\x1b]8;;\x1b\\")
+		}
+	}
+	if b.Len() == 0 {
+		return
+	}
+	// The cursor position and style tcell expects are saved and restored
+	fmt.Fprint(os.Stdout, "\x1b7"+b.String()+"\x1b8")
+}
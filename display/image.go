@@ -0,0 +1,320 @@
+package display
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// Functions for drawing image pages inline, using terminal graphics protocols.
+//
+// tcell can only draw text, so image views are drawn as empty boxes, and the
+// image is written into that space with escape sequences after the screen is shown.
+
+type graphicsProtocol int
+
+const (
+	graphicsNone graphicsProtocol = iota
+	graphicsKitty
+	graphicsSixel
+)
+
+// The graphics protocol supported by the terminal, set in Init.
+var graphics graphicsProtocol
+
+// Fallback size of a terminal cell in pixels, if the terminal doesn't report it.
+const (
+	defaultCellWidth  = 8
+	defaultCellHeight = 16
+)
+
+// The image view currently drawn on the screen, and where it was drawn.
+// Images are only drawn again when this changes.
+var shownImage *imageView
+var shownImageRect [4]int
+
+// Used to give each image sent to Kitty a unique ID.
+var kittyImageID uint32
+
+// detectGraphics returns the graphics protocol set in the config,
+// or guesses what the terminal supports if it's set to "auto".
+func detectGraphics() graphicsProtocol {
+	switch strings.ToLower(viper.GetString("a-general.images")) {
+	case "kitty":
+		return graphicsKitty
+	case "sixel":
+		return graphicsSixel
+	case "auto":
+	default:
+		return graphicsNone
+	}
+
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		// Multiplexers don't pass the escape sequences through
+		return graphicsNone
+	}
+	term := os.Getenv("TERM")
+	if os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") ||
+		os.Getenv("TERM_PROGRAM") == "WezTerm" {
+		return graphicsKitty
+	}
+	if strings.Contains(term, "sixel") || strings.HasPrefix(term, "mlterm") ||
+		strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "yaft") {
+		return graphicsSixel
+	}
+	return graphicsNone
+}
+
+// imageView is used instead of a TextView for pages that are images.
+type imageView struct {
+	*cview.Box
+
+	img     image.Image
+	kittyID uint32 // Zero if the image hasn't been sent to Kitty
+
+	sixel     string // Encoded image, cached for the size below
+	sixelSize [2]int
+}
+
+func newImageView() *imageView {
+	v := &imageView{Box: cview.NewBox()}
+	if viper.GetBool("a-general.color") {
+		v.SetBackgroundColor(config.GetColor("bg"))
+	} else {
+		v.SetBackgroundColor(tcell.ColorBlack)
+	}
+	return v
+}
+
+// setImage decodes the image data of the page to be drawn.
+func (v *imageView) setImage(p *structs.Page) {
+	if v.kittyID != 0 {
+		// Free the old image
+		fmt.Fprintf(os.Stdout, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", v.kittyID)
+		v.kittyID = 0
+	}
+	v.sixel = ""
+	v.img, _, _ = image.Decode(strings.NewReader(p.Raw))
+}
+
+// fitImage returns the size of the image in cells and pixels once it's fitted
+// into a box of the provided number of cells. The aspect ratio is kept, and
+// images are never made larger.
+func fitImage(imgW, imgH, boxCols, boxRows, cellW, cellH int) (cols, rows, pxW, pxH int) {
+	if imgW <= 0 || imgH <= 0 || boxCols <= 0 || boxRows <= 0 {
+		return 0, 0, 0, 0
+	}
+	pxW, pxH = imgW, imgH
+	if maxW := boxCols * cellW; pxW > maxW {
+		pxH = pxH * maxW / pxW
+		pxW = maxW
+	}
+	if maxH := boxRows * cellH; pxH > maxH {
+		pxW = pxW * maxH / pxH
+		pxH = maxH
+	}
+	if pxW < 1 {
+		pxW = 1
+	}
+	if pxH < 1 {
+		pxH = 1
+	}
+	cols = (pxW + cellW - 1) / cellW
+	rows = (pxH + cellH - 1) / cellH
+	return cols, rows, pxW, pxH
+}
+
+// encode returns the escape sequences to draw the image at the top left of the view.
+func (v *imageView) encode() string {
+	if v.img == nil {
+		return ""
+	}
+	x, y, w, h := v.GetInnerRect()
+	cellW, cellH := cellSize()
+	if cellW <= 0 || cellH <= 0 {
+		cellW, cellH = defaultCellWidth, defaultCellHeight
+	}
+	bounds := v.img.Bounds()
+	cols, rows, pxW, pxH := fitImage(bounds.Dx(), bounds.Dy(), w, h, cellW, cellH)
+	if cols == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b7") // Save cursor position
+	fmt.Fprintf(&b, "\x1b[%d;%dH", y+1, x+1)
+
+	switch graphics {
+	case graphicsKitty:
+		if v.kittyID == 0 {
+			kittyImageID++
+			v.kittyID = kittyImageID
+			b.WriteString(kittyTransmit(v.img, v.kittyID))
+		}
+		// Replace any image on the screen with this one
+		b.WriteString("\x1b_Ga=d,q=2\x1b\\")
+		fmt.Fprintf(&b, "\x1b_Ga=p,i=%d,c=%d,r=%d,C=1,q=2\x1b\\", v.kittyID, cols, rows)
+	case graphicsSixel:
+		if v.sixel == "" || v.sixelSize != [2]int{pxW, pxH} {
+			v.sixel = encodeSixel(v.img, pxW, pxH)
+			v.sixelSize = [2]int{pxW, pxH}
+		}
+		b.WriteString(v.sixel)
+	}
+
+	b.WriteString("\x1b8") // Restore cursor position
+	return b.String()
+}
+
+// kittyTransmit returns the escape sequences to send the image to Kitty as a PNG,
+// without displaying it.
+func kittyTransmit(img image.Image, id uint32) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// Data must be sent in chunks of at most 4096 bytes
+	var b strings.Builder
+	for i := 0; i < len(data); i += 4096 {
+		end := i + 4096
+		more := 1
+		if end >= len(data) {
+			end = len(data)
+			more = 0
+		}
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=t,f=100,i=%d,q=2,m=%d;%s\x1b\\", id, more, data[i:end])
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, data[i:end])
+		}
+	}
+	return b.String()
+}
+
+// encodeSixel returns the image as sixel data, scaled to the provided size in pixels.
+// Colors are reduced to a 6x6x6 color cube, and transparent pixels are left undrawn.
+func encodeSixel(img image.Image, w, h int) string {
+	bounds := img.Bounds()
+
+	// Palette index of each pixel, or -1 if it's transparent
+	pixels := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, a := img.At(bounds.Min.X+x*bounds.Dx()/w, bounds.Min.Y+y*bounds.Dy()/h).RGBA()
+			if a < 0x8000 {
+				pixels[y*w+x] = -1
+				continue
+			}
+			pixels[y*w+x] = int(r*5/0xffff)*36 + int(g*5/0xffff)*6 + int(bl*5/0xffff)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&b, "\"1;1;%d;%d", w, h)
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	writeRun := func(c byte, n int) {
+		if n > 3 {
+			fmt.Fprintf(&b, "!%d%c", n, c)
+		} else {
+			b.WriteString(strings.Repeat(string(c), n))
+		}
+	}
+
+	// Each band of six rows is drawn one color at a time
+	for y0 := 0; y0 < h; y0 += 6 {
+		var used [216]bool
+		for i := y0 * w; i < (y0+6)*w && i < len(pixels); i++ {
+			if pixels[i] >= 0 {
+				used[pixels[i]] = true
+			}
+		}
+
+		first := true
+		for c := range used {
+			if !used[c] {
+				continue
+			}
+			if !first {
+				b.WriteByte('$') // Back to the start of the band
+			}
+			first = false
+			fmt.Fprintf(&b, "#%d", c)
+
+			var prev byte
+			run := 0
+			for x := 0; x < w; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y0+dy < h; dy++ {
+					if pixels[(y0+dy)*w+x] == c {
+						bits |= 1 << dy
+					}
+				}
+				if bits+63 == prev {
+					run++
+					continue
+				}
+				writeRun(prev, run)
+				prev, run = bits+63, 1
+			}
+			writeRun(prev, run)
+		}
+		b.WriteByte('-') // Next band
+	}
+
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// drawGraphics draws the image of the current tab, if it has one.
+// It is used as the after draw func of the App.
+func drawGraphics(screen tcell.Screen) {
+	if graphics == graphicsNone || len(tabs) == 0 {
+		return
+	}
+	t := tabs[curTab]
+
+	// Images are hidden when anything, like a modal, might be drawn over them
+	focus := App.GetFocus()
+	if !t.page.Graphic || (focus != t.view && focus != bottomBar) {
+		if shownImage != nil {
+			shownImage = nil
+			if graphics == graphicsKitty {
+				fmt.Fprint(os.Stdout, "\x1b_Ga=d,q=2\x1b\\")
+			} else {
+				// Redraw everything to get rid of the sixel pixels
+				screen.Sync()
+			}
+		}
+		return
+	}
+
+	x, y, w, h := t.image.GetInnerRect()
+	if shownImage == t.image && shownImageRect == [4]int{x, y, w, h} {
+		// Already drawn
+		return
+	}
+	if shownImage != nil && graphics == graphicsSixel {
+		screen.Sync()
+	}
+	// Make sure the empty image view is on the screen before drawing over it
+	screen.Show()
+	fmt.Fprint(os.Stdout, t.image.encode())
+	shownImage = t.image
+	shownImageRect = [4]int{x, y, w, h}
+}
@@ -0,0 +1,51 @@
+package display
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+var fitImageTests = []struct {
+	imgW, imgH, boxCols, boxRows int
+	cols, rows, pxW, pxH         int
+}{
+	// Cells are 10x20 pixels
+	{50, 40, 80, 24, 5, 2, 50, 40},         // Small images aren't enlarged
+	{1600, 400, 80, 24, 80, 10, 800, 200},  // Too wide
+	{400, 1600, 80, 24, 12, 24, 120, 480},  // Too tall
+	{1600, 1600, 80, 24, 48, 24, 480, 480}, // Too wide and tall
+	{0, 40, 80, 24, 0, 0, 0, 0},            // Invalid image
+	{50, 40, 0, 0, 0, 0, 0, 0},             // No space
+}
+
+func TestFitImage(t *testing.T) {
+	for _, tt := range fitImageTests {
+		cols, rows, pxW, pxH := fitImage(tt.imgW, tt.imgH, tt.boxCols, tt.boxRows, 10, 20)
+		if cols != tt.cols || rows != tt.rows || pxW != tt.pxW || pxH != tt.pxH {
+			t.Errorf("fitImage(%d, %d, %d, %d): expected %d %d %d %d, actual %d %d %d %d",
+				tt.imgW, tt.imgH, tt.boxCols, tt.boxRows,
+				tt.cols, tt.rows, tt.pxW, tt.pxH, cols, rows, pxW, pxH)
+		}
+	}
+}
+
+func TestEncodeSixel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 7))
+	for y := 0; y < 7; y++ {
+		img.Set(0, y, color.RGBA{255, 0, 0, 255})
+	}
+	// The second column is transparent
+
+	s := encodeSixel(img, 2, 7)
+	if !strings.HasPrefix(s, "\x1bP0;1;0q\"1;1;2;7") || !strings.HasSuffix(s, "\x1b\\") {
+		t.Fatalf("encodeSixel: invalid start or end: %q", s)
+	}
+	// Red is palette index 5*36 = 180
+	// First band is six full pixels then transparent, second band is one pixel
+	expected := "#180~?-#180@?-\x1b\\"
+	if !strings.HasSuffix(s, expected) {
+		t.Errorf("encodeSixel: expected suffix %q, actual %q", expected, s[strings.LastIndex(s, "#215;"):])
+	}
+}
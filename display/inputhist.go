@@ -0,0 +1,102 @@
+package display
+
+import (
+	"net/url"
+	"sync"
+)
+
+// Input sent for status 10 prompts is kept for the session, for each URL,
+// so it can be used again by pressing Up in the input field. Sensitive input,
+// for status 11, is never kept.
+
+// How many inputs are kept for each URL. The oldest ones are removed first.
+const maxInputHistory = 20
+
+var inputHistory = make(map[string][]string)
+var inputHistoryMu = sync.Mutex{}
+
+// inputHistoryKey returns the URL that inputs for the prompt at the URL are kept
+// under. It's the URL without the query string, which is where the input goes.
+func inputHistoryKey(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// addInputHistory keeps the input sent to the URL. If it was already sent,
+// it's moved to the end so it's the most recent.
+func addInputHistory(u, input string) {
+	key := inputHistoryKey(u)
+	inputHistoryMu.Lock()
+	defer inputHistoryMu.Unlock()
+
+	inputs := inputHistory[key]
+	for i := range inputs {
+		if inputs[i] == input {
+			inputs = append(inputs[:i], inputs[i+1:]...)
+			break
+		}
+	}
+	inputs = append(inputs, input)
+	if len(inputs) > maxInputHistory {
+		inputs = inputs[len(inputs)-maxInputHistory:]
+	}
+	inputHistory[key] = inputs
+}
+
+// getInputHistory returns a copy of the inputs sent to the URL, oldest first.
+func getInputHistory(u string) []string {
+	inputHistoryMu.Lock()
+	defer inputHistoryMu.Unlock()
+	inputs := inputHistory[inputHistoryKey(u)]
+	return append(make([]string, 0, len(inputs)), inputs...)
+}
+
+// clearInputHistory removes all the inputs kept this session.
+func clearInputHistory() {
+	inputHistoryMu.Lock()
+	inputHistory = make(map[string][]string)
+	inputHistoryMu.Unlock()
+}
+
+// historyCursor goes through previous inputs, like a shell does with Up and Down.
+type historyCursor struct {
+	items []string
+	pos   int    // Index of the item in the field, len(items) when it's not one
+	draft string // What was typed before going through the items
+}
+
+func newHistoryCursor(items []string) *historyCursor {
+	return &historyCursor{items: items, pos: len(items)}
+}
+
+// prev returns the item before the one in the field, which has the text current.
+// It returns false if there are no older items.
+func (c *historyCursor) prev(current string) (string, bool) {
+	if c.pos == 0 {
+		return "", false
+	}
+	if c.pos == len(c.items) {
+		c.draft = current
+	}
+	c.pos--
+	return c.items[c.pos], true
+}
+
+// next returns the item after the one in the field, or what was typed before
+// going through the items once the newest one is passed. It returns false if
+// the field doesn't have an item.
+func (c *historyCursor) next() (string, bool) {
+	if c.pos >= len(c.items) {
+		return "", false
+	}
+	c.pos++
+	if c.pos == len(c.items) {
+		return c.draft, true
+	}
+	return c.items[c.pos], true
+}
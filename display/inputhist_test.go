@@ -0,0 +1,66 @@
+package display
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestInputHistory(t *testing.T) {
+	defer clearInputHistory()
+
+	addInputHistory("gemini://example.com/search?old", "a")
+	addInputHistory("gemini://example.com/search", "b")
+	addInputHistory("gemini://example.com/search#frag", "a")
+	addInputHistory("gemini://example.com/other", "c")
+
+	got := getInputHistory("gemini://example.com/search?query")
+	if want := []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getInputHistory = %q, want %q", got, want)
+	}
+
+	for i := 0; i < maxInputHistory+5; i++ {
+		addInputHistory("gemini://example.com/many", strconv.Itoa(i))
+	}
+	got = getInputHistory("gemini://example.com/many")
+	if len(got) != maxInputHistory || got[0] != "5" {
+		t.Errorf("getInputHistory kept %d inputs starting with %q, want %d starting with \"5\"",
+			len(got), got[0], maxInputHistory)
+	}
+
+	clearInputHistory()
+	if got := getInputHistory("gemini://example.com/search"); len(got) != 0 {
+		t.Errorf("getInputHistory after clearing = %q, want none", got)
+	}
+}
+
+func TestHistoryCursor(t *testing.T) {
+	c := newHistoryCursor([]string{"one", "two"})
+	if _, ok := c.next(); ok {
+		t.Error("next before prev returned true")
+	}
+	steps := []struct {
+		prev bool
+		want string
+		ok   bool
+	}{
+		{true, "two", true},
+		{true, "one", true},
+		{true, "", false},
+		{false, "two", true},
+		{false, "draft", true},
+		{false, "", false},
+	}
+	for i, s := range steps {
+		var got string
+		var ok bool
+		if s.prev {
+			got, ok = c.prev("draft")
+		} else {
+			got, ok = c.next()
+		}
+		if got != s.want || ok != s.ok {
+			t.Errorf("step %d = %q, %v, want %q, %v", i, got, ok, s.want, s.ok)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package display
+
+import (
+	"strconv"
+	"strings"
+
+	"gitlab.com/tslocum/cview"
+)
+
+// The link list is a side panel with all the links of the current page, and
+// where they go. It can be searched like the table of contents, and selecting
+// a link follows it.
+
+var linksPanel *listPanel
+
+// Indexes of the page links shown in the list, after searching.
+var linksShown []int
+
+// The link text and resolved URL of each link of the page the list is for.
+var linksText, linksURLs []string
+
+// Where the page was scrolled to when the list was opened, to go back to.
+var linksRow, linksCol int
+
+func linksInit() {
+	linksPanel = newListPanel("links", " Links ", "amfora_link", searchLinks, followListedLink, closeLinks)
+	linksPanel.addPanel(true)
+}
+
+// linkTexts returns the displayed text of each of the n links in the content,
+// which are the regions with the link indexes as IDs. The text of links that
+// were wrapped is joined back into one line.
+func linkTexts(content string, n int) []string {
+	parts := make([][]string, n)
+	region := -1
+	add := func(s string) {
+		if region >= 0 && region < n {
+			parts[region] = append(parts[region], s)
+		}
+	}
+
+	last := 0
+	for _, loc := range cviewTagRegex.FindAllStringIndex(content, -1) {
+		add(content[last:loc[0]])
+		tag := content[loc[0]:loc[1]]
+		if m := regionTagRegex.FindStringSubmatch(tag); m != nil {
+			region = -1
+			if i, err := strconv.Atoi(m[1]); err == nil {
+				region = i
+			}
+			add(" ") // Between the wrapped lines of the link
+		} else if m := escapedTagRegex.FindStringSubmatch(tag); m != nil {
+			// Displayed as the tag, without the extra bracket
+			add("[" + m[1] + m[2] + "]")
+		}
+		last = loc[1]
+	}
+	add(content[last:])
+
+	texts := make([]string, n)
+	for i := range parts {
+		texts[i] = strings.Join(strings.Fields(strings.Join(parts[i], "")), " ")
+	}
+	return texts
+}
+
+// pageLinks returns the displayed text and the resolved URL of each link of
+// the tab's page. Links with no text use their URL as it is on the page.
+func pageLinks(t *tab) ([]string, []string) {
+	texts := linkTexts(t.page.Content, len(t.page.Links))
+	urls := make([]string, len(t.page.Links))
+	for i, link := range t.page.Links {
+		urls[i] = link
+		if resolved, err := resolveRelLink(t, t.page.URL, link); err == nil {
+			urls[i] = resolved
+		}
+		if texts[i] == "" {
+			// Like a link line with no text, or one hidden in reader mode
+			texts[i] = link
+		}
+	}
+	return texts, urls
+}
+
+// Links displays the link list of the current page.
+func Links() {
+	t := tabs[curTab]
+	if !t.hasContent() || len(t.page.Links) == 0 {
+		Info("This page has no links.")
+		return
+	}
+
+	linksText, linksURLs = pageLinks(t)
+	linksRow, linksCol = t.view.GetScrollOffset()
+
+	linksPanel.show(0)
+}
+
+// searchLinks shows just the links whose text or URL contain the search text,
+// ignoring case.
+func searchLinks(text string) {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	linksPanel.list.Clear()
+	linksShown = linksShown[:0]
+	for i := range linksURLs {
+		if !strings.Contains(strings.ToLower(linksText[i]), text) &&
+			!strings.Contains(strings.ToLower(linksURLs[i]), text) {
+			continue
+		}
+		linksShown = append(linksShown, i)
+		item := cview.NewListItem("[" + strconv.Itoa(i+1) + "[] " + cview.Escape(linksText[i]))
+		item.SetSecondaryText(cview.Escape(linksURLs[i]))
+		linksPanel.list.AddItem(item)
+	}
+}
+
+// followListedLink closes the link list and follows the link at index i of the list.
+func followListedLink(i int) {
+	t := tabs[curTab]
+	if i < 0 || i >= len(linksShown) || linksShown[i] >= len(t.page.Links) {
+		closeLinks()
+		return
+	}
+	linksPanel.hide()
+	followLink(t, t.page.URL, t.page.Links[linksShown[i]])
+}
+
+// closeLinks closes the link list, and scrolls the page back to where it was.
+func closeLinks() {
+	linksPanel.hide()
+	tabs[curTab].view.ScrollTo(linksRow, linksCol)
+	App.Draw()
+}
@@ -0,0 +1,18 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinkTexts(t *testing.T) {
+	content := "text\r\n" +
+		`[::b][1[][::-]  ["0"][blue]First[-][""]` + "\r\n" +
+		`[::b][2[][::-]  ["1"][blue]Wrapped[-][""]` + "\r\n" +
+		`     ["1"][blue]link [x[] text[-][""]` + "\r\n" +
+		`[::b][3[][::-]  ["2"][blue][-][""]`
+	want := []string{"First", "Wrapped link [x] text", "", ""}
+	if got := linkTexts(content, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("linkTexts = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package display
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// listPanel is a bordered list with a search field under it, which is focused
+// so the list can be searched as soon as it's shown. The table of contents,
+// the link list, and the recently visited list are all one.
+type listPanel struct {
+	name   string // Of the panel in panels
+	layout *cview.Flex
+	list   *cview.List
+	search *cview.InputField
+
+	textColor string                                // Theme color of the list items
+	searched  func(text string)                     // Fills the list with the items that match
+	keys      func(*tcell.EventKey) *tcell.EventKey // Optional, for more keys in the list and search field
+}
+
+// newListPanel returns a list panel with the title. searched is called to fill
+// the list when the search text changes, selected with the index of the item
+// picked with Enter, and closed when Escape is pressed.
+func newListPanel(name, title, textColor string, searched func(string), selected func(int), closed func()) *listPanel {
+	p := &listPanel{
+		name:      name,
+		layout:    cview.NewFlex(),
+		list:      cview.NewList(),
+		search:    cview.NewInputField(),
+		textColor: textColor,
+		searched:  searched,
+	}
+
+	p.list.SetSelectedFunc(func(i int, _ *cview.ListItem) {
+		selected(i)
+	})
+	p.list.SetDoneFunc(closed)
+	p.list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if p.keys != nil {
+			return p.keys(event)
+		}
+		return event
+	})
+
+	p.search.SetLabel("Search: ")
+	p.search.SetChangedFunc(searched)
+	p.search.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if p.list.GetItemCount() > 0 {
+				selected(p.list.GetCurrentItemIndex())
+			}
+		case tcell.KeyEsc:
+			closed()
+		}
+	})
+	p.search.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Move through the list while searching
+		//nolint:exhaustive
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			p.list.InputHandler()(event, nil)
+			return nil
+		}
+		if p.keys != nil {
+			return p.keys(event)
+		}
+		return event
+	})
+
+	p.layout.SetDirection(cview.FlexRow)
+	p.layout.AddItem(p.list, 0, 1, false)
+	p.layout.AddItem(p.search, 1, 0, true)
+	p.layout.SetBorder(true)
+	p.layout.SetTitle(title)
+	p.layout.SetTitleAlign(cview.AlignCenter)
+
+	p.setColors()
+	return p
+}
+
+// addPanel adds the list panel to panels, hidden. If half is true it only
+// takes up the right half of the screen, leaving the page visible.
+func (p *listPanel) addPanel(half bool) {
+	if !half {
+		panels.AddPanel(p.name, p.layout, true, false)
+		return
+	}
+	side := cview.NewFlex()
+	side.SetBackgroundTransparent(true)
+	side.AddItem(nil, 0, 1, false)
+	side.AddItem(p.layout, 0, 1, true)
+	panels.AddPanel(p.name, side, true, false)
+}
+
+// setColors sets the colors of the list panel from the theme,
+// or to black and white if colors are disabled.
+func (p *listPanel) setColors() {
+	if viper.GetBool("a-general.color") {
+		p.layout.SetBackgroundColor(config.GetColor("bg"))
+		p.layout.SetBorderColor(config.GetColor("regular_text"))
+		p.layout.SetTitleColor(config.GetColor("regular_text"))
+		p.list.SetBackgroundColor(config.GetColor("bg"))
+		p.list.SetMainTextColor(config.GetColor(p.textColor))
+		p.list.SetSecondaryTextColor(config.GetColor("regular_text"))
+		p.list.SetSelectedBackgroundColor(config.GetColor("regular_text"))
+		p.list.SetSelectedTextColor(config.GetColor("bg"))
+		p.search.SetBackgroundColor(config.GetColor("bottombar_bg"))
+		p.search.SetLabelColor(config.GetColor("bottombar_label"))
+		p.search.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
+		p.search.SetFieldTextColor(config.GetColor("bottombar_text"))
+	} else {
+		p.layout.SetBackgroundColor(tcell.ColorBlack)
+		p.layout.SetBorderColor(tcell.ColorWhite)
+		p.layout.SetTitleColor(tcell.ColorWhite)
+		p.list.SetBackgroundColor(tcell.ColorBlack)
+		p.list.SetMainTextColor(tcell.ColorWhite)
+		p.list.SetSecondaryTextColor(tcell.ColorWhite)
+		p.list.SetSelectedBackgroundColor(tcell.ColorWhite)
+		p.list.SetSelectedTextColor(tcell.ColorBlack)
+		p.search.SetBackgroundColor(tcell.ColorWhite)
+		p.search.SetLabelColor(tcell.ColorBlack)
+		p.search.SetFieldBackgroundColor(tcell.ColorWhite)
+		p.search.SetFieldTextColor(tcell.ColorBlack)
+	}
+}
+
+// show clears the search, so all the items are listed, selects the item at
+// index cur, and shows the list panel.
+func (p *listPanel) show(cur int) {
+	p.search.SetText("")
+	p.searched("")
+	p.list.SetCurrentItem(cur)
+
+	panels.ShowPanel(p.name)
+	panels.SendToFront(p.name)
+	App.SetFocus(p.search)
+}
+
+// hide hides the list panel and focuses the current tab again.
+func (p *listPanel) hide() {
+	panels.HidePanel(p.name)
+	App.SetFocus(tabs[curTab].view)
+}
@@ -0,0 +1,84 @@
+package display
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Functions for showing that a page is loading, and stopping it.
+//
+// Stopping a request closes its connection, wherever it is: connecting, doing
+// the TLS handshake, waiting for the server, or reading the page. It leaves
+// the tab on the page it was on before. Titan uploads can't be stopped.
+
+// loadCancelMu protects the loadCancel field of tabs, which is set by
+// handleURL in its goroutine, and used by stopLoading in the UI one.
+var loadCancelMu sync.Mutex
+
+// Frames of the spinner shown in the bottomBar while loading.
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// loadingSpinner animates the "Loading..." text in the bottomBar of the tab,
+// until the context is cancelled or the tab stops loading.
+func (t *tab) loadingSpinner(ctx context.Context) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	frame := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		frame = (frame + 1) % len(spinnerFrames)
+		text := spinnerFrames[frame] + " Loading..."
+
+		App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || t.mode != tabModeLoading || !isValidTab(t) {
+				return
+			}
+			t.barText = text
+			if t == tabs[curTab] && !bottomBar.HasFocus() {
+				bottomBar.SetText(text)
+			}
+		})
+	}
+}
+
+// setLoadCancel sets the func that stops the request the tab is loading.
+// It's nil when nothing is loading.
+func (t *tab) setLoadCancel(cancel context.CancelFunc) {
+	loadCancelMu.Lock()
+	t.loadCancel = cancel
+	loadCancelMu.Unlock()
+}
+
+// stopLoading stops the request the tab is loading, if it can be stopped.
+// It returns false if there was nothing to stop.
+func (t *tab) stopLoading() bool {
+	loadCancelMu.Lock()
+	defer loadCancelMu.Unlock()
+	if t.mode != tabModeLoading || t.loadCancel == nil {
+		return false
+	}
+	t.loadCancel()
+	return true
+}
+
+// closeOnCancel closes the body if the context is cancelled, so that reads
+// from it stop. The returned func must be called once the body is no longer
+// being read from, to stop watching the context.
+func closeOnCancel(ctx context.Context, body io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			body.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,99 @@
+package display
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"gitlab.com/tslocum/cview"
+)
+
+// mailto is what a mailto: URL is for, see RFC 6068.
+type mailto struct {
+	to      []string
+	subject string
+	body    string
+}
+
+// parseMailto returns the addresses, subject, and body of the mailto: URL.
+// Addresses can be separated by commas, and also be in the "to" query param.
+func parseMailto(u string) (*mailto, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "mailto" {
+		return nil, errors.New("not a mailto: URL") //nolint:goerr113
+	}
+	addrs, err := url.PathUnescape(parsed.Opaque)
+	if err != nil {
+		return nil, err
+	}
+	query := parsed.Query()
+
+	m := mailto{
+		subject: query.Get("subject"),
+		body:    query.Get("body"),
+	}
+	for _, list := range append([]string{addrs}, query["to"]...) {
+		for _, addr := range strings.Split(list, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				m.to = append(m.to, addr)
+			}
+		}
+	}
+	if len(m.to) == 0 {
+		return nil, errors.New("no email address in the URL") //nolint:goerr113
+	}
+	return &m, nil
+}
+
+// String returns the addresses, subject, and body to display.
+func (m *mailto) String() string {
+	s := "To: " + strings.Join(m.to, ", ")
+	if m.subject != "" {
+		s += "\nSubject: " + m.subject
+	}
+	if m.body != "" {
+		s += "\n\n" + m.body
+	}
+	return s
+}
+
+// handleMailto is used by handleURL for mailto: URLs. If there's a
+// mailto_command the bottomBar asks whether to run it, otherwise the addresses
+// are displayed so they can be copied.
+func handleMailto(u string) {
+	m, err := parseMailto(u)
+	if err != nil {
+		Error("URL Error", "Invalid mailto: URL: "+err.Error())
+		return
+	}
+
+	if len(config.MailtoCommand) > 0 {
+		setBottomBarMode(barConfirmHTTP)
+		bottomBarHTTP = u
+		bottomBar.SetLabel("[::b]Write an email to " + cview.Escape(strings.Join(m.to, ", ")) + "? (y/n): [::-]")
+		bottomBar.SetText("")
+		App.SetFocus(bottomBar)
+		App.Draw()
+		return
+	}
+
+	if !YesNo(m.String() + "\n\nCopy the address?") {
+		return
+	}
+	copyText(strings.Join(m.to, ", "), "address", "[::b]Copied address. [::-]")
+}
+
+// openMailto runs the mailto_command for the URL, once it's been confirmed.
+func openMailto(u string) {
+	name, args := httpCommandArgs(config.MailtoCommand, u)
+	if err := exec.Command(name, args...).Start(); err != nil {
+		Error("Email Error", "Error executing the mailto command: "+err.Error())
+		return
+	}
+	App.Draw()
+}
@@ -0,0 +1,35 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+var parseMailtoTests = []struct {
+	url  string
+	want *mailto
+}{
+	{"mailto:a@example.com", &mailto{to: []string{"a@example.com"}}},
+	{"mailto:a@example.com,%20b@example.com", &mailto{to: []string{"a@example.com", "b@example.com"}}},
+	{"mailto:a@example.com?subject=Hi%20there&body=Line%201%0ALine%202",
+		&mailto{to: []string{"a@example.com"}, subject: "Hi there", body: "Line 1\nLine 2"}},
+	{"mailto:?to=a@example.com,b@example.com&to=c@example.com",
+		&mailto{to: []string{"a@example.com", "b@example.com", "c@example.com"}}},
+	{"mailto:?subject=Nobody", nil},
+	{"gemini://example.com/", nil},
+}
+
+func TestParseMailto(t *testing.T) {
+	for _, tt := range parseMailtoTests {
+		got, err := parseMailto(tt.url)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("parseMailto(%q) = %+v, want an error", tt.url, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMailto(%q) = %+v, %v, want %+v", tt.url, got, err, tt.want)
+		}
+	}
+}
@@ -1,11 +1,8 @@
 package display
 
 import (
-	"fmt"
 	"strings"
-	"time"
 
-	humanize "github.com/dustin/go-humanize"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/spf13/viper"
@@ -40,7 +37,61 @@ func modalInit() {
 	panels.AddPanel("input", inputModal, false, false)
 	panels.AddPanel("yesno", yesNoModal, false, false)
 
-	// Color setup
+	modalColors()
+
+	// Modal functions that can't be added up above, because they return the wrong type
+
+	infoModal.SetBorder(true)
+	frame := infoModal.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" Info ")
+	infoModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		panels.HidePanel("info")
+		App.SetFocus(tabs[curTab].view)
+		App.Draw()
+	})
+
+	errorModal.SetBorder(true)
+	errorModal.GetFrame().SetTitleAlign(cview.AlignCenter)
+	errorModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		panels.HidePanel("error")
+		App.SetFocus(tabs[curTab].view)
+		App.Draw()
+	})
+
+	inputModal.SetBorder(true)
+	frame = inputModal.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" Input ")
+	inputModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Send" {
+			inputCh <- inputModalText
+			return
+		}
+		// Empty string indicates no input
+		inputCh <- ""
+	})
+
+	yesNoModal.SetBorder(true)
+	yesNoModal.GetFrame().SetTitleAlign(cview.AlignCenter)
+	yesNoModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Yes" {
+			yesNoCh <- true
+			return
+		}
+		yesNoCh <- false
+	})
+
+	bkmkInit()
+	dlInit()
+	certInit()
+	tofuInit()
+	multilineInit()
+}
+
+// modalColors sets the colors of the modals from the theme,
+// or to black and white if colors are disabled.
+func modalColors() {
 	if viper.GetBool("a-general.color") {
 		m := infoModal
 		m.SetBackgroundColor(config.GetColor("info_modal_bg"))
@@ -133,52 +184,6 @@ func modalInit() {
 		form.SetButtonBackgroundColorFocused(tcell.ColorBlack)
 		form.SetButtonTextColorFocused(tcell.ColorWhite)
 	}
-
-	// Modal functions that can't be added up above, because they return the wrong type
-
-	infoModal.SetBorder(true)
-	frame := infoModal.GetFrame()
-	frame.SetTitleAlign(cview.AlignCenter)
-	frame.SetTitle(" Info ")
-	infoModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		panels.HidePanel("info")
-		App.SetFocus(tabs[curTab].view)
-		App.Draw()
-	})
-
-	errorModal.SetBorder(true)
-	errorModal.GetFrame().SetTitleAlign(cview.AlignCenter)
-	errorModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		panels.HidePanel("error")
-		App.SetFocus(tabs[curTab].view)
-		App.Draw()
-	})
-
-	inputModal.SetBorder(true)
-	frame = inputModal.GetFrame()
-	frame.SetTitleAlign(cview.AlignCenter)
-	frame.SetTitle(" Input ")
-	inputModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Send" {
-			inputCh <- inputModalText
-			return
-		}
-		// Empty string indicates no input
-		inputCh <- ""
-	})
-
-	yesNoModal.SetBorder(true)
-	yesNoModal.GetFrame().SetTitleAlign(cview.AlignCenter)
-	yesNoModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Yes" {
-			yesNoCh <- true
-			return
-		}
-		yesNoCh <- false
-	})
-
-	bkmkInit()
-	dlInit()
 }
 
 // Error displays an error on the screen in a modal.
@@ -214,6 +219,12 @@ func Info(s string) {
 // Input pulls up a modal that asks for input, and returns the user's input.
 // It returns an bool indicating if the user chose to send input or not.
 func Input(prompt string, sensitive bool) (string, bool) {
+	return inputWithHistory(prompt, sensitive, nil)
+}
+
+// inputWithHistory is like Input, but previous inputs can be put in the
+// field by pressing Up and Down. They're ordered oldest first.
+func inputWithHistory(prompt string, sensitive bool, history []string) (string, bool) {
 	// Remove elements and re-add them - to clear input text and keep input in focus
 	inputModal.ClearButtons()
 	inputModal.GetForm().Clear(false)
@@ -233,6 +244,26 @@ func Input(prompt string, sensitive bool) (string, bool) {
 			func(text string) {
 				inputModalText = text
 			})
+		if field, ok := inputModal.GetForm().GetFormItem(0).(*cview.InputField); ok && len(history) > 0 {
+			cursor := newHistoryCursor(history)
+			field.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+				var text string
+				var ok bool
+				//nolint:exhaustive
+				switch event.Key() {
+				case tcell.KeyUp:
+					text, ok = cursor.prev(field.GetText())
+				case tcell.KeyDown:
+					text, ok = cursor.next()
+				default:
+					return event
+				}
+				if ok {
+					field.SetText(text)
+				}
+				return nil
+			})
+		}
 	}
 
 	inputModal.SetText(prompt + " ")
@@ -283,41 +314,3 @@ func YesNo(prompt string) bool {
 	App.Draw()
 	return resp
 }
-
-// Tofu displays the TOFU warning modal.
-// It returns a bool indicating whether the user wants to continue.
-func Tofu(host string, expiry time.Time) bool {
-	// Reuses yesNoModal, with error color
-
-	m := yesNoModal
-	frame := yesNoModal.GetFrame()
-	if viper.GetBool("a-general.color") {
-		m.SetBackgroundColor(config.GetColor("tofu_modal_bg"))
-		m.SetTextColor(config.GetColor("tofu_modal_text"))
-		frame.SetBorderColor(config.GetColor("tofu_modal_text"))
-		frame.SetTitleColor(config.GetColor("tofu_modal_text"))
-	} else {
-		m.SetBackgroundColor(tcell.ColorBlack)
-		m.SetTextColor(tcell.ColorWhite)
-		m.SetBorderColor(tcell.ColorWhite)
-		m.SetTitleColor(tcell.ColorWhite)
-	}
-	frame.SetTitle(" TOFU ")
-	m.SetText(
-		//nolint:lll
-		fmt.Sprintf("%s's certificate has changed, possibly indicating an security issue. The certificate would have expired %s. Are you sure you want to continue? ",
-			host,
-			humanize.Time(expiry),
-		),
-	)
-	panels.ShowPanel("yesno")
-	panels.SendToFront("yesno")
-	App.SetFocus(yesNoModal)
-	App.Draw()
-
-	resp := <-yesNoCh
-	panels.HidePanel("yesno")
-	App.SetFocus(tabs[curTab].view)
-	App.Draw()
-	return resp
-}
@@ -0,0 +1,159 @@
+package display
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/mattn/go-runewidth"
+	"gitlab.com/tslocum/cview"
+)
+
+// Mouse support, if enabled in the config. The wheel scrolls the current tab,
+// and clicking a link follows it. Other mouse events on tabs and the tab bar
+// are ignored, so they can't change the focus or the current tab without the
+// rest of Amfora knowing.
+
+// The number of rows scrolled for each turn of the mouse wheel.
+const mouseScrollRows = 3
+
+// inPrimitive returns true if the screen position is inside the primitive,
+// as it was last drawn.
+func inPrimitive(p cview.Primitive, x, y int) bool {
+	px, py, w, h := p.GetRect()
+	return x >= px && x < px+w && y >= py && y < py+h
+}
+
+// mouseCapture handles mouse events before they get to the primitive under
+// the pointer. It's used with App.SetMouseCapture.
+func mouseCapture(event *tcell.EventMouse, action cview.MouseAction) (*tcell.EventMouse, cview.MouseAction) {
+	if event == nil || curTab < 0 {
+		return event, action
+	}
+	x, y := event.Position()
+	t := tabs[curTab]
+
+	switch {
+	case inPrimitive(browser.Switcher, x, y):
+		// Tabs are switched with the keyboard
+		return nil, action
+	case inPrimitive(bottomBar, x, y) && App.GetFocus() != bottomBar:
+		// The bottomBar is opened with the keyboard, so it's set up for what's typed
+		return nil, action
+	case inPrimitive(t.contentView(), x, y):
+		if App.GetFocus() == t.view {
+			// Not while a modal is open or the bottomBar is being used
+			t.handleMouse(x, y, action)
+		}
+		return nil, action
+	case splitTab != nil && inPrimitive(splitTab.contentView(), x, y):
+		return nil, action
+	}
+	// Modals and the bottomBar handle the mouse themselves
+	return event, action
+}
+
+// handleMouse scrolls the tab or follows a link, for a mouse event at
+// the screen position inside the tab.
+func (t *tab) handleMouse(x, y int, action cview.MouseAction) {
+	if t.page.Graphic {
+		return
+	}
+
+	//nolint:exhaustive
+	switch action {
+	case cview.MouseScrollUp:
+		t.scrollRows(-mouseScrollRows)
+	case cview.MouseScrollDown:
+		t.scrollRows(mouseScrollRows)
+	case cview.MouseLeftClick:
+		if t.mode != tabModeDone || t.page.Source ||
+			(t.page.Mode != structs.ModeOff && t.page.Mode != structs.ModeLinkSelect) {
+			// The content displayed isn't the page content with links
+			return
+		}
+		ix, iy, _, _ := t.view.GetInnerRect()
+		row, col := t.view.GetScrollOffset()
+		lines := strings.Split(t.page.Content, "\n")
+		n := row + y - iy
+		if n < 0 || n >= len(lines) {
+			return
+		}
+		i, err := strconv.Atoi(regionAt(strings.TrimSuffix(lines[n], "\r"), col+x-ix))
+		if err != nil || i < 0 || i >= len(t.page.Links) {
+			// Not a link
+			return
+		}
+		t.page.Selected = t.page.Links[i]
+		t.page.SelectedID = strconv.Itoa(i)
+		followLink(t, t.page.URL, t.page.Links[i])
+	}
+}
+
+// scrollRows scrolls the tab down by the number of rows, or up if it's negative.
+func (t *tab) scrollRows(n int) {
+	row, col := t.view.GetScrollOffset()
+	row += n
+	if row < 0 {
+		row = 0
+	}
+	_, lines := t.view.TextDimensions()
+	if row >= lines {
+		row = lines - 1
+	}
+	t.view.ScrollTo(row, col)
+	t.saveScroll()
+}
+
+// regionSpan is where a region is in a line, from the start column up to,
+// but not including, the end.
+type regionSpan struct {
+	id    string
+	start int
+	end   int
+}
+
+// regionSpans returns where each region is in the line, which can have cview tags.
+func regionSpans(line string) []regionSpan {
+	spans := make([]regionSpan, 0)
+	region := ""
+	pos := 0 // Column of the text after the last tag
+	addText := func(s string) {
+		w := runewidth.StringWidth(s)
+		if region != "" && w > 0 {
+			if n := len(spans); n > 0 && spans[n-1].id == region && spans[n-1].end == pos {
+				spans[n-1].end += w
+			} else {
+				spans = append(spans, regionSpan{region, pos, pos + w})
+			}
+		}
+		pos += w
+	}
+
+	last := 0
+	for _, loc := range cviewTagRegex.FindAllStringIndex(line, -1) {
+		addText(line[last:loc[0]])
+		tag := line[loc[0]:loc[1]]
+		if m := regionTagRegex.FindStringSubmatch(tag); m != nil {
+			region = m[1]
+		} else if m := escapedTagRegex.FindStringSubmatch(tag); m != nil {
+			// Displayed as the tag, without the extra bracket
+			addText("[" + m[1] + m[2] + "]")
+		}
+		last = loc[1]
+	}
+	addText(line[last:])
+	return spans
+}
+
+// regionAt returns the ID of the region at the column of the line, which can
+// have cview tags. An empty string is returned if there's no region there.
+func regionAt(line string, col int) string {
+	for _, s := range regionSpans(line) {
+		if col >= s.start && col < s.end {
+			return s.id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+var regionAtTests = []struct {
+	line string
+	col  int
+	want string
+}{
+	{`[::b][1[][::-]  ["0"][blue]Link[-][""]`, 0, ""},
+	{`[::b][1[][::-]  ["0"][blue]Link[-][""]`, 5, "0"},
+	{`[::b][1[][::-]  ["0"][blue]Link[-][""]`, 8, "0"},
+	{`[::b][1[][::-]  ["0"][blue]Link[-][""]`, 9, ""},
+	{`     ["12"][blue]wrapped[-][""] after`, 4, ""},
+	{`     ["12"][blue]wrapped[-][""] after`, 5, "12"},
+	{`     ["12"][blue]wrapped[-][""] after`, 13, ""},
+	{`["3"]日本[""]`, 3, "3"},
+	{`["3"]日本[""]`, 4, ""},
+	{`no regions`, 2, ""},
+}
+
+func TestRegionAt(t *testing.T) {
+	for _, tt := range regionAtTests {
+		if got := regionAt(tt.line, tt.col); got != tt.want {
+			t.Errorf("regionAt(%q, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestRegionSpans(t *testing.T) {
+	got := regionSpans(`[::b][1[][::-]  ["0"][blue]Li[::b]nk[-][""] ["1"]x[""]`)
+	want := []regionSpan{{"0", 5, 9}, {"1", 10, 11}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("regionSpans = %v, want %v", got, want)
+	}
+}
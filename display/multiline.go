@@ -0,0 +1,161 @@
+package display
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// The multi-line input is a popup for typing or pasting text that's sent to a
+// server, like for Spartan prompts and Titan uploads. Enter starts a new line,
+// Ctrl-S sends the text, and Esc cancels. The size of the text in bytes is
+// shown below it, since that's what's sent in the request.
+
+var multilinePanel = cview.NewFlex()
+var multilineLayout = cview.NewFlex()
+var multilinePrompt = cview.NewTextView()
+var multilineText = cview.NewTextView()
+var multilineStatus = cview.NewTextView()
+
+var multilineCh = make(chan bool)
+var multilineInput string // What's been typed so far
+
+func multilineInit() {
+	multilinePrompt.SetWordWrap(true)
+	multilineText.SetDynamicColors(true)
+	multilineText.SetInputCapture(multilineCapture)
+
+	multilineLayout.SetDirection(cview.FlexRow)
+	multilineLayout.AddItem(multilinePrompt, 2, 0, false)
+	multilineLayout.AddItem(multilineText, 0, 1, true)
+	multilineLayout.AddItem(multilineStatus, 1, 0, false)
+	multilineLayout.SetBorder(true)
+	multilineLayout.SetTitle(" Input ")
+	multilineLayout.SetTitleAlign(cview.AlignCenter)
+
+	// Centered, with part of the page visible around it
+	inner := cview.NewFlex()
+	inner.SetDirection(cview.FlexRow)
+	inner.SetBackgroundTransparent(true)
+	inner.AddItem(nil, 0, 1, false)
+	inner.AddItem(multilineLayout, 0, 4, true)
+	inner.AddItem(nil, 0, 1, false)
+	multilinePanel.SetBackgroundTransparent(true)
+	multilinePanel.AddItem(nil, 0, 1, false)
+	multilinePanel.AddItem(inner, 0, 6, true)
+	multilinePanel.AddItem(nil, 0, 1, false)
+
+	panels.AddPanel("multiline", multilinePanel, true, false)
+
+	multilineColors()
+}
+
+// multilineColors sets the colors of the multi-line input from the theme,
+// or to black and white if colors are disabled.
+func multilineColors() {
+	if viper.GetBool("a-general.color") {
+		multilineLayout.SetBackgroundColor(config.GetColor("input_modal_bg"))
+		multilineLayout.SetBorderColor(config.GetColor("input_modal_text"))
+		multilineLayout.SetTitleColor(config.GetColor("input_modal_text"))
+		multilinePrompt.SetBackgroundColor(config.GetColor("input_modal_bg"))
+		multilinePrompt.SetTextColor(config.GetColor("input_modal_text"))
+		multilineText.SetBackgroundColor(config.GetColor("input_modal_field_bg"))
+		multilineText.SetTextColor(config.GetColor("input_modal_field_text"))
+		multilineStatus.SetBackgroundColor(config.GetColor("input_modal_bg"))
+		multilineStatus.SetTextColor(config.GetColor("input_modal_text"))
+	} else {
+		multilineLayout.SetBackgroundColor(tcell.ColorBlack)
+		multilineLayout.SetBorderColor(tcell.ColorWhite)
+		multilineLayout.SetTitleColor(tcell.ColorWhite)
+		multilinePrompt.SetBackgroundColor(tcell.ColorBlack)
+		multilinePrompt.SetTextColor(tcell.ColorWhite)
+		multilineText.SetBackgroundColor(tcell.ColorWhite)
+		multilineText.SetTextColor(tcell.ColorBlack)
+		multilineStatus.SetBackgroundColor(tcell.ColorBlack)
+		multilineStatus.SetTextColor(tcell.ColorWhite)
+	}
+}
+
+// multilineCapture handles the keys pressed in the multi-line input.
+// Pasted text arrives as key presses too, with Enter for each newline.
+func multilineCapture(event *tcell.EventKey) *tcell.EventKey {
+	//nolint:exhaustive
+	switch event.Key() {
+	case tcell.KeyCtrlS:
+		multilineCh <- true
+		return nil
+	case tcell.KeyEsc:
+		multilineCh <- false
+		return nil
+	case tcell.KeyEnter:
+		multilineInput += "\n"
+	case tcell.KeyTab:
+		multilineInput += "\t"
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		multilineInput = deleteLastRune(multilineInput)
+	case tcell.KeyRune:
+		multilineInput += string(event.Rune())
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+		// Scroll through long text
+		return event
+	default:
+		return nil
+	}
+	multilineUpdate()
+	return nil
+}
+
+// multilineUpdate displays the text typed so far, and its size.
+func multilineUpdate() {
+	text := cview.Escape(strings.ReplaceAll(multilineInput, "\t", "    "))
+	// A cursor at the end, since that's where the text is typed
+	multilineText.SetText(text + "[::r] [::-]")
+	multilineText.ScrollToEnd()
+	multilineStatus.SetText(multilineStatusText(multilineInput))
+}
+
+// deleteLastRune returns the text without its last character.
+func deleteLastRune(s string) string {
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
+
+// multilineStatusText returns the size of the text and the keys to use,
+// for below the multi-line input.
+func multilineStatusText(s string) string {
+	size := humanize.Comma(int64(len(s))) + " bytes"
+	if len(s) == 1 {
+		size = "1 byte"
+	}
+	return size + " | Ctrl-S to send, Esc to cancel"
+}
+
+// MultilineInput pulls up a popup to type or paste text that can have
+// multiple lines. It returns the text, and false if it was cancelled.
+//
+// It must be called in a goroutine.
+func MultilineInput(prompt string) (string, bool) {
+	multilineInput = ""
+	multilinePrompt.SetText(prompt)
+	multilineUpdate()
+
+	panels.ShowPanel("multiline")
+	panels.SendToFront("multiline")
+	App.SetFocus(multilineText)
+	App.Draw()
+
+	ok := <-multilineCh
+	text := multilineInput
+	multilineInput = ""
+
+	panels.HidePanel("multiline")
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+
+	return text, ok
+}
@@ -0,0 +1,33 @@
+package display
+
+import "testing"
+
+func TestDeleteLastRune(t *testing.T) {
+	tests := map[string]string{
+		"":       "",
+		"a":      "",
+		"abc":    "ab",
+		"line\n": "line",
+		"café":   "caf",
+		"日本":     "日",
+	}
+	for s, want := range tests {
+		if got := deleteLastRune(s); got != want {
+			t.Errorf("deleteLastRune(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestMultilineStatusText(t *testing.T) {
+	tests := map[string]string{
+		"":    "0 bytes | Ctrl-S to send, Esc to cancel",
+		"a":   "1 byte | Ctrl-S to send, Esc to cancel",
+		"é":   "2 bytes | Ctrl-S to send, Esc to cancel",
+		"a\n": "2 bytes | Ctrl-S to send, Esc to cancel",
+	}
+	for s, want := range tests {
+		if got := multilineStatusText(s); got != want {
+			t.Errorf("multilineStatusText(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
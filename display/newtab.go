@@ -2,8 +2,10 @@ package display
 
 import (
 	"io/ioutil"
+	"strings"
 
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
 )
 
 //nolint
@@ -13,7 +15,7 @@ You've opened a new tab. Use the bar at the bottom to browse around. You can sta
 
 Press the ? key at any time to bring up the help, and see other keybindings. Most are what you expect.
 
-You can customize this page by creating a gemtext file called newtab.gmi, in Amfora's configuration folder.
+You can customize this page by creating a gemtext file called newtab.gmi, in Amfora's configuration folder, or with the newtab_content option in the config. Set newtab_url to load a page in new tabs instead.
 
 Happy browsing!
 
@@ -32,8 +34,12 @@ Happy browsing!
 => //gemini.circumlunar.space Project Gemini
 `
 
-// Read the new tab content from a file if it exists or fallback to a default page.
+// Read the new tab content from the config if it's set, or from a file if it exists,
+// or fallback to a default page.
 func getNewTabContent() string {
+	if content := viper.GetString("a-general.newtab_content"); strings.TrimSpace(content) != "" {
+		return content
+	}
 	data, err := ioutil.ReadFile(config.NewTabPath)
 	if err == nil {
 		return string(data)
@@ -0,0 +1,66 @@
+package display
+
+import (
+	"context"
+	"errors"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/rr"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// handlePlain displays a successful response to a request over a plain TCP
+// connection, like a Gopher or Spartan one, or offers to download it if it
+// can't be displayed. It returns the same values as handleURL.
+// The context stops loading the page, until it's displayed.
+func handlePlain(ctx context.Context, t *tab, u string, res *client.Response) (string, bool) {
+	// Use RestartReader to buffer read data, in case the download choice is needed
+	res.Body = rr.NewRestartReader(res.Body)
+
+	if !renderer.CanDisplay(res.Response) && !(graphics != graphicsNone && renderer.IsImage(res.Response)) {
+		// Binary files
+		return offerDownload("That file could not be displayed. What would you like to do?", u, res)
+	}
+
+	stopWatching := closeOnCancel(ctx, res.Body)
+	page, err := renderer.MakePage(u, res.Response, textWidth(), true)
+	stopWatching()
+	// Rendering may have taken a while, make sure tab is still valid
+	if !isValidTab(t) || ctx.Err() != nil {
+		res.Body.Close()
+		return "", false
+	}
+	if errors.Is(err, renderer.ErrTooLarge) {
+		return offerDownload("That page is too large. What would you like to do?", u, res)
+	}
+	if errors.Is(err, renderer.ErrTimedOut) {
+		return offerDownload("Loading that page timed out. What would you like to do?", u, res)
+	}
+	if errors.Is(err, renderer.ErrCantDisplay) && renderer.IsImage(res.Response) {
+		return offerDownload("That image could not be displayed. What would you like to do?", u, res)
+	}
+	if err != nil {
+		Error("Page Error", "Issuing creating page: "+err.Error())
+		return "", false
+	}
+
+	page.Security = structs.SecurityPlain
+	page.TermWidth = termW
+	page.LeftMargin = leftMargin()
+	if !t.incognito {
+		go t.pageCache().Set(page)
+	}
+	setPage(t, page)
+	return u, true
+}
+
+// offerDownload offers to download the response passed to handlePlain instead
+// of displaying it, from the start. It returns the values for handlePlain.
+func offerDownload(text, u string, res *client.Response) (string, bool) {
+	// Disable read timeout and go back to start
+	res.SetReadTimeout(0) //nolint: errcheck
+	res.Body.(*rr.RestartReader).Restart()
+	go dlChoice(text, u, res.Response)
+	return "", false
+}
@@ -0,0 +1,146 @@
+package display
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// Previews of selected links, if enabled in the config.
+//
+// Gemini has no HEAD request, so the link's URL is requested and only the
+// response header is used, the body is closed without being read. The status
+// and mediatype are shown in the bottomBar next to the link.
+
+// How long to wait after a link is selected before requesting it, so that
+// quickly moving through links doesn't make a request for each one.
+const previewDelay = 400 * time.Millisecond
+
+// How long a preview is kept before the link is requested again.
+const previewTTL = 30 * time.Second
+
+type linkPreview struct {
+	text string
+	at   time.Time
+}
+
+var previews = make(map[string]linkPreview)
+var previewsMu = sync.Mutex{}
+
+// previewText returns what's shown in the bottomBar for the response.
+func previewText(res *gemini.Response) string {
+	status := strconv.Itoa(res.Status)
+	meta := strings.TrimSpace(res.Meta)
+	switch gemini.SimplifyStatus(res.Status) {
+	case 20:
+		if meta == "" {
+			return status + " text/gemini"
+		}
+		mediatype := strings.TrimSpace(strings.SplitN(meta, ";", 2)[0])
+		return status + " " + mediatype
+	case 30:
+		return status + " redirect to " + meta
+	}
+	if meta == "" {
+		return status
+	}
+	return status + " " + meta
+}
+
+// getPreview returns the preview of the URL, making a request if it isn't in
+// the previews or the page cache. It returns false if the context was
+// cancelled first.
+func getPreview(ctx context.Context, pages cache.Cache, u string) (string, bool) {
+	previewsMu.Lock()
+	p, ok := previews[u]
+	previewsMu.Unlock()
+	if ok && time.Since(p.at) < previewTTL {
+		return p.text, true
+	}
+
+	if page, ok := pages.Get(u); ok {
+		return "20 " + page.RawMediatype, true
+	}
+
+	res, err := client.FetchContext(ctx, u)
+	if ctx.Err() != nil {
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
+		return "", false
+	}
+	var text string
+	if err != nil {
+		if res != nil && res.Body != nil {
+			// Returned with a TOFU error
+			res.Body.Close()
+		}
+		text = "error: " + err.Error()
+	} else {
+		res.Body.Close()
+		text = previewText(res.Response)
+	}
+
+	previewsMu.Lock()
+	previews[u] = linkPreview{text, time.Now()}
+	previewsMu.Unlock()
+	return text, true
+}
+
+// previewLink shows a preview of the selected link in the bottomBar, if enabled
+// in the config. Only Gemini links that aren't loaded through a proxy are previewed.
+// Any preview that's still loading for the tab is stopped.
+//
+// The preview is loaded in the background, and only displayed if the link
+// is still selected.
+func (t *tab) previewLink() {
+	if t.previewCancel != nil {
+		t.previewCancel()
+		t.previewCancel = nil
+	}
+	if !viper.GetBool("a-general.link_preview") || t.page.Mode != structs.ModeLinkSelect || t.incognito {
+		// Previews are kept for all tabs, so incognito tabs don't have them
+		return
+	}
+	link := t.page.Selected
+	u, err := resolveRelLink(t, t.page.URL, link)
+	if err != nil || !strings.HasPrefix(u, "gemini://") || !schemeAllowed("gemini") {
+		return
+	}
+	if proxy := strings.TrimSpace(viper.GetString("proxies.gemini")); proxy != "" && proxy != "off" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.previewCancel = cancel
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(previewDelay):
+		}
+		text, ok := getPreview(ctx, t.pageCache(), u)
+		if !ok {
+			return
+		}
+		App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || !isValidTab(t) || t.mode != tabModeDone ||
+				t.page.Mode != structs.ModeLinkSelect || t.page.Selected != link {
+				return
+			}
+			t.barText = link + " (" + text + ")"
+			if t == tabs[curTab] && !bottomBar.HasFocus() {
+				bottomBar.SetText(t.barText)
+			}
+		})
+	}()
+}
@@ -0,0 +1,22 @@
+package display
+
+import (
+	"context"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+func TestGetPreviewCached(t *testing.T) {
+	u := "gemini://preview.example.com/"
+	pages := fakeCache{}
+	pages.Set(&structs.Page{URL: u, RawMediatype: "text/gemini"})
+
+	// No request is made for a cached page, so the context doesn't matter
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	text, ok := getPreview(ctx, pages, u)
+	if !ok || text != "20 text/gemini" {
+		t.Errorf("getPreview = %q, %t, want %q, true", text, ok, "20 text/gemini")
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
 )
 
 // This file contains the functions that aren't part of the public API.
@@ -24,11 +26,22 @@ func followLink(t *tab, prev, next string) {
 	}
 
 	if t.hasContent() {
+		linkFollowed(t.page, next)
 		nextURL, err := resolveRelLink(t, prev, next)
 		if err != nil {
 			Error("URL Error", err.Error())
 			return
 		}
+		if isPrompt(t.page, next) {
+			go spartanPrompt(nextURL)
+			return
+		}
+		if fragment, ok := samePageFragment(t.page.URL, nextURL); ok {
+			// Just a link to somewhere on this page, so it isn't loaded again
+			t.fragment = fragment
+			t.scrollToFragment(fragment)
+			return
+		}
 		go goURL(t, nextURL)
 		return
 	}
@@ -42,50 +55,149 @@ func followLink(t *tab, prev, next string) {
 	go goURL(t, next)
 }
 
+// isFormatted returns true if the page content was set for the current terminal
+// width, left margin, theme, and text wrapping, and doesn't need to be reformatted.
+func isFormatted(p *structs.Page) bool {
+	return p.TermWidth == termW && p.LeftMargin == leftMargin() &&
+		p.NoLinkNums == !viper.GetBool("a-general.show_link_numbers") &&
+		p.ThemeVersion == config.ThemeVersion() &&
+		p.Wrapped == viper.GetBool("a-general.wrap_text")
+}
+
 // reformatPage will take the raw page content and reformat it according to the current terminal dimensions.
-// It should be called when the terminal size changes.
+// It should be called when the terminal size or left margin changes.
 // It will not waste resources if the passed page is already fitted to the current terminal width, and can be
 // called safely even when the page might be already formatted properly.
 func reformatPage(p *structs.Page) {
-	if p.TermWidth == termW {
+	if isFormatted(p) || p.Graphic {
 		// No changes to make
 		return
 	}
 
 	// TODO: Setup a renderer.RenderFromMediatype func so this isn't needed
 
+	proxied := true
+	if strings.HasPrefix(p.URL, "gemini") ||
+		strings.HasPrefix(p.URL, "about") ||
+		strings.HasPrefix(p.URL, "file") {
+		proxied = false
+	}
+
 	var rendered string
-	switch p.Mediatype {
-	case structs.TextGemini:
+	switch {
+	case p.Source:
+		rendered = renderer.RenderSource(p.Raw)
+	case p.Mediatype == structs.TextGemini:
 		// Links are not recorded because they won't change
-		proxied := true
-		if strings.HasPrefix(p.URL, "gemini") ||
-			strings.HasPrefix(p.URL, "about") ||
-			strings.HasPrefix(p.URL, "file") {
-			proxied = false
+		raw := p.Raw
+		if p.Reader {
+			raw = renderer.StripLinks(raw)
+		}
+		// The headings are though, because their rows depend on the width
+		if strings.HasPrefix(p.URL, "spartan://") {
+			rendered, _, _, p.Headings = renderer.RenderSpartan(raw, textWidth())
+		} else {
+			r := renderer.RenderGemtext(raw, renderer.PageOptions(textWidth(), proxied, p.URL, p.Folds))
+			rendered, p.Headings, p.PreBlocks = r.Content, r.Headings, r.PreBlocks
+		}
+		if viper.GetBool("a-general.mark_new_links") {
+			rendered = renderer.MarkNewLinks(rendered, p.NewLinks)
 		}
-		rendered, _ = renderer.RenderGemini(p.Raw, textWidth(), proxied)
-	case structs.TextPlain:
+	case p.Mediatype == structs.TextMarkdown:
+		var err error
+		rendered, _, err = renderer.RenderMarkdown(p.Raw, textWidth(), proxied, p.URL)
+		if err != nil {
+			// It rendered fine the first time, so this shouldn't happen
+			return
+		}
+	case p.Mediatype == structs.TextPlain:
 		rendered = renderer.RenderPlainText(p.Raw)
-	case structs.TextAnsi:
+	case p.Mediatype == structs.TextAnsi:
 		rendered = renderer.RenderANSI(p.Raw)
 	default:
 		// Rendering this type is not implemented
 		return
 	}
+	if !p.Source && (p.Mediatype == structs.TextPlain || p.Mediatype == structs.TextAnsi) {
+		// Every line is preformatted, unless the text is wrapped
+		if viper.GetBool("a-general.wrap_text") {
+			rendered = renderer.WrapText(rendered, textWidth())
+			p.MaxPreCols = 0
+		} else {
+			p.MaxPreCols = renderer.MaxPreCols(p.Raw, p.Mediatype)
+		}
+	}
 	p.Content = rendered
+	scaleColumn(p, p.TermWidth, p.LeftMargin)
 	p.TermWidth = termW
+	p.LeftMargin = leftMargin()
+	p.NoLinkNums = !viper.GetBool("a-general.show_link_numbers")
+	p.ThemeVersion = config.ThemeVersion()
+	p.Wrapped = viper.GetBool("a-general.wrap_text")
+}
+
+// scaleColumn adjusts the horizontal scroll position of the page after the
+// terminal width changed from oldW and the left margin from oldMargin, so
+// that wide preformatted content stays roughly where it was. The left margin
+// is part of the column (see #197), so it's adjusted separately from how
+// far the text itself is scrolled.
+func scaleColumn(p *structs.Page, oldW, oldMargin int) {
+	if p.Column == 0 || oldW <= 0 || termW <= 0 {
+		return
+	}
+	margin := leftMargin()
+	if p.Column < oldMargin {
+		// Only the margin is scrolled, by the same fraction of it
+		p.Column = p.Column * margin / oldMargin
+	} else {
+		p.Column = margin + (p.Column-oldMargin)*termW/oldW
+	}
+
+	if p.MaxPreCols > 0 {
+		// Same limit as scrollRight, once the left margin is gone
+		maxColumn := p.MaxPreCols + margin - termW
+		if p.Column > maxColumn {
+			p.Column = maxColumn
+		}
+	}
+	if p.Column < 0 {
+		p.Column = 0
+	}
+}
+
+// linkFollowed stops marking the link as new on the page, see renderer.MarkNewLinks.
+// The page is rendered again the next time it's displayed.
+func linkFollowed(p *structs.Page, link string) {
+	for i := range p.NewLinks {
+		if p.NewLinks[i] && i < len(p.Links) && p.Links[i] == link {
+			p.NewLinks[i] = false
+			p.TermWidth = -1
+		}
+	}
 }
 
 // reformatPageAndSetView is for reformatting a page that is already being displayed.
 // setPage should be used when a page is being loaded for the first time.
 func reformatPageAndSetView(t *tab, p *structs.Page) {
-	if p.TermWidth == termW {
+	if isFormatted(p) {
 		// No changes to make
 		return
 	}
+	if p.Mode == structs.ModeTextSelect {
+		// The lines change when the page is reformatted, so the selection can't be kept
+		t.clearSelected()
+		t.barLabel = securityLabel(p.Security)
+		t.barText = p.URL
+		if t == tabs[curTab] {
+			t.applyBottomBar()
+		}
+	}
 	reformatPage(p)
 	t.view.SetText(p.Content)
+	if p.Mode == structs.ModeSearch {
+		// Search regions need to be added to the new content
+		t.applySearch()
+	}
 	t.applyScroll() // Go back to where you were, roughly
 
 	App.Draw()
@@ -102,9 +214,26 @@ func setPage(t *tab, p *structs.Page) {
 	// Make sure the page content is fitted to the terminal every time it's displayed
 	reformatPage(p)
 
+	if t.page.Mode == structs.ModeTextSelect {
+		// Stop selecting text on the page being left
+		t.page.Mode = structs.ModeOff
+	}
+	if t.page.Source && t.page != p {
+		// Render the page being left again, in case it's cached
+		t.page.Source = false
+		t.page.MaxPreCols = renderer.MaxPreCols(t.page.Raw, t.page.Mediatype)
+		t.page.TermWidth = -1
+	}
+	if t.autoReloadURL != "" && p.URL != t.autoReloadURL {
+		// Went to another page
+		t.stopAutoReload()
+	}
 	t.page = p
 
 	// Change page on screen
+	if p.Graphic {
+		t.image.setImage(p)
+	}
 	t.view.SetText(p.Content)
 	t.view.Highlight("") // Turn off highlights, other funcs may restore if necessary
 	t.view.ScrollToBeginning()
@@ -112,21 +241,25 @@ func setPage(t *tab, p *structs.Page) {
 	tabNum := tabNumber(t)
 	browser.AddTab(
 		strconv.Itoa(tabNum),
-		makeTabLabel(strconv.Itoa(tabNum+1)),
-		makeContentLayout(t.view, leftMargin()),
+		tabLabel(tabNum),
+		makeContentLayout(t.contentView(), leftMargin()),
 	)
+	if t == splitTab {
+		// The other pane has the old layout
+		applySplit()
+	}
 	App.Draw()
 
-	go func() {
-		parsed, _ := url.Parse(p.URL)
-		handleFavicon(t, parsed.Host)
-	}()
+	go handleFavicon(t, p)
+	if !t.incognito {
+		addRecentVisit(p.URL)
+	}
 
 	// Setup display
 	App.SetFocus(t.view)
 
 	// Save bottom bar for the tab - other funcs will apply/display it
-	t.barLabel = ""
+	t.barLabel = securityLabel(p.Security)
 	t.barText = p.URL
 }
 
@@ -136,12 +269,24 @@ func setPage(t *tab, p *structs.Page) {
 //
 // It should be called in a goroutine.
 func goURL(t *tab, u string) {
+	t.saveHistState()
 	final, displayed := handleURL(t, u, 0)
 	if displayed {
 		t.addToHistory(final)
+
+		// The fragment is removed from the URL before it's loaded, but it's kept for scrolling
+		t.fragment = ""
+		if parsed, err := url.Parse(u); err == nil && parsed.Fragment != "" {
+			t.fragment = parsed.Fragment
+			t.scrollToFragment(t.fragment)
+		}
 	}
 	if t == tabs[curTab] {
 		// Display the bottomBar state that handleURL set
 		t.applyBottomBar()
+	} else if displayed && isValidTab(t) {
+		// Loaded in the background, so show that in the tab bar
+		t.unseen = true
+		browser.SetTabLabel(strconv.Itoa(tabNumber(t)), tabLabel(tabNumber(t)))
 	}
 }
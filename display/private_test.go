@@ -0,0 +1,38 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+func TestScaleColumn(t *testing.T) {
+	oldW := termW
+	defer func() { termW = oldW }()
+	defer viper.Set("a-general.center_text", nil)
+	defer viper.Set("a-general.left_margin", nil)
+
+	viper.Set("a-general.center_text", false)
+	viper.Set("a-general.left_margin", 10) // The new margin
+	for _, tt := range []struct {
+		column, oldW, oldMargin, termW, maxPreCols int
+		want                                       int
+	}{
+		{10, 100, 20, 100, 0, 5},    // Inside the old margin, scaled to the new one
+		{60, 100, 10, 200, 0, 110},  // Past the margin, the text is scaled to the width
+		{60, 100, 10, 200, 250, 60}, // Clamped to the widest preformatted line
+		{60, 100, 10, 200, 100, 0},  // Which fits on the screen, so no scrolling
+		{60, 0, 10, 200, 0, 60},     // No old width, left alone
+		{60, 100, 10, 0, 0, 60},     // No terminal width yet, left alone
+		{0, 100, 10, 200, 250, 0},   // Not scrolled
+	} {
+		termW = tt.termW
+		p := structs.Page{Column: tt.column, MaxPreCols: tt.maxPreCols}
+		scaleColumn(&p, tt.oldW, tt.oldMargin)
+		if p.Column != tt.want {
+			t.Errorf("scaleColumn from column %d, width %d, and margin %d, to width %d with MaxPreCols %d = %d, want %d",
+				tt.column, tt.oldW, tt.oldMargin, tt.termW, tt.maxPreCols, p.Column, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package display
+
+import (
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Functions for reader mode, where the link lines of a page are hidden,
+// and for viewing the source of a page.
+
+// headingAbove returns the nearest heading at or above the row of the rendered
+// content, with tags removed. n is the number of identical lines before it,
+// so it can be found again by headingRow. ok is false if there is no heading.
+func headingAbove(content string, row int) (heading string, n int, ok bool) {
+	lines := strings.Split(content, "\n")
+	if row >= len(lines) {
+		row = len(lines) - 1
+	}
+	for i := row; i >= 0; i-- {
+		line := strings.TrimSpace(cviewTagRegex.ReplaceAllString(lines[i], ""))
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if strings.TrimSpace(cviewTagRegex.ReplaceAllString(lines[j], "")) == line {
+				n++
+			}
+		}
+		return line, n, true
+	}
+	return "", 0, false
+}
+
+// headingRow returns the row of the nth occurrence of the heading returned by
+// headingAbove in the rendered content, or -1 if it's not there.
+func headingRow(content, heading string, n int) int {
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(cviewTagRegex.ReplaceAllString(line, "")) != heading {
+			continue
+		}
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return -1
+}
+
+// toggleReader hides the link lines of the tab's gemtext page, or shows them
+// again if they're hidden. The page stays scrolled to the same heading.
+func (t *tab) toggleReader() {
+	if t.page.Mediatype != structs.TextGemini || t.mode != tabModeDone {
+		return
+	}
+
+	reformatMu.Lock()
+	defer reformatMu.Unlock()
+
+	row, _ := t.view.GetScrollOffset()
+	heading, n, ok := headingAbove(t.page.Content, row)
+
+	// Link numbers and search matches might not be visible anymore
+	t.clearSelected()
+	bottomBar.SetLabel(securityLabel(t.page.Security))
+	bottomBar.SetText(t.page.URL)
+	t.saveBottomBar()
+
+	t.page.Reader = !t.page.Reader
+	t.page.TermWidth = -1 // Force reformatting
+	reformatPage(t.page)
+	t.view.SetText(t.page.Content)
+
+	t.page.Row = 0
+	if ok {
+		if newRow := headingRow(t.page.Content, heading, n); newRow != -1 {
+			t.page.Row = newRow
+		}
+	}
+	t.applyScroll()
+	App.Draw()
+}
+
+// toggleSource shows the raw text of the tab's page as it was received,
+// or renders it again if the source is being shown. The page isn't loaded
+// again, and it stays scrolled to the same heading, or the same row if
+// there isn't one. Links can't be selected while the source is shown.
+func (t *tab) toggleSource() {
+	if t.page.Graphic || t.page.Raw == "" || t.mode != tabModeDone {
+		return
+	}
+
+	reformatMu.Lock()
+	defer reformatMu.Unlock()
+
+	row, _ := t.view.GetScrollOffset()
+	heading, n, ok := headingAbove(t.page.Content, row)
+
+	t.clearSelected()
+	t.page.Source = !t.page.Source
+	if t.page.Source {
+		// Every line of the source is as wide as it is
+		t.page.MaxPreCols = renderer.MaxPreCols(t.page.Raw, structs.TextPlain)
+		t.barLabel = "[::b]Source: [::-]"
+	} else {
+		t.page.MaxPreCols = renderer.MaxPreCols(t.page.Raw, t.page.Mediatype)
+		t.barLabel = securityLabel(t.page.Security)
+	}
+	t.barText = t.page.URL
+	t.page.TermWidth = -1 // Force reformatting
+	reformatPage(t.page)
+	t.view.SetText(t.page.Content)
+
+	t.page.Row = row
+	if ok {
+		if newRow := headingRow(t.page.Content, heading, n); newRow != -1 {
+			t.page.Row = newRow
+		}
+	}
+	t.applyScroll()
+	t.applyBottomBar()
+	App.Draw()
+}
@@ -0,0 +1,48 @@
+package display
+
+import (
+	"testing"
+)
+
+const readerTestContent = "[red::b]# Title[-::-]\r\n" +
+	"Some text\r\n" +
+	`[::b][1[][::-]  ["0"][blue]A link[-][""]` + "\r\n" +
+	"[red::b]## Section[-::-]\r\n" +
+	"More text\r\n" +
+	"[red::b]## Section[-::-]\r\n" +
+	"Even more text"
+
+var headingAboveTests = []struct {
+	row     int
+	heading string
+	n       int
+	ok      bool
+}{
+	{0, "# Title", 0, true},
+	{2, "# Title", 0, true},
+	{4, "## Section", 0, true},
+	{6, "## Section", 1, true},
+	{100, "## Section", 1, true},
+}
+
+func TestHeadingAbove(t *testing.T) {
+	for _, tt := range headingAboveTests {
+		heading, n, ok := headingAbove(readerTestContent, tt.row)
+		if heading != tt.heading || n != tt.n || ok != tt.ok {
+			t.Errorf("headingAbove(%d): expected %q %d %v, actual %q %d %v", tt.row, tt.heading, tt.n, tt.ok, heading, n, ok)
+		}
+	}
+
+	if _, _, ok := headingAbove("No headings\r\nhere", 1); ok {
+		t.Error("headingAbove: found a heading in content without one")
+	}
+}
+
+func TestHeadingRow(t *testing.T) {
+	if row := headingRow(readerTestContent, "## Section", 1); row != 5 {
+		t.Errorf("headingRow: expected 5, actual %d", row)
+	}
+	if row := headingRow(readerTestContent, "## Missing", 0); row != -1 {
+		t.Errorf("headingRow: expected -1, actual %d", row)
+	}
+}
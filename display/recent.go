@@ -0,0 +1,148 @@
+package display
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"gitlab.com/tslocum/cview"
+)
+
+// The recently visited list is a side panel with the last pages displayed in
+// any tab, most recent first. It can be searched like the link list, and
+// selecting a URL opens it in the current tab, or in a new one with the new
+// tab key.
+
+// How many visits are remembered.
+const recentVisitsMax = 50
+
+type recentVisit struct {
+	url  string
+	time time.Time
+}
+
+// recentVisits is a ring buffer of the pages displayed this session, across
+// all tabs except incognito ones. recentVisitsNext is where the next one goes.
+var recentVisits [recentVisitsMax]recentVisit
+var recentVisitsNext, recentVisitsLen int
+var recentVisitsMu = sync.Mutex{}
+
+var recentPanel *listPanel
+
+// The visits the list is for, most recent first, and the indexes of the
+// ones shown after searching.
+var recentListed []recentVisit
+var recentShown []int
+
+// addRecentVisit records that the URL was displayed now. If it's for the same
+// page as the last one, see urlKey, only the time of that one is changed.
+func addRecentVisit(u string) {
+	if u == "" || u == "about:newtab" {
+		return
+	}
+	recentVisitsMu.Lock()
+	defer recentVisitsMu.Unlock()
+
+	if recentVisitsLen > 0 {
+		last := &recentVisits[(recentVisitsNext+recentVisitsMax-1)%recentVisitsMax]
+		if urlKey(last.url) == urlKey(u) {
+			last.time = time.Now()
+			return
+		}
+	}
+	recentVisits[recentVisitsNext] = recentVisit{u, time.Now()}
+	recentVisitsNext = (recentVisitsNext + 1) % recentVisitsMax
+	if recentVisitsLen < recentVisitsMax {
+		recentVisitsLen++
+	}
+}
+
+// getRecentVisits returns the recent visits, most recent first.
+func getRecentVisits() []recentVisit {
+	recentVisitsMu.Lock()
+	defer recentVisitsMu.Unlock()
+
+	visits := make([]recentVisit, recentVisitsLen)
+	for i := range visits {
+		visits[i] = recentVisits[(recentVisitsNext+recentVisitsMax-1-i)%recentVisitsMax]
+	}
+	return visits
+}
+
+// clearRecentVisits forgets all the recent visits.
+func clearRecentVisits() {
+	recentVisitsMu.Lock()
+	recentVisits = [recentVisitsMax]recentVisit{}
+	recentVisitsNext, recentVisitsLen = 0, 0
+	recentVisitsMu.Unlock()
+}
+
+func recentInit() {
+	recentPanel = newListPanel("recent", " Recently Visited ", "amfora_link", searchRecent,
+		func(i int) { openRecent(i, false) }, closeRecent)
+	recentPanel.keys = recentNewTabCapture
+	recentPanel.addPanel(true)
+}
+
+// recentNewTabCapture opens the selected URL in a new tab when the new tab
+// key is pressed in the list.
+func recentNewTabCapture(event *tcell.EventKey) *tcell.EventKey {
+	if config.TranslateKeyEvent(event) == config.CmdNewTab {
+		openRecent(recentPanel.list.GetCurrentItemIndex(), true)
+		return nil
+	}
+	return event
+}
+
+// Recent displays the recently visited list.
+func Recent() {
+	recentListed = getRecentVisits()
+	if len(recentListed) == 0 {
+		Info("No pages have been visited yet.")
+		return
+	}
+
+	recentPanel.show(0)
+}
+
+// searchRecent shows just the visits whose URL contains the search text,
+// ignoring case.
+func searchRecent(text string) {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	recentPanel.list.Clear()
+	recentShown = recentShown[:0]
+	for i, v := range recentListed {
+		if !strings.Contains(strings.ToLower(v.url), text) {
+			continue
+		}
+		recentShown = append(recentShown, i)
+		item := cview.NewListItem(cview.Escape(v.url))
+		item.SetSecondaryText(v.time.Format("15:04:05") + ", " + humanize.Time(v.time))
+		recentPanel.list.AddItem(item)
+	}
+}
+
+// openRecent closes the recently visited list and opens the URL at index i
+// of the list, in a new tab if newTab is true.
+func openRecent(i int, newTab bool) {
+	if i < 0 || i >= len(recentShown) {
+		closeRecent()
+		return
+	}
+	u := recentListed[recentShown[i]].url
+	recentPanel.hide()
+	if newTab {
+		NewTab()
+	}
+	URL(u)
+}
+
+// closeRecent closes the recently visited list.
+func closeRecent() {
+	recentPanel.hide()
+	App.Draw()
+}
@@ -0,0 +1,51 @@
+package display
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func recentURLs() []string {
+	visits := getRecentVisits()
+	urls := make([]string, len(visits))
+	for i, v := range visits {
+		urls[i] = v.url
+	}
+	return urls
+}
+
+func TestAddRecentVisit(t *testing.T) {
+	clearRecentVisits()
+	addRecentVisit("gemini://a.com/")
+	addRecentVisit("gemini://b.com/")
+	first := getRecentVisits()[0].time
+	addRecentVisit("gemini://b.com/")
+	addRecentVisit("about:newtab")
+	addRecentVisit("gemini://a.com/")
+
+	want := []string{"gemini://a.com/", "gemini://b.com/", "gemini://a.com/"}
+	if got := recentURLs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("recent visits = %q, want %q", got, want)
+	}
+	if got := getRecentVisits()[1].time; got.Before(first) {
+		t.Errorf("time of repeated visit = %v, want it updated from %v", got, first)
+	}
+}
+
+func TestRecentVisitsWrap(t *testing.T) {
+	clearRecentVisits()
+	for i := 0; i < recentVisitsMax+5; i++ {
+		addRecentVisit("gemini://example.com/" + strconv.Itoa(i))
+	}
+	got := recentURLs()
+	if len(got) != recentVisitsMax {
+		t.Fatalf("len(recent visits) = %d, want %d", len(got), recentVisitsMax)
+	}
+	if want := "gemini://example.com/" + strconv.Itoa(recentVisitsMax+4); got[0] != want {
+		t.Errorf("most recent visit = %q, want %q", got[0], want)
+	}
+	if want := "gemini://example.com/5"; got[len(got)-1] != want {
+		t.Errorf("oldest visit = %q, want %q", got[len(got)-1], want)
+	}
+}
@@ -0,0 +1,169 @@
+package display
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// A Unix socket that other programs can use to control Amfora, if it's enabled
+// in the config. Each line sent to it is a JSON command, like:
+//
+//   {"action":"open","url":"gemini://example.com/"}
+//   {"action":"tab","index":2}
+//
+// A JSON object is sent back on its own line for each command, which is
+// {"ok":true}, or {"ok":false,"error":"..."} if the command failed.
+// Tab indexes are the numbers shown in the tab bar, starting at 1.
+
+type remoteCommand struct {
+	Action string `json:"action"`
+	URL    string `json:"url"`
+	Index  int    `json:"index"`
+}
+
+type remoteResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+var remoteListener net.Listener
+
+// parseRemoteCommand parses and checks one line sent to the socket.
+func parseRemoteCommand(line []byte) (*remoteCommand, error) {
+	var c remoteCommand
+	if err := json.Unmarshal(line, &c); err != nil {
+		return nil, fmt.Errorf("malformed command: %w", err)
+	}
+
+	switch c.Action {
+	case "open":
+		c.URL = strings.TrimSpace(c.URL)
+		if c.URL == "" {
+			return nil, errors.New(`the "open" action needs a url`) //nolint:goerr113
+		}
+	case "tab":
+		if c.Index < 1 {
+			return nil, errors.New(`the "tab" action needs an index of 1 or more`) //nolint:goerr113
+		}
+	case "":
+		return nil, errors.New("the command has no action") //nolint:goerr113
+	default:
+		return nil, fmt.Errorf("unknown action %q", c.Action) //nolint:goerr113
+	}
+	return &c, nil
+}
+
+// runRemoteCommand does what the command asks on the UI event loop,
+// and waits until it's done.
+func runRemoteCommand(c *remoteCommand) error {
+	switch c.Action {
+	case "open":
+		return NewTabWithURL(c.URL)
+	case "tab":
+		errCh := make(chan error, 1)
+		App.QueueUpdateDraw(func() {
+			if c.Index > NumTabs() {
+				errCh <- fmt.Errorf("there's no tab %d, there are %d tabs open", c.Index, NumTabs()) //nolint:goerr113
+				return
+			}
+			SwitchTab(c.Index - 1)
+			errCh <- nil
+		})
+		return <-errCh
+	}
+	return nil
+}
+
+// handleRemoteConn runs the commands sent on the connection until it's closed.
+func handleRemoteConn(conn net.Conn) {
+	defer conn.Close()
+
+	enc := json.NewEncoder(conn)
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		c, err := parseRemoteCommand(line)
+		if err == nil {
+			err = runRemoteCommand(c)
+		}
+		resp := remoteResponse{OK: err == nil}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if enc.Encode(&resp) != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// Likely a line that was too long
+		enc.Encode(&remoteResponse{Error: "malformed command: " + err.Error()}) //nolint:errcheck
+	}
+}
+
+// StartRemote starts listening on the remote control socket, if it's enabled
+// in the config. Any error returned should be displayed to the user.
+func StartRemote() error {
+	if !viper.GetBool("remote.enabled") {
+		return nil
+	}
+	path := config.RemoteSocketPath
+
+	if _, err := os.Stat(path); err == nil {
+		// Left behind by an Amfora that didn't close properly, unless it's still in use
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			conn.Close()
+			return fmt.Errorf("%s is being used by another program, maybe another Amfora", path) //nolint:goerr113
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("couldn't remove old socket: %w", err)
+		}
+	}
+
+	// Other users shouldn't be able to control Amfora, even before the
+	// permissions are set
+	restoreUmask := privateUmask()
+	l, err := net.Listen("unix", path)
+	restoreUmask()
+	if err != nil {
+		return fmt.Errorf("couldn't listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return fmt.Errorf("couldn't set the permissions of %s: %w", path, err)
+	}
+	remoteListener = l
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				// The listener was closed
+				return
+			}
+			go handleRemoteConn(conn)
+		}
+	}()
+	return nil
+}
+
+// stopRemote stops listening on the remote control socket, and removes it.
+func stopRemote() {
+	if remoteListener == nil {
+		return
+	}
+	remoteListener.Close() // Removes the socket file too
+	remoteListener = nil
+}
@@ -0,0 +1,36 @@
+package display
+
+import "testing"
+
+var parseRemoteCommandTests = []struct {
+	line    string
+	want    remoteCommand
+	wantErr bool
+}{
+	{`{"action":"open","url":" gemini://example.com/ "}`, remoteCommand{Action: "open", URL: "gemini://example.com/"}, false},
+	{`{"action":"tab","index":2}`, remoteCommand{Action: "tab", Index: 2}, false},
+	{`{"action":"open"}`, remoteCommand{}, true},
+	{`{"action":"tab","index":0}`, remoteCommand{}, true},
+	{`{"action":"close"}`, remoteCommand{}, true},
+	{`{"url":"gemini://example.com/"}`, remoteCommand{}, true},
+	{`open gemini://example.com/`, remoteCommand{}, true},
+}
+
+func TestParseRemoteCommand(t *testing.T) {
+	for _, tt := range parseRemoteCommandTests {
+		got, err := parseRemoteCommand([]byte(tt.line))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRemoteCommand(%q) didn't return an error", tt.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRemoteCommand(%q) returned error: %v", tt.line, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseRemoteCommand(%q) = %+v, want %+v", tt.line, *got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package display
+
+import (
+	"strconv"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// responseInfoDuration is how long the response info stays in the bottomBar.
+const responseInfoDuration = 2 * time.Second
+
+const responseInfoLabel = "[::b]Response: [::-]"
+
+// responseInfo describes the response the page is from, with its status code,
+// mediatype, and size as it was received.
+func responseInfo(p *structs.Page) string {
+	size := len(p.Raw)
+	if p.Encoded != "" {
+		size = len(p.Encoded)
+	}
+	info := strconv.Itoa(p.Status)
+	if p.RawMediatype != "" {
+		info += " " + p.RawMediatype
+	}
+	return info + ", " + humanize.Bytes(uint64(size))
+}
+
+// showResponseInfo saves bottomBar values with the response info of the tab's
+// page, for the response_info option. They're removed after a couple seconds,
+// unless the bottomBar changed since. Nothing is shown if the bottomBar is
+// already showing something other than the URL, like that the page is streaming.
+func (t *tab) showResponseInfo() {
+	if t.page.Status == 0 || t.barLabel != securityLabel(t.page.Security) || t.barText != t.page.URL {
+		return
+	}
+	t.barLabel = responseInfoLabel
+	t.barText = responseInfo(t.page)
+
+	go func(text string) {
+		time.Sleep(responseInfoDuration)
+		App.QueueUpdateDraw(func() {
+			if t.barLabel != responseInfoLabel || t.barText != text {
+				// Something else is being shown now
+				return
+			}
+			t.barLabel = securityLabel(t.page.Security)
+			t.barText = t.page.URL
+			if t == tabs[curTab] && App.GetFocus() != bottomBar {
+				t.applyBottomBar()
+			}
+		})
+	}(t.barText)
+}
@@ -0,0 +1,24 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+var responseInfoTests = []struct {
+	p    structs.Page
+	want string
+}{
+	{structs.Page{Status: 20, RawMediatype: "text/gemini", Raw: "# Hello\n"}, "20 text/gemini, 8 B"},
+	{structs.Page{Status: 20, RawMediatype: "text/plain", Raw: "héllo", Encoded: "h\xe9llo"}, "20 text/plain, 5 B"},
+	{structs.Page{Status: 20, Raw: string(make([]byte, 4200))}, "20, 4.2 kB"},
+}
+
+func TestResponseInfo(t *testing.T) {
+	for _, tt := range responseInfoTests {
+		if got := responseInfo(&tt.p); got != tt.want {
+			t.Errorf("responseInfo(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package display
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// The scroll indicator is at the right of the bottomBar, and shows how far
+// the current page is scrolled down. It's a percentage or a bar, depending
+// on the scroll_indicator setting.
+//
+// It's updated before every draw, as scrolling the page doesn't call the
+// changed func of the TextView, only changing its text does. That way it's
+// also right after the page is reformatted and has a different number of rows.
+
+var scrollIndicator = cview.NewTextView()
+
+// The bottomBar and the scroll indicator, which is the bottom row of the layout.
+var bottomRow = cview.NewFlex()
+
+// How many columns the bar of the scroll indicator is.
+const scrollBarCols = 10
+
+// The Content and number of rows of the page the indicator was last updated for,
+// so the rows aren't counted again for every draw.
+var indicatorContent string
+var indicatorRows int
+
+func scrollIndicatorInit() {
+	scrollIndicator.SetScrollable(false)
+	scrollIndicator.SetWrap(false)
+	scrollIndicator.SetTextAlign(cview.AlignRight)
+
+	width := 0
+	switch viper.GetString("a-general.scroll_indicator") {
+	case "percent":
+		width = 5 // Like " 100%"
+	case "bar":
+		width = scrollBarCols + 2 // A space on each side
+	}
+	bottomRow.AddItem(bottomBar, 0, 1, false)
+	bottomRow.AddItem(scrollIndicator, width, 0, false)
+
+	App.SetBeforeDrawFunc(func(_ tcell.Screen) bool {
+		updateScrollIndicator()
+		return false
+	})
+}
+
+// scrollIndicatorColors sets the colors of the scroll indicator to match the bottomBar.
+func scrollIndicatorColors() {
+	if viper.GetBool("a-general.color") {
+		scrollIndicator.SetBackgroundColor(config.GetColor("bottombar_bg"))
+		scrollIndicator.SetTextColor(config.GetColor("bottombar_text"))
+	} else {
+		scrollIndicator.SetBackgroundColor(tcell.ColorWhite)
+		scrollIndicator.SetTextColor(tcell.ColorBlack)
+	}
+}
+
+// scrollIndicatorText returns the text of the scroll indicator, for a page
+// with the number of rows that's scrolled to row, in a view with the height.
+// style is "percent" or "bar", and char is what the bar is made of.
+// "All" is the percentage when the whole page fits.
+func scrollIndicatorText(style, char string, row, height, rows int) string {
+	frac := 1.0
+	if rows > height {
+		frac = math.Min(math.Max(float64(row)/float64(rows-height), 0), 1)
+	}
+
+	if style == "bar" {
+		if runewidth.StringWidth(char) != 1 {
+			char = "█"
+		}
+		filled := int(math.Round(frac * scrollBarCols))
+		return " " + strings.Repeat(char, filled) + strings.Repeat(" ", scrollBarCols-filled) + " "
+	}
+	if rows <= height {
+		return "All "
+	}
+	return strconv.Itoa(int(math.Round(frac*100))) + "% "
+}
+
+// updateScrollIndicator sets the scroll indicator for the current tab.
+func updateScrollIndicator() {
+	style := viper.GetString("a-general.scroll_indicator")
+	if style != "percent" && style != "bar" {
+		return
+	}
+	if curTab < 0 || curTab >= NumTabs() || !tabs[curTab].hasContent() || tabs[curTab].page.Graphic {
+		scrollIndicator.SetText("")
+		return
+	}
+
+	t := tabs[curTab]
+	if t.page.Content != indicatorContent {
+		indicatorContent = t.page.Content
+		indicatorRows = strings.Count(indicatorContent, "\n") + 1
+	}
+	row, _ := t.view.GetScrollOffset()
+	_, _, _, height := t.view.GetInnerRect()
+	scrollIndicator.SetText(scrollIndicatorText(style, viper.GetString("a-general.scroll_indicator_char"),
+		row, height, indicatorRows))
+}
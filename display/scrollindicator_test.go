@@ -0,0 +1,32 @@
+package display
+
+import "testing"
+
+var scrollIndicatorTextTests = []struct {
+	style  string
+	char   string
+	row    int
+	height int
+	rows   int
+	want   string
+}{
+	{"percent", "", 0, 20, 10, "All "},
+	{"percent", "", 0, 20, 120, "0% "},
+	{"percent", "", 45, 20, 120, "45% "},
+	{"percent", "", 100, 20, 120, "100% "},
+	{"percent", "", 150, 20, 120, "100% "},
+	{"bar", "#", 0, 20, 10, " ########## "},
+	{"bar", "#", 0, 20, 120, "            "},
+	{"bar", "#", 50, 20, 120, " #####      "},
+	{"bar", "##", 100, 20, 120, " ██████████ "},
+}
+
+func TestScrollIndicatorText(t *testing.T) {
+	for _, tt := range scrollIndicatorTextTests {
+		got := scrollIndicatorText(tt.style, tt.char, tt.row, tt.height, tt.rows)
+		if got != tt.want {
+			t.Errorf("scrollIndicatorText(%q, %q, %d, %d, %d) = %q, want %q",
+				tt.style, tt.char, tt.row, tt.height, tt.rows, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package display
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Functions for searching the text of the current page.
+//
+// Each match is put in a region, just like links. The region IDs of matches
+// start with "s", so they can't clash with link region IDs, which are numbers.
+
+// Regex for any tag cview uses in page content: colors, regions, and escaped brackets.
+// The patterns are copied from cview.
+var cviewTagRegex = regexp.MustCompile(
+	`\[([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([lbidrus]+|\-)?)?)?\]|` +
+		`\["([a-zA-Z0-9_,;: \-\.]*)"\]|` +
+		`\[([a-zA-Z0-9_,;: \-\."#]+)\[(\[*)\]`,
+)
+
+// Regex for just region tags, the submatch is the region ID.
+var regionTagRegex = regexp.MustCompile(`^\["([a-zA-Z0-9_,;: \-\.]*)"\]$`)
+
+// searchRegionID returns the region ID for the nth match, zero-indexed.
+func searchRegionID(n int) string {
+	return "s" + strconv.Itoa(n)
+}
+
+// searchContent puts every match of query in the content in its own region.
+// It returns the new content and the number of matches.
+//
+// Text inside tags is never matched. Matches inside links end the link region
+// and then start it again, so link selection still works.
+func searchContent(content, query string) (string, int) {
+	pattern := regexp.QuoteMeta(query)
+	if !viper.GetBool("a-general.page_search_case_sensitive") {
+		pattern = "(?i)" + pattern
+	}
+	re := regexp.MustCompile(pattern)
+
+	var b strings.Builder
+	n := 0       // Number of matches so far
+	region := "" // The region the text is in, to be restored after each match
+
+	search := func(text string) {
+		b.WriteString(re.ReplaceAllStringFunc(text, func(match string) string {
+			id := searchRegionID(n)
+			n++
+			return `["` + id + `"]` + match + `["` + region + `"]`
+		}))
+	}
+
+	last := 0
+	for _, loc := range cviewTagRegex.FindAllStringIndex(content, -1) {
+		search(content[last:loc[0]])
+		tag := content[loc[0]:loc[1]]
+		if m := regionTagRegex.FindStringSubmatch(tag); m != nil {
+			region = m[1]
+		}
+		b.WriteString(tag)
+		last = loc[1]
+	}
+	search(content[last:])
+
+	return b.String(), n
+}
+
+// applySearch displays the page content with the search matches from the
+// page's Selected query, and highlights the selected match.
+// It should only be used when the page is in ModeSearch.
+//
+// applyBottomBar should be called after, as this func might set some bottomBar values.
+func (t *tab) applySearch() {
+	content, n := searchContent(t.page.Content, t.page.Selected)
+	t.searchMatches = n
+	t.view.SetText(content)
+	t.view.Highlight(t.page.SelectedID)
+
+	if t.mode == tabModeDone {
+		// Page is not loading so bottomBar can change
+		t.setSearchBottomBar()
+	}
+}
+
+// setSearchBottomBar saves bottomBar values showing the search query
+// and which match is selected.
+func (t *tab) setSearchBottomBar() {
+	i, _ := strconv.Atoi(strings.TrimPrefix(t.page.SelectedID, "s"))
+	t.barLabel = "[::b]Match " + strconv.Itoa(i+1) + "/" + strconv.Itoa(t.searchMatches) + ": [::-]"
+	t.barText = t.page.Selected
+}
+
+// search searches the page of the tab for the query, and selects the first match.
+func (t *tab) search(query string) {
+	t.clearSelected()
+
+	if _, n := searchContent(t.page.Content, query); n == 0 {
+		t.barLabel = "[::b]No matches: [::-]"
+		t.barText = query
+		t.applyBottomBar()
+		return
+	}
+
+	t.page.Mode = structs.ModeSearch
+	t.page.Selected = query
+	t.page.SelectedID = searchRegionID(0)
+	t.applySearch()
+	t.view.ScrollToHighlight()
+	t.applyBottomBar()
+}
+
+// nextSearchMatch selects the next match when the page is being searched,
+// or the previous one if prev is true. It wraps around at the ends.
+func (t *tab) nextSearchMatch(prev bool) {
+	if t.page.Mode != structs.ModeSearch || t.searchMatches == 0 {
+		return
+	}
+
+	i, _ := strconv.Atoi(strings.TrimPrefix(t.page.SelectedID, "s"))
+	if prev {
+		i = (i - 1 + t.searchMatches) % t.searchMatches
+	} else {
+		i = (i + 1) % t.searchMatches
+	}
+	t.page.SelectedID = searchRegionID(i)
+	t.view.Highlight(t.page.SelectedID)
+	t.view.ScrollToHighlight()
+	t.setSearchBottomBar()
+	t.applyBottomBar()
+}
@@ -0,0 +1,30 @@
+package display
+
+import (
+	"testing"
+)
+
+var searchContentTests = []struct {
+	content  string
+	query    string
+	expected string
+	n        int
+}{
+	{"Hello world", "world", `Hello ["s0"]world[""]`, 1},
+	{"Hello World", "world", `Hello ["s0"]World[""]`, 1},
+	{"a a", "a", `["s0"]a[""] ["s1"]a[""]`, 2},
+	{"[#ffffff]red text[-]", "red", `[#ffffff]["s0"]red[""] text[-]`, 1},
+	{"[#ffffff]text[-]", "ff", "[#ffffff]text[-]", 0},
+	{`["0"]link text[""]`, "text", `["0"]link ["s0"]text["0"][""]`, 1},
+	{"[1[] one", "1", `[1[] one`, 0},
+}
+
+func TestSearchContent(t *testing.T) {
+	for _, tt := range searchContentTests {
+		actual, n := searchContent(tt.content, tt.query)
+		if actual != tt.expected || n != tt.n {
+			t.Errorf("searchContent(%q, %q): expected %q with %d matches, actual %q with %d matches",
+				tt.content, tt.query, tt.expected, tt.n, actual, n)
+		}
+	}
+}
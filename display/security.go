@@ -0,0 +1,63 @@
+package display
+
+import (
+	"net"
+	"net/url"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// The bottomBar shows how the page was loaded before its URL, when
+// security_indicator is enabled. It's a filled circle for TLS with a cert
+// that's been trusted, a triangle in a warning color for a cert that's only
+// trusted for this session, and an empty circle for schemes without TLS.
+
+var securityGlyphs = map[structs.Security]string{
+	structs.SecurityPlain:   "○",
+	structs.SecurityTLS:     "●",
+	structs.SecuritySession: "▲",
+}
+
+var securityColors = map[structs.Security]string{
+	structs.SecurityPlain:   "security_plain",
+	structs.SecurityTLS:     "security_tls",
+	structs.SecuritySession: "security_session",
+}
+
+// geminiSecurity returns the security of a page loaded from the Gemini
+// server, once its cert has been checked.
+func geminiSecurity(hostname, port string) structs.Security {
+	if client.TrustedForSession(hostname, port) {
+		return structs.SecuritySession
+	}
+	return structs.SecurityTLS
+}
+
+// responseServer returns the host to display, the hostname, and the port of
+// the server a Gemini response came from, which has the cert. That's the
+// Gemini proxy if one was used, shown as proxy if it's not empty.
+func responseServer(parsed *url.URL, res *client.Response, proxy string) (string, string, string) {
+	if res.ProxyHostname == "" {
+		return parsed.Host, parsed.Hostname(), parsed.Port()
+	}
+	if proxy == "" {
+		proxy = net.JoinHostPort(res.ProxyHostname, res.ProxyPort)
+	}
+	return proxy, res.ProxyHostname, res.ProxyPort
+}
+
+// securityLabel returns the bottomBar label that goes before the URL of
+// a page with that security. It's empty for pages not loaded from a server.
+func securityLabel(s structs.Security) string {
+	glyph, ok := securityGlyphs[s]
+	if !ok || !viper.GetBool("a-general.security_indicator") {
+		return ""
+	}
+	if viper.GetBool("a-general.color") {
+		return "[" + config.GetColorString(securityColors[s]) + "]" + glyph + "[-] "
+	}
+	return glyph + " "
+}
@@ -0,0 +1,56 @@
+package display
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+func TestSecurityLabel(t *testing.T) {
+	defer viper.Set("a-general.security_indicator", nil)
+	defer viper.Set("a-general.color", nil)
+
+	viper.Set("a-general.security_indicator", true)
+	viper.Set("a-general.color", false)
+	for s, want := range map[structs.Security]string{
+		structs.SecurityNone:    "",
+		structs.SecurityPlain:   "○ ",
+		structs.SecurityTLS:     "● ",
+		structs.SecuritySession: "▲ ",
+	} {
+		if got := securityLabel(s); got != want {
+			t.Errorf("securityLabel(%d) = %q, want %q", s, got, want)
+		}
+	}
+
+	viper.Set("a-general.security_indicator", false)
+	if got := securityLabel(structs.SecurityTLS); got != "" {
+		t.Errorf("securityLabel is %q when the indicator is disabled", got)
+	}
+}
+
+func TestResponseServer(t *testing.T) {
+	parsed, _ := url.Parse("gemini://example.com:1966/page")
+	direct := &client.Response{}
+	proxied := &client.Response{ProxyHostname: "proxy.example.org", ProxyPort: "1965"}
+
+	tests := []struct {
+		res                  *client.Response
+		proxy                string
+		host, hostname, port string
+	}{
+		{direct, "", "example.com:1966", "example.com", "1966"},
+		{proxied, "", "proxy.example.org:1965", "proxy.example.org", "1965"},             // From host-proxies
+		{proxied, "proxy.example.org", "proxy.example.org", "proxy.example.org", "1965"}, // From proxies
+	}
+	for _, tt := range tests {
+		host, hostname, port := responseServer(parsed, tt.res, tt.proxy)
+		if host != tt.host || hostname != tt.hostname || port != tt.port {
+			t.Errorf("responseServer with proxy %q = %q, %q, %q, want %q, %q, %q",
+				tt.proxy, host, hostname, port, tt.host, tt.hostname, tt.port)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package display
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Functions for selecting lines of the current page and copying their text.
+//
+// Like search matches, each line is put in its own region, and the selected
+// lines are highlighted together. The region IDs of lines start with "v".
+
+// Regex for escaped brackets, which cview displays as just the tag.
+var escapedTagRegex = regexp.MustCompile(`^\[([a-zA-Z0-9_,;: \-\."#]+)\[(\[*)\]$`)
+
+// selectRegionID returns the region ID for the nth line, zero-indexed.
+func selectRegionID(n int) string {
+	return "v" + strconv.Itoa(n)
+}
+
+// selectContent puts every line of the content in its own region.
+// Link regions are removed, as regions can't be nested.
+func selectContent(content string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(`["` + selectRegionID(i) + `"]`)
+		b.WriteString(cviewTagRegex.ReplaceAllStringFunc(line, func(tag string) string {
+			if regionTagRegex.MatchString(tag) {
+				return ""
+			}
+			return tag
+		}))
+		b.WriteString(`[""]`)
+	}
+	return b.String()
+}
+
+// stripTags removes all cview tags from the text, leaving it as it's displayed.
+func stripTags(text string) string {
+	return cviewTagRegex.ReplaceAllStringFunc(text, func(tag string) string {
+		if m := escapedTagRegex.FindStringSubmatch(tag); m != nil {
+			return "[" + m[1] + m[2] + "]"
+		}
+		return ""
+	})
+}
+
+// selectedText returns the plain text of the lines from start to end of the
+// content, inclusive. Tags are stripped, as well as trailing spaces and any
+// indentation that all the lines share.
+func selectedText(content string, start, end int) string {
+	if start > end {
+		start, end = end, start
+	}
+	lines := strings.Split(content, "\n")
+	if start < 0 {
+		start = 0
+	}
+	if end >= len(lines) {
+		end = len(lines) - 1
+	}
+	if start > end {
+		return ""
+	}
+	lines = lines[start : end+1]
+
+	indent := -1
+	for i := range lines {
+		lines[i] = strings.TrimRight(stripTags(lines[i]), " \t\r")
+		if lines[i] == "" {
+			continue
+		}
+		n := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := range lines {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			}
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// textSelectLines returns the number of lines that can be selected on the page.
+func (t *tab) textSelectLines() int {
+	return strings.Count(t.page.Content, "\n") + 1
+}
+
+// applyTextSelect displays the page content with each line in a region,
+// and highlights the selected lines.
+// It should only be used when the page is in ModeTextSelect.
+//
+// applyBottomBar should be called after, as this func might set some bottomBar values.
+func (t *tab) applyTextSelect() {
+	t.view.SetText(selectContent(t.page.Content))
+	t.highlightTextSelect()
+}
+
+// highlightTextSelect highlights the lines between the start and end of the selection.
+func (t *tab) highlightTextSelect() {
+	start, end := t.selectStart, t.selectEnd
+	if start > end {
+		start, end = end, start
+	}
+	ids := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		ids = append(ids, selectRegionID(i))
+	}
+	t.view.Highlight(ids...)
+
+	if t.mode == tabModeDone {
+		// Page is not loading so bottomBar can change
+		t.barLabel = "[::b]Select: [::-]"
+		t.barText = "Lines " + strconv.Itoa(start+1) + "-" + strconv.Itoa(end+1) +
+			" - press Enter or y to copy, Esc to stop"
+	}
+}
+
+// startTextSelect starts selecting lines on the page, from the line at the
+// top of the screen.
+func (t *tab) startTextSelect() {
+	if t.page.Graphic || t.page.Content == "" {
+		Info("The current page has no text to select.")
+		return
+	}
+	if t.page.Mode == structs.ModeTextSelect {
+		return
+	}
+	t.saveScroll()
+	t.clearSelected()
+
+	row, _ := t.view.GetScrollOffset()
+	if row >= t.textSelectLines() {
+		row = t.textSelectLines() - 1
+	}
+	t.page.Mode = structs.ModeTextSelect
+	t.selectStart = row
+	t.selectEnd = row
+	t.applyTextSelect()
+	t.applyScroll()
+	t.applyBottomBar()
+}
+
+// moveTextSelect moves the end of the selection by delta lines,
+// and scrolls so the end can be seen.
+func (t *tab) moveTextSelect(delta int) {
+	t.selectEnd += delta
+	if t.selectEnd < 0 {
+		t.selectEnd = 0
+	}
+	if t.selectEnd >= t.textSelectLines() {
+		t.selectEnd = t.textSelectLines() - 1
+	}
+	t.highlightTextSelect()
+
+	row, col := t.view.GetScrollOffset()
+	_, _, _, height := t.view.GetInnerRect()
+	if t.selectEnd < row {
+		t.view.ScrollTo(t.selectEnd, col)
+	} else if height > 0 && t.selectEnd >= row+height {
+		t.view.ScrollTo(t.selectEnd-height+1, col)
+	}
+	t.saveScroll()
+	t.applyBottomBar()
+}
+
+// copyTextSelect copies the text of the selected lines to the clipboard,
+// and stops selecting.
+func (t *tab) copyTextSelect() {
+	text := selectedText(t.page.Content, t.selectStart, t.selectEnd)
+	lines := t.selectEnd - t.selectStart
+	if lines < 0 {
+		lines = -lines
+	}
+	lines++
+
+	t.saveScroll()
+	t.clearSelected()
+	t.applyScroll()
+	t.barLabel = securityLabel(t.page.Security)
+	t.barText = t.page.URL
+	t.applyBottomBar()
+
+	label := "[::b]Copied " + strconv.Itoa(lines) + " lines. [::-]"
+	if lines == 1 {
+		label = "[::b]Copied 1 line. [::-]"
+	}
+	go copyText(text, "text", label)
+}
@@ -0,0 +1,36 @@
+package display
+
+import "testing"
+
+func TestSelectContent(t *testing.T) {
+	content := "[red]one[-]\n[\"0\"]two[\"\"]"
+	want := "[\"v0\"][red]one[-][\"\"]\n[\"v1\"]two[\"\"]"
+	if got := selectContent(content); got != want {
+		t.Errorf("selectContent(%q) = %q, want %q", content, got, want)
+	}
+}
+
+var selectedTextTests = []struct {
+	start, end int
+	want       string
+}{
+	{0, 0, "Title\n"},
+	{1, 2, "[1] link\n    wrapped [tag]\n"},
+	{2, 1, "[1] link\n    wrapped [tag]\n"},
+	{3, 5, "indented\n\n  more\n"},
+	{4, 10, "\nmore\n"},
+}
+
+func TestSelectedText(t *testing.T) {
+	content := "[::b]Title[::-]  \n" +
+		`["0"][1] [#ff0000]link[-][""]` + "\n" +
+		"    wrapped [tag[]\n" +
+		"  indented\n" +
+		"\n" +
+		"    more"
+	for _, tt := range selectedTextTests {
+		if got := selectedText(content, tt.start, tt.end); got != tt.want {
+			t.Errorf("selectedText(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// Functions for saving and restoring the history of all tabs between runs.
+
+/*
+Example stored JSON.
+
+{
+  "tabs": [
+    {
+      "urls": ["about:newtab", "gemini://example.com/"],
+      "pos": 1
+    }
+  ],
+  "cur_tab": 0
+}
+*/
+
+type sessionTab struct {
+	URLs []string `json:"urls"`
+	Pos  int      `json:"pos"`
+}
+
+type sessionJSON struct {
+	Tabs   []*sessionTab `json:"tabs"`
+	CurTab int           `json:"cur_tab"`
+}
+
+// SaveSession writes the history of every tab to disk, if enabled
+// in the config. Incognito tabs aren't saved.
+func SaveSession() error {
+	if !viper.GetBool("a-general.restore_session") {
+		return nil
+	}
+
+	s := sessionJSON{
+		Tabs: make([]*sessionTab, 0, len(tabs)),
+	}
+	for i := range tabs {
+		if tabs[i].incognito {
+			// Not saved, and the tabs after it move left
+			continue
+		}
+		if i <= curTab {
+			s.CurTab = len(s.Tabs)
+		}
+		s.Tabs = append(s.Tabs, &sessionTab{
+			URLs: tabs[i].history.urls,
+			Pos:  tabs[i].history.pos,
+		})
+	}
+
+	jsonBytes, err := json.MarshalIndent(&s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.SessionPath, jsonBytes, 0666)
+}
+
+// RestoreSession reopens the tabs saved by SaveSession, if enabled in the config.
+// Only the current tab is loaded, the others are loaded when they are switched to.
+//
+// It returns false if no tabs were opened, and so NewTab should be used instead.
+// Any error returned should be displayed after a tab has been opened.
+func RestoreSession() (bool, error) {
+	if !viper.GetBool("a-general.restore_session") {
+		return false, nil
+	}
+
+	jsonBytes, err := ioutil.ReadFile(config.SessionPath)
+	if os.IsNotExist(err) {
+		// Nothing saved yet
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("read session.json error: %w", err)
+	}
+	var s sessionJSON
+	err = json.Unmarshal(jsonBytes, &s)
+	if err != nil {
+		return false, fmt.Errorf("session.json is corrupted: %w", err)
+	}
+
+	cur := 0
+	for i, st := range s.Tabs {
+		// Skip URLs that can't be displayed anymore, like if a proxy was removed,
+		// and keep the history position on the same URL when possible
+		urls := make([]string, 0, len(st.URLs))
+		pos := 0
+		for j, u := range st.URLs {
+			if !isDisplayable(u) {
+				continue
+			}
+			if j <= st.Pos {
+				pos = len(urls)
+			}
+			urls = append(urls, u)
+		}
+		if len(urls) == 0 {
+			continue
+		}
+		if i == s.CurTab {
+			cur = NumTabs()
+		}
+
+		t := makeNewTab()
+		t.history.urls = urls
+		t.history.pos = pos
+		t.page = &structs.Page{URL: urls[pos], Mode: structs.ModeOff}
+		t.barText = urls[pos]
+		t.mode = tabModeUnloaded
+		tabs = append(tabs, t)
+
+		browser.AddTab(
+			strconv.Itoa(NumTabs()-1),
+			tabLabel(NumTabs()-1),
+			makeContentLayout(t.contentView(), leftMargin()),
+		)
+	}
+
+	if NumTabs() == 0 {
+		return false, nil
+	}
+	SwitchTab(cur)
+	return true, nil
+}
+
+// Sessions can also be saved by name with the save-session command, and opened
+// again with open-session. These have the current URL and scroll position of
+// each tab instead of the whole history, and are stored in sessions.json,
+// keyed by name.
+
+type namedSessionTab struct {
+	URL    string `json:"url"`
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+}
+
+type namedSession struct {
+	Tabs   []namedSessionTab `json:"tabs"`
+	CurTab int               `json:"cur_tab"`
+}
+
+// readNamedSessions returns the sessions saved by name.
+func readNamedSessions() (map[string]*namedSession, error) {
+	sessions := make(map[string]*namedSession)
+	jsonBytes, err := ioutil.ReadFile(config.SessionsPath)
+	if os.IsNotExist(err) {
+		return sessions, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read sessions.json error: %w", err)
+	}
+	if err := json.Unmarshal(jsonBytes, &sessions); err != nil {
+		return nil, fmt.Errorf("sessions.json is corrupted: %w", err)
+	}
+	return sessions, nil
+}
+
+func writeNamedSessions(sessions map[string]*namedSession) error {
+	jsonBytes, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.SessionsPath, jsonBytes, 0666)
+}
+
+// sessionNames returns the names of the sessions, sorted.
+func sessionNames(sessions map[string]*namedSession) []string {
+	names := make([]string, 0, len(sessions))
+	for name := range sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// saveNamedSession saves the open tabs as a session with the name, replacing
+// any session with the same name. Incognito tabs aren't saved.
+func saveNamedSession(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		Error("Command Error", "Type a name for the session, like save-session work.")
+		return
+	}
+	sessions, err := readNamedSessions()
+	if err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+
+	s := &namedSession{Tabs: make([]namedSessionTab, 0, len(tabs))}
+	for i, t := range tabs {
+		if t.incognito {
+			continue
+		}
+		if i <= curTab {
+			s.CurTab = len(s.Tabs)
+		}
+		if t.mode == tabModeDone && t.hasContent() {
+			t.saveScroll()
+		}
+		s.Tabs = append(s.Tabs, namedSessionTab{
+			URL:    t.page.URL,
+			Row:    t.page.Row,
+			Column: t.page.Column,
+		})
+	}
+	if len(s.Tabs) == 0 {
+		Error("Session Error", "There are no tabs to save, incognito tabs aren't saved.")
+		return
+	}
+	sessions[name] = s
+	if err := writeNamedSessions(sessions); err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+	Info(fmt.Sprintf("Saved %d tabs as the session %s.", len(s.Tabs), cview.Escape(name)))
+}
+
+// sessionTabsToOpen returns the tabs of the session that can be opened, and
+// the index in them of the tab to switch to. That's the tab that was current
+// when it was saved, or the last one before it that can be opened, or else the
+// first one. The index is -1 if none of them can be opened.
+func sessionTabsToOpen(s *namedSession) ([]namedSessionTab, int) {
+	toOpen := make([]namedSessionTab, 0, len(s.Tabs))
+	cur := -1
+	for i, st := range s.Tabs {
+		if !isDisplayable(st.URL) {
+			continue
+		}
+		if i <= s.CurTab {
+			cur = len(toOpen)
+		}
+		toOpen = append(toOpen, st)
+	}
+	if cur == -1 && len(toOpen) > 0 {
+		cur = 0
+	}
+	return toOpen, cur
+}
+
+// openNamedSession opens the tabs of the session with the name after the open
+// tabs, and switches to the one that was current when it was saved. Each page
+// is scrolled to where it was once it loads.
+func openNamedSession(name string) {
+	name = strings.TrimSpace(name)
+	sessions, err := readNamedSessions()
+	if err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+	s, ok := sessions[name]
+	if !ok {
+		listSessions()
+		return
+	}
+
+	toOpen, cur := sessionTabsToOpen(s)
+	if cur == -1 {
+		Error("Session Error", "None of the pages in that session can be opened.")
+		return
+	}
+
+	first := NumTabs()
+	loads := make([]func(), 0, len(toOpen))
+	for _, st := range toOpen {
+		t := makeNewTab()
+		tabs = append(tabs, t)
+		browser.AddTab(
+			strconv.Itoa(NumTabs()-1),
+			tabLabel(NumTabs()-1),
+			makeContentLayout(t.contentView(), leftMargin()),
+		)
+		temp := newTabPage // Copy
+		setPage(t, &temp)
+		t.addToHistory("about:newtab")
+		t.history.pos = 0 // Manually set as first page
+
+		st := st
+		loads = append(loads, func() {
+			goURL(t, st.URL)
+			App.QueueUpdateDraw(func() {
+				if !isValidTab(t) || t.mode != tabModeDone || t.page.URL != st.URL {
+					return
+				}
+				t.page.Row = st.Row
+				t.page.Column = st.Column
+				t.applyScroll()
+			})
+		})
+	}
+	SwitchTab(first + cur)
+
+	// Loaded a few at a time, like when reloading all the tabs
+	loadInWorkers(loads)
+}
+
+// listSessions shows the names of the sessions saved by name.
+func listSessions() {
+	sessions, err := readNamedSessions()
+	if err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+	if len(sessions) == 0 {
+		Info("There are no saved sessions. Save the open tabs as one with save-session NAME.")
+		return
+	}
+	Info("Saved sessions: " + cview.Escape(strings.Join(sessionNames(sessions), ", ")) +
+		". Open one with open-session NAME, or delete one with sessions delete NAME.")
+}
+
+// sessionsCommand runs the sessions command with a value typed after it,
+// which can be "delete NAME".
+func sessionsCommand(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) < 2 || strings.ToLower(fields[0]) != "delete" {
+		Error("Command Error", "Type sessions to list the saved sessions, or sessions delete NAME to delete one.")
+		return
+	}
+	name := strings.TrimSpace(arg[len(fields[0]):])
+	sessions, err := readNamedSessions()
+	if err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+	if _, ok := sessions[name]; !ok {
+		listSessions()
+		return
+	}
+	delete(sessions, name)
+	if err := writeNamedSessions(sessions); err != nil {
+		Error("Session Error", err.Error())
+		return
+	}
+	Info("Deleted the session " + cview.Escape(name) + ".")
+}
@@ -0,0 +1,37 @@
+package display
+
+import (
+	"testing"
+)
+
+var sessionTabsToOpenTests = []struct {
+	urls   []string
+	curTab int
+	open   int // How many tabs are opened
+	cur    int
+}{
+	{[]string{"gemini://a/", "gemini://b/", "gemini://c/"}, 1, 3, 1},
+	{[]string{"gemini://a/", "gemini://b/", "gemini://c/"}, 2, 3, 2},
+	// The current tab can't be opened, so the one before it is used
+	{[]string{"gemini://a/", "gemini://b/", "mailto:c@example.com"}, 2, 2, 1},
+	{[]string{"gemini://a/", "mailto:b@example.com", "gemini://c/"}, 1, 2, 0},
+	// None before it can be opened either, so the first one is used
+	{[]string{"mailto:a@example.com", "gemini://b/", "gemini://c/"}, 0, 2, 0},
+	{[]string{"mailto:a@example.com", "mailto:b@example.com", "gemini://c/", "gemini://d/"}, 1, 2, 0},
+	{[]string{"mailto:a@example.com"}, 0, 0, -1},
+	{nil, 0, 0, -1},
+}
+
+func TestSessionTabsToOpen(t *testing.T) {
+	for _, tt := range sessionTabsToOpenTests {
+		s := &namedSession{CurTab: tt.curTab}
+		for _, u := range tt.urls {
+			s.Tabs = append(s.Tabs, namedSessionTab{URL: u})
+		}
+		toOpen, cur := sessionTabsToOpen(s)
+		if len(toOpen) != tt.open || cur != tt.cur {
+			t.Errorf("sessionTabsToOpen(%q, current %d) opens %d tabs and switches to %d, want %d and %d",
+				tt.urls, tt.curTab, len(toOpen), cur, tt.open, tt.cur)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package display
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Functions for waiting after a 44 SLOW DOWN response.
+
+// slowDown counts down the seconds a server asked to wait for in the tab's bottomBar,
+// then retries the URL if enabled in the config and the tab is still the current one.
+//
+// The countdown is stopped by cancelSlowDown, which handleURL calls for every
+// new request, so going to another page cancels the retry.
+func (t *tab) slowDown(u string, seconds int) {
+	t.cancelSlowDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.slowDownCancel = cancel
+	t.slowDownLabel = t.barLabel
+	t.slowDownText = t.barText
+	t.setSlowDownBottomBar(seconds)
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for seconds > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			seconds--
+			if seconds > 0 && ctx.Err() == nil {
+				t.setSlowDownBottomBar(seconds)
+				t.applySlowDownBottomBar()
+			}
+		}
+		if ctx.Err() != nil {
+			// Cancelled during the last second
+			return
+		}
+
+		retry := viper.GetBool("a-general.retry_slow_down") && isValidTab(t) && t == tabs[curTab]
+		t.cancelSlowDown()
+		if retry {
+			goURL(t, u)
+			return
+		}
+		t.applySlowDownBottomBar()
+	}()
+}
+
+// cancelSlowDown stops the 44 SLOW DOWN countdown if there is one,
+// and puts back the bottomBar values from before it started.
+// It does not apply the bottomBar values.
+func (t *tab) cancelSlowDown() {
+	if t.slowDownCancel == nil {
+		return
+	}
+	t.slowDownCancel()
+	t.slowDownCancel = nil
+	t.barLabel = t.slowDownLabel
+	t.barText = t.slowDownText
+}
+
+// setSlowDownBottomBar saves bottomBar values showing how many seconds are left.
+func (t *tab) setSlowDownBottomBar(seconds int) {
+	t.barLabel = "[::b]Slow down: [::-]"
+	if viper.GetBool("a-general.retry_slow_down") {
+		t.barText = "retrying in " + strconv.Itoa(seconds) + "s"
+	} else {
+		t.barText = "wait " + strconv.Itoa(seconds) + "s before retrying"
+	}
+}
+
+// applySlowDownBottomBar applies the bottomBar values of the tab if it's the
+// current one, unless the bottomBar is being typed in.
+func (t *tab) applySlowDownBottomBar() {
+	if !isValidTab(t) || t != tabs[curTab] || bottomBar.HasFocus() || t.mode != tabModeDone {
+		return
+	}
+	t.applyBottomBar()
+	App.Draw()
+}
@@ -0,0 +1,83 @@
+package display
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// handleSpartan is used by handleURL for spartan:// URLs, once the bottomBar
+// is showing that the page is loading. It returns the same values as handleURL.
+// The context stops loading the page, until it's displayed.
+func handleSpartan(ctx context.Context, t *tab, u string, numRedirects int) (string, bool) {
+	res, err := client.FetchSpartan(ctx, u)
+
+	// Loading may have taken a while, make sure tab is still valid
+	if !isValidTab(t) || ctx.Err() != nil {
+		return "", false
+	}
+	if err != nil {
+		fetchError(err)
+		return "", false
+	}
+
+	switch res.Status {
+	case 20:
+		return handlePlain(ctx, t, u, res)
+	case 30:
+		// The meta is an absolute path on the same server
+		parsed, _ := url.Parse(u)
+		parsedMeta, err := url.Parse(res.Meta)
+		if err != nil {
+			Error("Redirect Error", "Invalid URL: "+err.Error())
+			return "", false
+		}
+		redir := parsed.ResolveReference(parsedMeta).String()
+		if followRedirect(t, u, redir, numRedirects) {
+			t.redirects = append(t.redirects, u)
+			return handleURL(t, redir, numRedirects+1)
+		}
+		return "", false
+	case 40:
+		Error("Client Error", escapeMeta(res.Meta))
+		return "", false
+	case 50:
+		Error("Server Error", escapeMeta(res.Meta))
+		return "", false
+	}
+	// Shouldn't happen, other statuses are rejected by the client package
+	return "", false
+}
+
+// isPrompt returns true if the link is from a Spartan prompt line on the page.
+func isPrompt(p *structs.Page, link string) bool {
+	for _, prompt := range p.Prompts {
+		if prompt == link {
+			return true
+		}
+	}
+	return false
+}
+
+// spartanPrompt opens the multi-line input to type or paste the data for a
+// Spartan prompt line. The data is sent to the URL once it's submitted.
+//
+// It should be called in a goroutine.
+func spartanPrompt(u string) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		Error("URL Error", err.Error())
+		return
+	}
+	data, ok := MultilineInput("Input for " + u)
+	if !ok {
+		return
+	}
+	parsed.RawQuery = gemini.QueryEscape(data)
+	// Don't use the cached version of the response
+	tabs[curTab].pageCache().Remove(parsed.String())
+	URL(parsed.String())
+}
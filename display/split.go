@@ -0,0 +1,126 @@
+package display
+
+import (
+	"fmt"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// Functions for splitting the view into two panes, to see two tabs side by side.
+//
+// The focused pane is always the current tab, displayed by the browser as usual,
+// with the tab bar above it. The other pane displays splitTab. Switching to the
+// tab in the other pane moves the focus there, and the panes keep their places.
+//
+// When split, termW is the width of a pane, so pages are formatted to fit in it.
+
+// The tab displayed in the pane that isn't focused. It's nil when the view isn't split.
+var splitTab *tab
+
+// Whether the pane with splitTab is the one on the left.
+var splitTabFirst bool
+
+// The width of the whole terminal, which termW is half of when split.
+var screenW int
+
+// paneWidth returns the width that pages are formatted for, for the terminal width.
+func paneWidth(width int) int {
+	if splitTab != nil {
+		return width / 2
+	}
+	return width
+}
+
+// toggleSplit splits the view, with the current tab on the left and a new tab
+// on the right that is focused. If the view is already split, it goes back
+// to a single pane with the current tab.
+func toggleSplit() {
+	if splitTab != nil {
+		unsplit()
+		return
+	}
+	old := tabs[curTab]
+	NewTab()
+	splitTab = old
+	splitTabFirst = true
+	termW = paneWidth(screenW)
+	applySplit()
+	App.SetFocus(tabs[curTab].view)
+	loadNewTabURL()
+	go reformatTabs(tabs[curTab])
+}
+
+// unsplit goes back to a single pane, with the current tab.
+func unsplit() {
+	if splitTab == nil {
+		return
+	}
+	splitTab = nil
+	panels.AddPanel("browser", browser, true, true)
+	panels.SendToBack("browser")
+	App.SetFocus(tabs[curTab].view)
+	termW = paneWidth(screenW)
+	go reformatTabs(tabs[curTab])
+}
+
+// focusSplit moves the focus to the other pane, by switching to its tab.
+func focusSplit() {
+	if splitTab == nil {
+		return
+	}
+	SwitchTab(tabNumber(splitTab))
+}
+
+// swapSplit is used when switching tabs while split. If the tab being switched
+// to is in the other pane, the tab that was current is put there instead, so
+// that both are still displayed.
+func swapSplit(to *tab) {
+	if splitTab != nil && to == splitTab {
+		splitTab = tabs[curTab]
+		splitTabFirst = !splitTabFirst
+	}
+}
+
+// applySplit lays out the two panes again, so the other pane displays the
+// current content of splitTab. It does nothing if the view isn't split.
+func applySplit() {
+	if splitTab == nil {
+		return
+	}
+	i := tabNumber(splitTab)
+	if i == -1 {
+		// The tab was closed
+		unsplit()
+		return
+	}
+
+	label := cview.NewTextView()
+	label.SetDynamicColors(true)
+	label.SetWrap(false)
+	label.SetBackgroundColor(config.GetColor("bg"))
+	if viper.GetBool("a-general.color") {
+		label.SetText(fmt.Sprintf("[%s]%s[-]", config.GetColorString("tab_num"), tabLabel(i)))
+	} else {
+		label.SetText(tabLabel(i))
+	}
+
+	other := cview.NewFlex()
+	other.SetDirection(cview.FlexRow)
+	other.AddItem(label, 1, 0, false)
+	other.AddItem(makeContentLayout(splitTab.contentView(), leftMargin()), 0, 1, false)
+
+	// Holds both panes, in place of the browser
+	splitView := cview.NewFlex()
+	splitView.SetDirection(cview.FlexColumn)
+	if splitTabFirst {
+		splitView.AddItem(other, 0, 1, false)
+		splitView.AddItem(browser, 0, 1, true)
+	} else {
+		splitView.AddItem(browser, 0, 1, true)
+		splitView.AddItem(other, 0, 1, false)
+	}
+	panels.AddPanel("browser", splitView, true, true)
+	panels.SendToBack("browser")
+}
@@ -0,0 +1,379 @@
+package display
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/rr"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Streaming text pages, for servers that keep the connection open and keep
+// sending, like for logs. A text page that hasn't finished loading after
+// streamInterval is displayed with what has arrived so far, and is rendered
+// again with what's new every streamInterval, until it ends or the user stops
+// following it. Pages that finish before that are loaded like any other.
+
+// How often a streaming page is rendered again with what has arrived.
+const streamInterval = 500 * time.Millisecond
+
+// How much is read from the connection at once.
+const streamChunkSize = 32 * 1024
+
+type streamChunk struct {
+	data []byte
+	err  error // Set for the last chunk, io.EOF if the response ended
+}
+
+// Labels for the bottomBar while a page is streaming, so it's clear it isn't
+// timed out, and when the oldest lines have been removed for stream_max_size.
+const (
+	streamLabel        = "[::b]Streaming, no time limit: [::-]"
+	streamTrimmedLabel = "[::b]Streaming, start removed: [::-]"
+)
+
+// readChunks sends what is read from r to the channel, until there's an
+// error reading, or done is closed.
+func readChunks(r io.Reader, ch chan<- streamChunk, done <-chan struct{}) {
+	for {
+		buf := make([]byte, streamChunkSize)
+		n, err := r.Read(buf)
+		if n > 0 {
+			select {
+			case ch <- streamChunk{data: buf[:n]}:
+			case <-done:
+				return
+			}
+		}
+		if err != nil {
+			select {
+			case ch <- streamChunk{err: err}:
+			case <-done:
+			}
+			return
+		}
+	}
+}
+
+// chunkReader reads the data that was read before it was made, and then the
+// chunks from readChunks. It's for reading a response from the start again
+// while readChunks is still reading it.
+type chunkReader struct {
+	buf    []byte
+	chunks <-chan streamChunk
+	done   chan struct{} // The one readChunks was given
+	body   io.Closer
+	err    error
+	once   sync.Once
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		c := <-r.chunks
+		if c.err != nil {
+			r.err = c.err
+			return 0, c.err
+		}
+		r.buf = c.data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.once.Do(func() { close(r.done) })
+	return r.body.Close()
+}
+
+// trimStream removes whole lines from the start of the text until it's no
+// longer than limit bytes, so a stream that doesn't end uses a limited amount of memory.
+func trimStream(text string, limit int) string {
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	cut := len(text) - limit
+	if text[cut-1] != '\n' {
+		// Don't start partway through a line
+		if i := strings.IndexByte(text[cut:], '\n'); i >= 0 {
+			cut += i + 1
+		}
+	}
+	return text[cut:]
+}
+
+// handleStream loads a response that renderer.CanStream is true for.
+// If the response ends quickly it's displayed like any other page, otherwise
+// it's displayed as it arrives, and the rest is read in the background after
+// this returns. It returns false if nothing was displayed.
+//
+// The context stops loading the response, until it's displayed.
+func handleStream(ctx context.Context, t *tab, u string, res *client.Response, proxied bool, security structs.Security) bool {
+	// Only readChunks reads the body from now on, and what it has read is kept
+	// in b, so the body doesn't need to be restarted
+	res.Body.(*rr.RestartReader).StopBuffering()
+	done := make(chan struct{})
+	chunks := make(chan streamChunk)
+	go readChunks(res.Body, chunks, done)
+
+	stopWatching := closeOnCancel(ctx, res.Body)
+	defer stopWatching()
+
+	maxSize := viper.GetInt("a-general.page_max_size")
+	timer := time.NewTimer(streamInterval)
+	defer timer.Stop()
+
+	var b strings.Builder
+	for {
+		select {
+		case c := <-chunks:
+			if !isValidTab(t) || ctx.Err() != nil {
+				close(done)
+				res.Body.Close()
+				return false
+			}
+			if c.err == nil {
+				b.Write(c.data)
+				if b.Len() <= maxSize {
+					continue
+				}
+				// Downloading now, from the start
+				res.SetReadTimeout(0) //nolint: errcheck
+				downloadStream("That page is too large. What would you like to do?", u, res, b.String(), chunks, done)
+				return false
+			}
+			// readChunks has stopped reading
+			close(done)
+			if c.err != io.EOF {
+				if os.IsTimeout(c.err) {
+					// Downloading now, from the start
+					res.SetReadTimeout(0) //nolint: errcheck
+					done = make(chan struct{})
+					chunks = make(chan streamChunk)
+					go readChunks(res.Body, chunks, done)
+					downloadStream("Loading that page timed out. What would you like to do?", u, res, b.String(), chunks, done)
+					return false
+				}
+				res.Body.Close()
+				Error("Page Error", "Issuing creating page: "+c.err.Error())
+				return false
+			}
+			// The whole page arrived, so it's a regular one
+			res.Body.Close()
+			page, err := streamPage(u, res, b.String(), proxied, security)
+			if err != nil {
+				Error("Page Error", "Issuing creating page: "+err.Error())
+				return false
+			}
+			go cacheStreamPage(t, page)
+			setPage(t, page)
+			return true
+
+		case <-timer.C:
+			if !viper.GetBool("a-general.stream_text") || b.Len() == 0 {
+				// Keep waiting for the whole page, or for it to start
+				timer.Reset(streamInterval)
+				continue
+			}
+			if !isValidTab(t) || ctx.Err() != nil {
+				close(done)
+				res.Body.Close()
+				return false
+			}
+			page, err := streamPage(u, res, b.String(), proxied, security)
+			if err != nil {
+				close(done)
+				res.Body.Close()
+				Error("Page Error", "Issuing creating page: "+err.Error())
+				return false
+			}
+			setPage(t, page)
+			t.barLabel = streamLabel
+
+			// It could last forever, so it isn't timed out
+			res.SetReadTimeout(0) //nolint: errcheck
+
+			streamCtx, cancel := context.WithCancel(context.Background())
+			t.streamCancel = cancel
+			go followStream(streamCtx, t, page, u, res, b.String(), chunks, done, proxied)
+			return true
+		}
+	}
+}
+
+// downloadStream offers to download the response, once text has been read
+// from it and readChunks is reading the rest. It's downloaded from the start.
+func downloadStream(text, u string, res *client.Response, read string, chunks <-chan streamChunk, done chan struct{}) {
+	res.Body = &chunkReader{buf: []byte(read), chunks: chunks, done: done, body: res.Body}
+	go dlChoice(text, u, res.Response)
+}
+
+// streamPage returns the page for the text of a streamed response.
+func streamPage(u string, res *client.Response, text string, proxied bool, security structs.Security) (*structs.Page, error) {
+	page, err := renderer.MakeStreamPage(u, res.Response, text, textWidth(), proxied)
+	if err != nil {
+		return nil, err
+	}
+	page.Security = security
+	page.TermWidth = termW
+	page.LeftMargin = leftMargin()
+	return page, nil
+}
+
+// cacheStreamPage caches the page of a stream that has ended.
+func cacheStreamPage(t *tab, page *structs.Page) {
+	if parsed, _ := url.Parse(page.URL); !client.HasClientCert(parsed.Host) && !t.incognito {
+		// Don't cache pages with client certs, or pages in incognito tabs
+		t.pageCache().Set(page)
+	}
+}
+
+// followStream renders the streaming page again with what arrives, until the
+// stream ends, the context is cancelled, or the tab leaves the page.
+func followStream(ctx context.Context, t *tab, p *structs.Page, u string, res *client.Response,
+	text string, chunks <-chan streamChunk, done chan struct{}, proxied bool) {
+
+	stopWatching := closeOnCancel(ctx, res.Body)
+	defer stopWatching()
+	defer close(done)
+	defer res.Body.Close()
+
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+
+	var b strings.Builder
+	b.WriteString(text)
+	changed := false
+	trimmed := false
+	for {
+		select {
+		case c := <-chunks:
+			if c.err == nil {
+				b.Write(c.data)
+				changed = true
+				if limit := viper.GetInt("a-general.stream_max_size"); b.Len() > limit {
+					kept := trimStream(b.String(), limit)
+					b.Reset()
+					b.WriteString(kept)
+					if !trimmed {
+						trimmed = true
+						streamTrimmed(t, p)
+					}
+				}
+				continue
+			}
+			// The stream ended, or couldn't be read anymore
+			updateStream(t, p, u, res, b.String(), proxied, false)
+			if c.err == io.EOF && !trimmed {
+				// It's all there, so it can be loaded again from the cache,
+				// like a page that ended before it was streamed
+				if page, err := streamPage(u, res, b.String(), proxied, p.Security); err == nil {
+					cacheStreamPage(t, page)
+				}
+			}
+			return
+		case <-ticker.C:
+			if !isValidTab(t) || t.page != p {
+				// Left the page, so it doesn't need to be followed
+				return
+			}
+			if changed {
+				updateStream(t, p, u, res, b.String(), proxied, true)
+				changed = false
+			}
+		case <-ctx.Done():
+			updateStream(t, p, u, res, b.String(), proxied, false)
+			return
+		}
+	}
+}
+
+// streamTrimmed changes the bottomBar label of the streaming page, once its
+// oldest lines have been removed.
+func streamTrimmed(t *tab, p *structs.Page) {
+	App.QueueUpdateDraw(func() {
+		if !isValidTab(t) || t.page != p || t.streamCancel == nil {
+			return
+		}
+		t.barLabel = streamTrimmedLabel
+		if t == tabs[curTab] && !bottomBar.HasFocus() {
+			t.applyBottomBar()
+		}
+	})
+}
+
+// updateStream sets the page to the text of the stream so far, and displays
+// it if the tab is still on that page. The view follows new content if it was
+// scrolled to the end. streaming is whether more of it might arrive.
+func updateStream(t *tab, p *structs.Page, u string, res *client.Response, text string, proxied, streaming bool) {
+	App.QueueUpdateDraw(func() {
+		if !isValidTab(t) || t.page != p {
+			return
+		}
+		if !streaming {
+			t.streamCancel = nil
+			t.barLabel = securityLabel(p.Security)
+			t.barText = p.URL
+			if t == tabs[curTab] && !bottomBar.HasFocus() {
+				t.applyBottomBar()
+			}
+		}
+		if p.Raw == text && isFormatted(p) {
+			return
+		}
+
+		updated, err := renderer.MakeStreamPage(u, res.Response, text, textWidth(), proxied)
+		if err != nil {
+			return
+		}
+		p.Raw = updated.Raw
+		p.Links = updated.Links
+		p.Prompts = updated.Prompts
+		p.MaxPreCols = updated.MaxPreCols
+		if p.Mode == structs.ModeTextSelect {
+			// The selection would be changed, it's displayed once it's done with
+			p.TermWidth = -1
+			return
+		}
+
+		row, col := t.view.GetScrollOffset()
+		_, _, _, height := t.view.GetInnerRect()
+		atEnd := row+height >= strings.Count(p.Content, "\n")+1
+
+		p.TermWidth = -1 // Rendered again for the new text
+		reformatPage(p)
+		t.view.SetText(p.Content)
+		if p.Mode == structs.ModeSearch {
+			t.applySearch()
+		} else if p.Mode == structs.ModeLinkSelect {
+			t.view.Highlight(p.SelectedID)
+		}
+		if atEnd {
+			t.view.ScrollToEnd()
+		} else {
+			t.view.ScrollTo(row, col)
+		}
+	})
+}
+
+// stopStream stops following the page the tab is streaming, if it is,
+// leaving what has arrived displayed. It returns false if there was nothing to stop.
+func (t *tab) stopStream() bool {
+	if t.streamCancel == nil {
+		return false
+	}
+	t.streamCancel()
+	t.streamCancel = nil
+	return true
+}
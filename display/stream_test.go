@@ -0,0 +1,107 @@
+package display
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+var trimStreamTests = []struct {
+	text  string
+	limit int
+	want  string
+}{
+	{"one\ntwo\nthree\n", 100, "one\ntwo\nthree\n"},
+	{"one\ntwo\nthree\n", 10, "two\nthree\n"},
+	{"one\ntwo\nthree\n", 9, "three\n"},
+	{"one\ntwo\nthree\n", 8, "three\n"},
+	{"one\ntwo\nthree\n", 6, "three\n"},
+	{"one\ntwo\nthree\n", 5, ""},
+	{"no newlines", 3, "nes"},
+	{"anything", 0, "anything"},
+}
+
+func TestTrimStream(t *testing.T) {
+	for _, tt := range trimStreamTests {
+		if got := trimStream(tt.text, tt.limit); got != tt.want {
+			t.Errorf("trimStream(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	done := make(chan struct{})
+	chunks := make(chan streamChunk)
+	go readChunks(strings.NewReader(" world"), chunks, done)
+
+	r := &chunkReader{buf: []byte("hello"), chunks: chunks, done: done, body: ioutil.NopCloser(nil)}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello world" {
+		t.Errorf("read %q, want %q", b, "hello world")
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read after the end returned %v, want io.EOF", err)
+	}
+	r.Close()
+}
+
+// streamTab returns a tab with its own cache, and the chunks of a response
+// with the rest of a slow page, for followStream.
+func streamTab(rest string) (*tab, fakeCache, *client.Response, chan streamChunk, chan struct{}) {
+	pages := fakeCache{}
+	tb := &tab{pages: pages}
+	res := &client.Response{Response: &gemini.Response{
+		Status: 20,
+		Meta:   "text/gemini",
+		Body:   ioutil.NopCloser(strings.NewReader(rest)),
+	}}
+	done := make(chan struct{})
+	chunks := make(chan streamChunk)
+	go readChunks(res.Body, chunks, done)
+	return tb, pages, res, chunks, done
+}
+
+func TestFollowStreamCaches(t *testing.T) {
+	defer viper.Set("a-general.stream_max_size", nil)
+	viper.Set("a-general.stream_max_size", 1024)
+	u := "gemini://example.com/slow"
+
+	// The start was displayed, and the rest arrived after that
+	tb, pages, res, chunks, done := streamTab("# More\n")
+	p := &structs.Page{URL: u, Raw: "# Start\n"}
+	followStream(context.Background(), tb, p, u, res, "# Start\n", chunks, done, false)
+	page, ok := pages[u]
+	if !ok {
+		t.Fatal("the page that ended wasn't cached")
+	}
+	if page.Raw != "# Start\n# More\n" {
+		t.Errorf("the cached page is %q, want all of it", page.Raw)
+	}
+
+	// Not cached once the start has been removed
+	viper.Set("a-general.stream_max_size", 10)
+	tb, pages, res, chunks, done = streamTab("# More\n")
+	followStream(context.Background(), tb, p, u, res, "# Start\n", chunks, done, false)
+	if _, ok := pages[u]; ok {
+		t.Error("the page was cached without its start")
+	}
+
+	// Or if it's in an incognito tab
+	viper.Set("a-general.stream_max_size", 1024)
+	tb, pages, res, chunks, done = streamTab("# More\n")
+	tb.incognito = true
+	followStream(context.Background(), tb, p, u, res, "# Start\n", chunks, done, false)
+	if _, ok := pages[u]; ok {
+		t.Error("the page was cached in an incognito tab")
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
@@ -64,7 +63,7 @@ func Subscriptions(t *tab, u string) string {
 	u = correctURL(u)
 
 	// Retrieve cached version if there hasn't been any updates
-	p, ok := cache.GetPage(u)
+	p, ok := t.pageCache().Get(u)
 	if subscriptionPageUpdated[pageN].After(subscriptions.LastUpdated) && ok {
 		setPage(t, p)
 		t.applyBottomBar()
@@ -97,7 +96,7 @@ func Subscriptions(t *tab, u string) string {
 	} else {
 		// Render page
 
-		rawPage += "You can use Ctrl-X to subscribe to a page, or to an Atom/RSS/JSON feed. See the online wiki for more.\n" +
+		rawPage += "You can use Ctrl-X to subscribe to a page, or to an Atom/RSS/JSON feed or gemfeed. See the online wiki for more.\n" +
 			"If you just opened Amfora then updates may appear incrementally. Reload the page to see them.\n\n" +
 			"=> about:manage-subscriptions Manage subscriptions\n\n"
 
@@ -151,14 +150,15 @@ func Subscriptions(t *tab, u string) string {
 
 	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
 	page := structs.Page{
-		Raw:       rawPage,
-		Content:   content,
-		Links:     links,
-		URL:       u,
-		TermWidth: termW,
-		Mediatype: structs.TextGemini,
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        u,
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
 	}
-	go cache.AddPage(&page)
+	go t.pageCache().Set(&page)
 	setPage(t, &page)
 	t.applyBottomBar()
 
@@ -193,14 +193,15 @@ func ManageSubscriptions(t *tab, u string) {
 
 	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
 	page := structs.Page{
-		Raw:       rawPage,
-		Content:   content,
-		Links:     links,
-		URL:       "about:manage-subscriptions",
-		TermWidth: termW,
-		Mediatype: structs.TextGemini,
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        "about:manage-subscriptions",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
 	}
-	go cache.AddPage(&page)
+	go t.pageCache().Set(&page)
 	setPage(t, &page)
 	t.applyBottomBar()
 }
@@ -273,7 +274,11 @@ func openSubscriptionModal(validFeed, subscribed bool) bool {
 }
 
 // getFeedFromPage is like subscriptions.GetFeed but takes a structs.Page as input.
+// Gemtext pages are checked for being gemfeeds instead.
 func getFeedFromPage(p *structs.Page) (*gofeed.Feed, bool) {
+	if p.Mediatype == structs.TextGemini {
+		return subscriptions.GetGemfeed(p.URL, strings.NewReader(p.Raw))
+	}
 	parsed, _ := url.Parse(p.URL)
 	filename := path.Base(parsed.Path)
 	r := strings.NewReader(p.Raw)
@@ -1,12 +1,17 @@
 package display
 
 import (
+	"context"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
 	"gitlab.com/tslocum/cview"
 )
 
@@ -15,21 +20,69 @@ type tabMode int
 const (
 	tabModeDone tabMode = iota
 	tabModeLoading
+	tabModeUnloaded // Restored from a session, the page is loaded when the tab is switched to
 )
 
+// The number of columns scrolled horizontally with each key press.
+const horizontalScrollCols = 4
+
 type tabHistory struct {
-	urls []string
-	pos  int // Position: where in the list of URLs we are
+	urls   []string
+	pos    int         // Position: where in the list of URLs we are
+	states []histState // Saved when leaving a page, can be shorter than urls
 }
 
 // tab hold the information needed for each browser tab.
 type tab struct {
 	page     *structs.Page
 	view     *cview.TextView
+	image    *imageView // Used instead of the view for image pages
 	history  *tabHistory
 	mode     tabMode
 	barLabel string // The bottomBar label for the tab
 	barText  string // The bottomBar text for the tab
+
+	searchMatches int // The number of matches on the page, when it is being searched
+	selectStart   int // The line where the text selection started, when selecting text
+	selectEnd     int // The line where the text selection ends, which moves
+
+	linkNumber   string    // The digits typed so far to select a link, in link select mode
+	linkNumberAt time.Time // When the last digit was typed
+
+	slowDownCancel context.CancelFunc // Cancels the 44 SLOW DOWN countdown, if there is one
+	slowDownLabel  string             // The bottomBar label from before the countdown
+	slowDownText   string             // The bottomBar text from before the countdown
+
+	redirects       []string // The URLs the page being loaded was redirected through, in order
+	fragment        string   // The URL fragment the page was opened with, which is scrolled to again on reload
+	identityRetried bool     // The page being loaded is requested again with the identity just chosen
+
+	loadCancel    context.CancelFunc // Stops the request that's loading, if it can be stopped
+	streamCancel  context.CancelFunc // Stops following the page that's streaming, if there is one
+	previewCancel context.CancelFunc // Stops loading the preview of the selected link
+
+	title string // The title set by the user for the tab bar, used instead of the page's title
+
+	autoReloadCancel context.CancelFunc // Stops reloading the page over and over, if it's being reloaded
+	autoReloadURL    string             // The URL of the page being reloaded
+
+	// Pages loaded in an incognito tab aren't cached, and the URLs and inputs
+	// aren't kept anywhere outside of the tab's own history. That history is
+	// not saved in the session, and is discarded when the tab is closed.
+	incognito bool
+
+	pages cache.Cache // Where the pages loaded in the tab are cached, see pageCache
+
+	unseen bool // A page was loaded while the tab was in the background, and it hasn't been switched to since
+}
+
+// pageCache returns the cache for the pages loaded in the tab, which is
+// cache.Pages unless the tab was given another one, like a fake in tests.
+func (t *tab) pageCache() cache.Cache {
+	if t.pages == nil {
+		return cache.Pages
+	}
+	return t.pages
 }
 
 // makeNewTab initializes an tab struct with no content.
@@ -37,8 +90,10 @@ func makeNewTab() *tab {
 	t := tab{
 		page:    &structs.Page{Mode: structs.ModeOff},
 		view:    cview.NewTextView(),
+		image:   newImageView(),
 		history: &tabHistory{},
 		mode:    tabModeDone,
+		pages:   cache.Pages,
 	}
 	t.view.SetDynamicColors(true)
 	t.view.SetRegions(true)
@@ -62,18 +117,23 @@ func makeNewTab() *tab {
 
 		if key == tcell.KeyEsc {
 			// Stop highlighting
-			bottomBar.SetLabel("")
+			bottomBar.SetLabel(securityLabel(tabs[tab].page.Security))
 			bottomBar.SetText(tabs[tab].page.URL)
 			tabs[tab].clearSelected()
 			tabs[tab].saveBottomBar()
 			return
 		}
 
-		if len(tabs[tab].page.Links) == 0 {
-			// No links on page
+		if len(tabs[tab].page.Links) == 0 || tabs[tab].page.Source {
+			// No links on page, or the source is shown so there are no link regions
 			return
 		}
 
+		if tabs[tab].page.Mode == structs.ModeSearch || tabs[tab].page.Mode == structs.ModeTextSelect {
+			// Stop searching or selecting, so link highlighting starts from the beginning
+			tabs[tab].clearSelected()
+		}
+
 		currentSelection := tabs[tab].view.GetHighlights()
 		numSelections := len(tabs[tab].page.Links)
 
@@ -98,6 +158,7 @@ func makeNewTab() *tab {
 			tabs[tab].saveBottomBar()
 			tabs[tab].page.Selected = tabs[tab].page.Links[0]
 			tabs[tab].page.SelectedID = "0"
+			tabs[tab].previewLink()
 		}
 
 		if len(currentSelection) > 0 {
@@ -111,6 +172,7 @@ func makeNewTab() *tab {
 			} else {
 				return
 			}
+			tabs[tab].linkNumber = "" // Typing a number starts over
 			tabs[tab].view.Highlight(strconv.Itoa(index))
 			tabs[tab].view.ScrollToHighlight()
 			// Display link URL in bottomBar
@@ -119,6 +181,7 @@ func makeNewTab() *tab {
 			tabs[tab].saveBottomBar()
 			tabs[tab].page.Selected = tabs[tab].page.Links[index]
 			tabs[tab].page.SelectedID = strconv.Itoa(index)
+			tabs[tab].previewLink()
 		}
 	})
 	t.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -126,46 +189,42 @@ func makeNewTab() *tab {
 		// Up/down scrolling is saved in this func to keep them in sync, but the keys
 		// are passed and no extra behaviour happens.
 
+		if t.page.Graphic {
+			// Images can't be scrolled
+			return nil
+		}
+
 		key := event.Key()
 		mod := event.Modifiers()
 		ru := event.Rune()
 
-		width, height := t.view.TextDimensions()
-		_, _, boxW, boxH := t.view.GetInnerRect()
-
-		// Make boxW accurate by subtracting one if a scrollbar is covering the last
-		// column of text
-		if config.ScrollBar == cview.ScrollBarAlways ||
-			(config.ScrollBar == cview.ScrollBarAuto && height > boxH) {
-			boxW--
+		if t.page.Mode == structs.ModeTextSelect && mod == tcell.ModNone {
+			// Up and down move the end of the selection instead of scrolling
+			switch {
+			case key == tcell.KeyUp || (key == tcell.KeyRune && ru == 'k'):
+				t.moveTextSelect(-1)
+				return nil
+			case key == tcell.KeyDown || (key == tcell.KeyRune && ru == 'j'):
+				t.moveTextSelect(1)
+				return nil
+			case key == tcell.KeyEnter || (key == tcell.KeyRune && ru == 'y'):
+				t.copyTextSelect()
+				return nil
+			}
 		}
 
+		_, height := t.view.TextDimensions()
+
 		if (key == tcell.KeyRight && mod == tcell.ModNone) ||
 			(key == tcell.KeyRune && mod == tcell.ModNone && ru == 'l') {
 			// Scrolling to the right
-
-			if t.page.Column >= leftMargin() {
-				// Scrolled right far enought that no left margin is needed
-				if (t.page.Column-leftMargin())+boxW >= width {
-					// And scrolled as far as possible to the right
-					return nil
-				}
-			} else {
-				// Left margin still exists
-				if boxW-(leftMargin()-t.page.Column) >= width {
-					// But still scrolled as far as possible
-					return nil
-				}
-			}
-			t.page.Column++
+			t.scrollRight()
+			return nil
 		} else if (key == tcell.KeyLeft && mod == tcell.ModNone) ||
 			(key == tcell.KeyRune && mod == tcell.ModNone && ru == 'h') {
 			// Scrolling to the left
-			if t.page.Column == 0 {
-				// Can't scroll to the left anymore
-				return nil
-			}
-			t.page.Column--
+			t.scrollLeft()
+			return nil
 		} else if (key == tcell.KeyUp && mod == tcell.ModNone) ||
 			(key == tcell.KeyRune && mod == tcell.ModNone && ru == 'k') {
 			// Scrolling up
@@ -180,14 +239,9 @@ func makeNewTab() *tab {
 				t.page.Row++
 			}
 			return event
-		} else {
-			// Some other key, stop processing it
-			return event
 		}
-
-		t.applyHorizontalScroll()
-		App.Draw()
-		return nil
+		// Some other key, stop processing it
+		return event
 	})
 
 	return &t
@@ -195,26 +249,111 @@ func makeNewTab() *tab {
 
 // addToHistory adds the given URL to history.
 // It assumes the URL is currently being loaded and displayed on the page.
+// Nothing is added if it's the URL the history is at already, like after a reload,
+// or one for the same page, see urlKey.
 func (t *tab) addToHistory(u string) {
+	if t.history.pos < len(t.history.urls) && urlKey(t.history.urls[t.history.pos]) == urlKey(u) {
+		// The page was reloaded, or loaded again from itself,
+		// so there's no new entry to go back from
+		return
+	}
 	if t.history.pos < len(t.history.urls)-1 {
 		// We're somewhere in the middle of the history instead, with URLs ahead and behind.
 		// The URLs ahead need to be removed so this new URL is the most recent item in the history
 		t.history.urls = t.history.urls[:t.history.pos+1]
+		if len(t.history.states) > t.history.pos+1 {
+			t.history.states = t.history.states[:t.history.pos+1]
+		}
 	}
 	t.history.urls = append(t.history.urls, u)
 	t.history.pos++
+	if !t.incognito {
+		addVisitedURL(u)
+	}
+}
+
+// pageScrollRows returns the number of rows to scroll for pageUp and pageDown,
+// which is a percentage of the height of the terminal set in the config.
+// It is always at least one row.
+func pageScrollRows() int {
+	rows := termH * viper.GetInt("a-general.scroll_percentage") / 100
+	if rows < 1 {
+		return 1
+	}
+	return rows
 }
 
-// pageUp scrolls up 75% of the height of the terminal, like Bombadillo.
+// pageUp scrolls up a percentage of the height of the terminal,
+// 75% by default like Bombadillo.
 func (t *tab) pageUp() {
+	if t.page.Graphic {
+		return
+	}
 	row, col := t.view.GetScrollOffset()
-	t.view.ScrollTo(row-(termH/4)*3, col)
+	t.view.ScrollTo(row-pageScrollRows(), col)
 }
 
-// pageDown scrolls down 75% of the height of the terminal, like Bombadillo.
+// pageDown scrolls down a percentage of the height of the terminal,
+// 75% by default like Bombadillo.
 func (t *tab) pageDown() {
+	if t.page.Graphic {
+		return
+	}
 	row, col := t.view.GetScrollOffset()
-	t.view.ScrollTo(row+(termH/4)*3, col)
+	t.view.ScrollTo(row+pageScrollRows(), col)
+}
+
+// scrollRight scrolls the page to the right by horizontalScrollCols columns.
+// It won't scroll past the end of the longest preformatted line.
+func (t *tab) scrollRight() {
+	_, height := t.view.TextDimensions()
+	_, _, boxW, boxH := t.view.GetInnerRect()
+
+	// Make boxW accurate by subtracting one if a scrollbar is covering the last
+	// column of text
+	if config.ScrollBar == cview.ScrollBarAlways ||
+		(config.ScrollBar == cview.ScrollBarAuto && height > boxH) {
+		boxW--
+	}
+
+	// The furthest right the page can be scrolled, while still showing
+	// the end of the longest preformatted line. This includes the left margin,
+	// because the margin shrinks before the text itself is scrolled.
+	maxColumn := t.page.MaxPreCols + leftMargin() - boxW
+	if t.page.Column >= maxColumn {
+		// Already scrolled as far as possible to the right
+		return
+	}
+	t.page.Column += horizontalScrollCols
+	if t.page.Column > maxColumn {
+		t.page.Column = maxColumn
+	}
+	t.applyHorizontalScroll()
+	App.Draw()
+}
+
+// scrollLeft scrolls the page to the left by horizontalScrollCols columns.
+func (t *tab) scrollLeft() {
+	if t.page.Column == 0 {
+		// Can't scroll to the left anymore
+		return
+	}
+	t.page.Column -= horizontalScrollCols
+	if t.page.Column < 0 {
+		t.page.Column = 0
+	}
+	t.applyHorizontalScroll()
+	App.Draw()
+}
+
+// setTitle sets the title shown in the tab bar for the tab, which is kept
+// when going to other pages in the tab. An empty title clears it, so the
+// title of the page is shown again.
+func (t *tab) setTitle(title string) {
+	t.title = strings.TrimSpace(title)
+	if i := tabNumber(t); i != -1 {
+		browser.SetTabLabel(strconv.Itoa(i), tabLabel(i))
+	}
 }
 
 // hasContent returns false when the tab's page is malformed,
@@ -235,6 +374,15 @@ func (t *tab) hasContent() bool {
 	return true
 }
 
+// contentView returns what displays the page of the tab: the image view
+// for images, and the TextView for everything else.
+func (t *tab) contentView() cview.Primitive {
+	if t.page.Graphic {
+		return t.image
+	}
+	return t.view
+}
+
 // applyHorizontalScroll handles horizontal scroll logic including left margin resizing,
 // see #197 for details. Use applyScroll instead.
 //
@@ -249,20 +397,27 @@ func (t *tab) applyHorizontalScroll() {
 		// Scrolled to the right far enough that no left margin is needed
 		browser.AddTab(
 			strconv.Itoa(i),
-			makeTabLabel(strconv.Itoa(i+1)),
-			makeContentLayout(t.view, 0),
+			tabLabel(i),
+			makeContentLayout(t.contentView(), 0),
 		)
 		t.view.ScrollTo(t.page.Row, t.page.Column-leftMargin())
 	} else {
 		// Left margin is still needed, but is not necessarily at the right size by default
 		browser.AddTab(
 			strconv.Itoa(i),
-			makeTabLabel(strconv.Itoa(i+1)),
-			makeContentLayout(t.view, leftMargin()-t.page.Column),
+			tabLabel(i),
+			makeContentLayout(t.contentView(), leftMargin()-t.page.Column),
 		)
 	}
 }
 
+// saveScroll saves the vertical scroll position of the page, so it can be
+// restored with applyScroll. The horizontal one is always kept up to date.
+func (t *tab) saveScroll() {
+	row, _ := t.view.GetScrollOffset()
+	t.page.Row = row
+}
+
 // applyScroll applies the saved scroll values to the page and tab.
 // It should only be used when going backward and forward.
 func (t *tab) applyScroll() {
@@ -285,10 +440,177 @@ func (t *tab) applyBottomBar() {
 // clearSelected turns off any selection that was going on.
 // It does not affect the bottomBar.
 func (t *tab) clearSelected() {
+	if t.page.Mode == structs.ModeSearch || t.page.Mode == structs.ModeTextSelect {
+		// Remove the regions around search matches or lines
+		t.view.SetText(t.page.Content)
+	}
 	t.page.Mode = structs.ModeOff
 	t.page.Selected = ""
 	t.page.SelectedID = ""
 	t.view.Highlight("")
+	t.linkNumber = ""
+}
+
+// linkNumberTimeout is how long after the last digit typed a new link number is started.
+const linkNumberTimeout = time.Second
+
+// nextLinkNumber returns the link number typed so far after the digit is typed,
+// and the number of the link to select, one-indexed. The digit starts a new
+// number if the result would be out of range. Zero is returned for the link
+// if the number is invalid even then, and the typed number doesn't change.
+func nextLinkNumber(typed string, digit rune, numLinks int) (string, int) {
+	for _, s := range []string{typed + string(digit), string(digit)} {
+		if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= numLinks {
+			return s, n
+		}
+	}
+	return typed, 0
+}
+
+// typeLinkNumber selects a link using its number, as the digits are typed
+// one by one. It should only be used when the page is in ModeLinkSelect.
+// The link can then be followed with Enter, like with Tab.
+func (t *tab) typeLinkNumber(digit rune) {
+	if time.Since(t.linkNumberAt) > linkNumberTimeout {
+		t.linkNumber = ""
+	}
+	t.linkNumberAt = time.Now()
+
+	var n int
+	t.linkNumber, n = nextLinkNumber(t.linkNumber, digit, len(t.page.Links))
+	if n == 0 {
+		// Out of range, keep the current selection
+		return
+	}
+	t.page.SelectedID = strconv.Itoa(n - 1)
+	t.page.Selected = t.page.Links[n-1]
+	t.view.Highlight(t.page.SelectedID)
+	t.view.ScrollToHighlight()
+	t.barLabel = "[::b]Link: [::-]"
+	t.barText = t.page.Selected
+	t.applyBottomBar()
+	t.previewLink()
+}
+
+// linkRegionStart matches the start of a link region in the content of a page.
+var linkRegionStart = regexp.MustCompile(`\["([0-9]+)"\]`)
+
+// linkRows returns the line of the content each link starts on, or -1 for
+// links that aren't in the content, like when it's in reader mode.
+func linkRows(content string, numLinks int) []int {
+	rows := make([]int, numLinks)
+	for i := range rows {
+		rows[i] = -1
+	}
+	for row, line := range strings.Split(content, "\n") {
+		for _, m := range linkRegionStart.FindAllStringSubmatch(line, -1) {
+			i, err := strconv.Atoi(m[1])
+			if err == nil && i < numLinks && rows[i] == -1 {
+				rows[i] = row
+			}
+		}
+	}
+	return rows
+}
+
+// nearLink returns the index of the link to select after the one at cur,
+// going forward if next is true. The screen shows the rows from top to bottom.
+// If the link at cur isn't on the screen, or cur is -1, the first link from
+// the top of the screen is next, and the last one from the bottom is previous.
+// -1 is returned if there's no link to select.
+func nearLink(rows []int, top, bottom, cur int, next bool) int {
+	if cur >= 0 && cur < len(rows) && rows[cur] >= top && rows[cur] <= bottom {
+		// Move on from the selected link
+		if next {
+			for i := cur + 1; i < len(rows); i++ {
+				if rows[i] >= 0 {
+					return i
+				}
+			}
+			return -1
+		}
+		for i := cur - 1; i >= 0; i-- {
+			if rows[i] >= 0 {
+				return i
+			}
+		}
+		return -1
+	}
+	if next {
+		for i, row := range rows {
+			if row >= top {
+				return i
+			}
+		}
+		return -1
+	}
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i] >= 0 && rows[i] <= bottom {
+			return i
+		}
+	}
+	return -1
+}
+
+// screenLink returns the index of the first link on the screen, which shows
+// the rows from top to bottom, or -1 if there isn't one. rows is from linkRows.
+func screenLink(rows []int, top, bottom int) int {
+	i := nearLink(rows, top, bottom, -1, true)
+	if i < 0 || rows[i] > bottom {
+		return -1
+	}
+	return i
+}
+
+// selectNearLink selects the next or previous link from where the page is
+// scrolled to, or from the selected link if it's on the screen. The page
+// is put in ModeLinkSelect, so the link can be followed with Enter.
+func (t *tab) selectNearLink(next bool) {
+	if t.mode != tabModeDone || len(t.page.Links) == 0 || t.page.Source ||
+		(t.page.Mode != structs.ModeOff && t.page.Mode != structs.ModeLinkSelect) {
+		return
+	}
+	cur := -1
+	if t.page.Mode == structs.ModeLinkSelect {
+		if highlights := t.view.GetHighlights(); len(highlights) > 0 {
+			cur, _ = strconv.Atoi(highlights[0])
+		}
+	}
+	top, _ := t.view.GetScrollOffset()
+	_, _, _, height := t.view.GetInnerRect()
+	i := nearLink(linkRows(t.page.Content, len(t.page.Links)), top, top+height-1, cur, next)
+	if i == -1 {
+		return
+	}
+
+	t.page.Mode = structs.ModeLinkSelect
+	t.linkNumber = "" // Typing a number starts over
+	t.page.SelectedID = strconv.Itoa(i)
+	t.page.Selected = t.page.Links[i]
+	t.view.Highlight(t.page.SelectedID)
+	t.view.ScrollToHighlight()
+	t.barLabel = "[::b]Link: [::-]"
+	t.barText = t.page.Selected
+	t.applyBottomBar()
+	t.previewLink()
+}
+
+// saveSelection saves the link that is highlighted on the page, so it can be
+// restored with applySelected.
+func (t *tab) saveSelection() {
+	if t.page.Mode != structs.ModeLinkSelect {
+		return
+	}
+	highlights := t.view.GetHighlights()
+	if len(highlights) == 0 {
+		return
+	}
+	i, err := strconv.Atoi(highlights[0])
+	if err != nil || i < 0 || i >= len(t.page.Links) {
+		return
+	}
+	t.page.SelectedID = highlights[0]
+	t.page.Selected = t.page.Links[i]
 }
 
 // applySelected selects whatever is stored as the selected element in the struct,
@@ -311,6 +633,10 @@ func (t *tab) applySelected() {
 			t.barLabel = "[::b]Link: [::-]"
 			t.barText = t.page.Selected
 		}
+	} else if t.page.Mode == structs.ModeSearch {
+		t.applySearch()
+	} else if t.page.Mode == structs.ModeTextSelect {
+		t.applyTextSelect()
 	}
 }
 
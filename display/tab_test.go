@@ -0,0 +1,224 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+var nextLinkNumberTests = []struct {
+	typed    string
+	digit    rune
+	numLinks int
+	want     string
+	wantLink int
+}{
+	{"", '3', 20, "3", 3},
+	{"1", '2', 20, "12", 12},
+	{"2", '5', 20, "5", 5},  // 25 is out of range, so 5 starts a new number
+	{"12", '3', 20, "3", 3}, // So is 123
+	{"", '0', 20, "", 0},    // There's no link zero
+	{"4", '0', 20, "4", 0},  // 40 is out of range, and so is 0 on its own
+	{"5", '7', 3, "5", 0},
+	{"", '1', 0, "", 0},
+	{"10", '0', 100, "100", 100},
+}
+
+func TestNextLinkNumber(t *testing.T) {
+	for _, tt := range nextLinkNumberTests {
+		got, gotLink := nextLinkNumber(tt.typed, tt.digit, tt.numLinks)
+		if got != tt.want || gotLink != tt.wantLink {
+			t.Errorf("nextLinkNumber(%q, %q, %d) = %q, %d, want %q, %d",
+				tt.typed, tt.digit, tt.numLinks, got, gotLink, tt.want, tt.wantLink)
+		}
+	}
+}
+
+// newHistoryTab returns a tab like NewTab makes, with just the history.
+func newHistoryTab() *tab {
+	t := &tab{history: &tabHistory{}, incognito: true}
+	t.addToHistory("about:newtab")
+	t.history.pos = 0
+	return t
+}
+
+func checkHistory(t *testing.T, tb *tab, urls []string, pos int) {
+	t.Helper()
+	if !reflect.DeepEqual(tb.history.urls, urls) || tb.history.pos != pos {
+		t.Errorf("history is %v at %d, want %v at %d", tb.history.urls, tb.history.pos, urls, pos)
+	}
+}
+
+func TestAddToHistory(t *testing.T) {
+	tb := newHistoryTab()
+	tb.addToHistory("gemini://a.example/")
+	tb.addToHistory("gemini://b.example/")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/", "gemini://b.example/"}, 2)
+
+	// Reloading doesn't add an entry
+	tb.addToHistory("gemini://b.example/")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/", "gemini://b.example/"}, 2)
+
+	// The same URL can be in the history again, just not twice in a row
+	tb.addToHistory("gemini://a.example/")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/", "gemini://b.example/", "gemini://a.example/"}, 3)
+}
+
+func TestAddToHistoryMiddle(t *testing.T) {
+	tb := newHistoryTab()
+	tb.addToHistory("gemini://a.example/")
+	tb.addToHistory("gemini://b.example/")
+	tb.addToHistory("gemini://c.example/")
+	tb.history.states = make([]histState, 4)
+
+	// Going back twice, then somewhere new removes the URLs ahead
+	tb.history.pos = 1
+	tb.addToHistory("gemini://d.example/")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/", "gemini://d.example/"}, 2)
+	if len(tb.history.states) != 2 {
+		t.Errorf("%d history states are left, want 2", len(tb.history.states))
+	}
+
+	// Reloading in the middle keeps the URLs ahead
+	tb.history.pos = 1
+	tb.addToHistory("gemini://a.example/")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/", "gemini://d.example/"}, 1)
+}
+
+func TestAddToHistoryKey(t *testing.T) {
+	defer viper.Set("cache.fold_trailing_slash", nil)
+	defer viper.Set("cache.lowercase_hosts", nil)
+	viper.Set("cache.fold_trailing_slash", true)
+	viper.Set("cache.lowercase_hosts", true)
+
+	tb := newHistoryTab()
+	tb.addToHistory("gemini://a.example/dir/")
+	// Another URL for the same page doesn't add an entry
+	tb.addToHistory("gemini://A.example/dir")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/dir/"}, 1)
+
+	viper.Set("cache.fold_trailing_slash", false)
+	tb.addToHistory("gemini://a.example/dir")
+	checkHistory(t, tb, []string{"about:newtab", "gemini://a.example/dir/", "gemini://a.example/dir"}, 2)
+}
+
+func TestLinkRows(t *testing.T) {
+	content := "Text\r\n" +
+		`[::b][1[][::-]  ["0"]One[""]` + "\r\n" +
+		"More text, with an escaped [\"2\"[] region\r\n" +
+		`[::b][2[][::-]  ["1"]Two is` + "\r\n" +
+		`     wrapped["1"][""]` + "\r\n"
+	want := []int{1, 3, -1}
+	if got := linkRows(content, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("linkRows = %v, want %v", got, want)
+	}
+}
+
+var nearLinkTests = []struct {
+	top, bottom, cur int
+	next             bool
+	want             int
+}{
+	{0, 9, -1, true, 0},   // First link on the screen
+	{0, 9, -1, false, 2},  // Last link on the screen
+	{0, 9, 0, true, 1},    // After the selected link
+	{0, 9, 1, false, 0},   // Before it
+	{0, 9, 0, false, -1},  // Nothing before the first link
+	{5, 14, 3, true, 5},   // Links not in the content are skipped
+	{12, 21, -1, true, 5}, // No links on the screen, so the next one below
+	{12, 21, -1, false, 3},
+	{12, 21, 0, true, 5}, // The selected link was scrolled away from
+	{31, 40, -1, true, -1},
+}
+
+func TestNearLink(t *testing.T) {
+	rows := []int{2, 5, 8, 11, -1, 25}
+	for _, tt := range nearLinkTests {
+		if got := nearLink(rows, tt.top, tt.bottom, tt.cur, tt.next); got != tt.want {
+			t.Errorf("nearLink(%v, %d, %d, %d, %t) = %d, want %d",
+				rows, tt.top, tt.bottom, tt.cur, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestScreenLink(t *testing.T) {
+	rows := []int{2, 5, 8, 11, -1, 25}
+	tests := []struct {
+		top, bottom, want int
+	}{
+		{0, 9, 0},
+		{3, 9, 1},
+		{12, 21, -1}, // The next link is below the screen
+		{31, 40, -1},
+	}
+	for _, tt := range tests {
+		if got := screenLink(rows, tt.top, tt.bottom); got != tt.want {
+			t.Errorf("screenLink(%v, %d, %d) = %d, want %d", rows, tt.top, tt.bottom, got, tt.want)
+		}
+	}
+}
+
+// fakeCache is a page cache for tests, that doesn't limit its pages.
+type fakeCache map[string]*structs.Page
+
+func (c fakeCache) Get(u string) (*structs.Page, bool) {
+	p, ok := c[u]
+	return p, ok
+}
+func (c fakeCache) Set(p *structs.Page) { c[p.URL] = p }
+func (c fakeCache) Remove(u string)     { delete(c, u) }
+func (c fakeCache) Size() int {
+	size := 0
+	for _, p := range c {
+		size += p.Size()
+	}
+	return size
+}
+
+func TestPageCache(t *testing.T) {
+	tb := &tab{}
+	if tb.pageCache() != cache.Pages {
+		t.Error("a tab without a cache doesn't use cache.Pages")
+	}
+	pages := fakeCache{}
+	tb.pages = pages
+	tb.pageCache().Set(&structs.Page{URL: "gemini://example.com/"})
+	if _, ok := pages["gemini://example.com/"]; !ok {
+		t.Error("the page wasn't added to the tab's cache")
+	}
+	if _, ok := cache.GetPage("gemini://example.com/"); ok {
+		t.Error("the page was added to cache.Pages")
+	}
+}
+
+func TestCopyPage(t *testing.T) {
+	src := &structs.Page{
+		Links:     []string{"gemini://example.com/"},
+		NewLinks:  []bool{true},
+		PreBlocks: []structs.PreBlock{{N: 0, Row: 2, Lines: 10}},
+		Folds:     map[int]bool{0: false},
+	}
+	page := copyPage(src)
+	page.PreBlocks[0].Folded = true
+	page.Folds[0] = true
+	linkFollowed(page, "gemini://example.com/")
+	if !src.NewLinks[0] {
+		t.Error("following a link in the copy made it not new on the page")
+	}
+	page.Links[0] = "gemini://example.org/"
+	if src.Links[0] != "gemini://example.com/" {
+		t.Error("changing the copy's links changed the page")
+	}
+	if src.PreBlocks[0].Folded || src.Folds[0] {
+		t.Error("folding a block in the copy folded it on the page")
+	}
+
+	// Nil stays nil
+	page = copyPage(&structs.Page{})
+	if page.NewLinks != nil || page.PreBlocks != nil || page.Folds != nil {
+		t.Error("the copy of a page without blocks has some")
+	}
+}
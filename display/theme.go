@@ -0,0 +1,50 @@
+package display
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// setTheme changes the theme for the rest of the session, see config.SetTheme.
+// The UI is given the new colors, and the open tabs are reformatted for them.
+func setTheme(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		names := config.ThemeNames()
+		sort.Strings(names)
+		Error("Command Error", "Type the name of a theme, like "+strings.Join(names, ", ")+
+			", or of a theme file in "+config.ThemesDir)
+		return
+	}
+	if err := config.SetTheme(name); err != nil {
+		Error("Theme Error", err.Error())
+		return
+	}
+
+	uiColors()
+	modalColors()
+	bkmkColors()
+	dlColors()
+	certColors()
+	tofuColors()
+	tocPanel.setColors()
+	linksPanel.setColors()
+	recentPanel.setColors()
+	multilineColors()
+
+	bg := tcell.ColorBlack
+	if viper.GetBool("a-general.color") {
+		bg = config.GetColor("bg")
+	}
+	for _, t := range tabs {
+		// These were set when the tab was made
+		t.view.SetBackgroundColor(bg)
+		t.view.SetScrollBarColor(config.GetColor("scrollbar"))
+		t.image.SetBackgroundColor(bg)
+	}
+	go reformatTabs(tabs[curTab])
+}
@@ -0,0 +1,158 @@
+package display
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// editText opens the text in the user's editor, and returns the edited text.
+// An error is returned if the editor exits with an error, so nothing is uploaded.
+func editText(text, ext string) ([]byte, error) {
+	f, err := ioutil.TempFile("", "amfora-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	_, err = f.WriteString(text)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runEditor(f.Name()); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
+
+// editAndUpload opens the raw content of the tab's page in the user's editor,
+// and uploads the edited content to the page using Titan.
+// The response from the server is then displayed in the tab.
+//
+// It should be called in a goroutine.
+func editAndUpload(t *tab) {
+	p := t.page
+	if !t.hasContent() || !strings.HasPrefix(p.URL, "gemini://") || p.Graphic {
+		Info("Only Gemini text pages can be edited and uploaded.")
+		return
+	}
+	if !schemeAllowed("titan") {
+		// Checked before editing, so nothing is written that can't be sent
+		schemeNotAllowed("titan")
+		return
+	}
+
+	mediatype := p.RawMediatype
+	ext := ".txt"
+	if mediatype == "" || mediatype == "text/gemini" {
+		mediatype = "text/gemini"
+		ext = ".gmi"
+	}
+	data, err := editText(p.Raw, ext)
+	if err != nil {
+		Error("Upload Aborted", err.Error())
+		return
+	}
+	upload(t, mediatype, data)
+}
+
+// typeAndUpload opens the multi-line input to type or paste text, and uploads
+// it to the tab's page using Titan, replacing what was there.
+// The response from the server is then displayed in the tab.
+//
+// It should be called in a goroutine.
+func typeAndUpload(t *tab) {
+	p := t.page
+	if !t.hasContent() || !strings.HasPrefix(p.URL, "gemini://") || p.Graphic {
+		Info("Only Gemini text pages can be uploaded to.")
+		return
+	}
+	if !schemeAllowed("titan") {
+		schemeNotAllowed("titan")
+		return
+	}
+
+	mediatype := p.RawMediatype
+	if mediatype == "" {
+		mediatype = "text/gemini"
+	}
+	text, ok := MultilineInput("Text to upload to " + p.URL + ", as " + mediatype)
+	if !ok {
+		return
+	}
+	upload(t, mediatype, []byte(text))
+}
+
+// upload sends the data to the tab's page using Titan, asking for a token
+// first, and displays the response from the server in the tab.
+// Nothing is sent if titan isn't one of the allowed_schemes.
+func upload(t *tab, mediatype string, data []byte) {
+	if !schemeAllowed("titan") {
+		schemeNotAllowed("titan")
+		return
+	}
+	p := t.page
+	token, _ := Input("Token for the upload, if the server needs one. Press Cancel to upload without one.", true)
+	titanURL, err := client.TitanURL(p.URL, mediatype, len(data), token)
+	if err != nil {
+		Error("Upload Error", err.Error())
+		return
+	}
+
+	if t == tabs[curTab] {
+		bottomBar.SetText("Uploading...")
+		App.Draw()
+	}
+	res, err := client.Upload(titanURL, data)
+	if errors.Is(err, client.ErrTofu) {
+		parsed, _ := url.Parse(p.URL)
+		if !Tofu(parsed.Host, parsed.Hostname(), parsed.Port(), res.Cert) {
+			t.applyBottomBar()
+			return
+		}
+		res, err = client.Upload(titanURL, data)
+	}
+	if !isValidTab(t) {
+		return
+	}
+	if t == tabs[curTab] {
+		t.applyBottomBar()
+	}
+	if err != nil {
+		Error("Upload Error", err.Error())
+		return
+	}
+
+	//nolint:exhaustive
+	switch gemini.SimplifyStatus(res.Status) {
+	case 20:
+		// Show the new version of the page
+		t.pageCache().Remove(p.URL)
+		goURL(t, p.URL)
+	case 30:
+		// Usually the page that was uploaded to
+		parsed, _ := url.Parse(p.URL)
+		parsedMeta, err := url.Parse(res.Meta)
+		if err != nil {
+			Error("Redirect Error", "Invalid URL: "+err.Error())
+			return
+		}
+		redir := parsed.ResolveReference(parsedMeta).String()
+		t.pageCache().Remove(redir)
+		goURL(t, redir)
+	case 10:
+		Error("Upload Error", "The server asked for input, which can't be sent with an upload.")
+	case 40:
+		Error("Temporary Failure", escapeMeta(res.Meta))
+	case 50:
+		Error("Permanent Failure", escapeMeta(res.Meta))
+	case 60:
+		Error("Client Certificate Error", escapeMeta(res.Meta))
+	}
+}
@@ -0,0 +1,113 @@
+package display
+
+import (
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"gitlab.com/tslocum/cview"
+)
+
+// The table of contents is a list of the headings of the current page,
+// which can be searched. Selecting one scrolls the page to it.
+// URL fragments scroll to headings too, see scrollToFragment.
+
+var tocPanel *listPanel
+
+// Indexes of the page headings shown in the list, after searching.
+// Indexes are kept instead of the headings so that the row used is always
+// the current one, even if the page was reformatted while the list was open.
+var tocShown []int
+
+func tocInit() {
+	tocPanel = newListPanel("toc", " Table of Contents ", "regular_text", searchTOC, jumpToHeading, closeTOC)
+	tocPanel.list.ShowSecondaryText(false)
+	tocPanel.addPanel(false)
+}
+
+// TOC displays the table of contents of the current page.
+// The heading at or above the top of the screen is selected.
+func TOC() {
+	t := tabs[curTab]
+	if len(t.page.Headings) == 0 {
+		Info("This page has no headings.")
+		return
+	}
+
+	row, _ := t.view.GetScrollOffset()
+	cur := headingIndex(t.page.Headings, row)
+	if cur < 0 {
+		cur = 0
+	}
+	tocPanel.show(cur)
+}
+
+// headingIndex returns the index of the last heading that starts at or above
+// the row, or -1 if the row is above all of them.
+func headingIndex(headings []structs.Heading, row int) int {
+	n := -1
+	for i, h := range headings {
+		if h.Row > row {
+			break
+		}
+		n = i
+	}
+	return n
+}
+
+// searchTOC shows just the headings of the current page that contain the
+// search text, ignoring case.
+func searchTOC(text string) {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	tocPanel.list.Clear()
+	tocShown = tocShown[:0]
+	for i, h := range tabs[curTab].page.Headings {
+		if !strings.Contains(strings.ToLower(h.Text), text) {
+			continue
+		}
+		tocShown = append(tocShown, i)
+		tocPanel.list.AddItem(cview.NewListItem(strings.Repeat("  ", h.Level-1) + h.Text))
+	}
+}
+
+// jumpToHeading closes the table of contents and scrolls the page to the
+// heading at index i of the list.
+func jumpToHeading(i int) {
+	closeTOC()
+
+	t := tabs[curTab]
+	if i < 0 || i >= len(tocShown) || tocShown[i] >= len(t.page.Headings) {
+		return
+	}
+	t.scrollToHeading(t.page.Headings[tocShown[i]])
+}
+
+// scrollToHeading scrolls the page so the heading is at the top of the screen.
+func (t *tab) scrollToHeading(h structs.Heading) {
+	_, col := t.view.GetScrollOffset()
+	t.view.ScrollTo(h.Row, col)
+	t.saveScroll()
+}
+
+// scrollToFragment scrolls the page to the heading the URL fragment links to.
+// It returns false if there's no such heading on the page.
+func (t *tab) scrollToFragment(fragment string) bool {
+	slug := renderer.HeadingSlug(fragment)
+	if slug == "" {
+		return false
+	}
+	for _, h := range t.page.Headings {
+		if h.Slug == slug {
+			t.scrollToHeading(h)
+			App.Draw()
+			return true
+		}
+	}
+	return false
+}
+
+func closeTOC() {
+	tocPanel.hide()
+	App.Draw()
+}
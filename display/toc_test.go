@@ -0,0 +1,40 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+func TestHeadingIndex(t *testing.T) {
+	headings := []structs.Heading{{Row: 2}, {Row: 5}, {Row: 9}}
+	for row, want := range map[int]int{0: -1, 1: -1, 2: 0, 4: 0, 5: 1, 8: 1, 9: 2, 100: 2} {
+		if got := headingIndex(headings, row); got != want {
+			t.Errorf("headingIndex(row %d) = %d, want %d", row, got, want)
+		}
+	}
+	if got := headingIndex(nil, 3); got != -1 {
+		t.Errorf("headingIndex with no headings = %d, want -1", got)
+	}
+}
+
+var headingURLTests = []struct {
+	pageURL string
+	slug    string
+	want    string
+	ok      bool
+}{
+	{"gemini://example.com/page.gmi", "section", "gemini://example.com/page.gmi#section", true},
+	{"gemini://example.com/page.gmi#old", "section-1", "gemini://example.com/page.gmi#section-1", true},
+	{"gemini://example.com/?q=1", "notes", "gemini://example.com/?q=1#notes", true},
+	{"gemini://example.com/", "", "", false},
+}
+
+func TestHeadingURL(t *testing.T) {
+	for _, tt := range headingURLTests {
+		got, ok := headingURL(tt.pageURL, tt.slug)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("headingURL(%q, %q) = %q, %v, want %q, %v", tt.pageURL, tt.slug, got, ok, tt.want, tt.ok)
+		}
+	}
+}
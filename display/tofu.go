@@ -0,0 +1,229 @@
+package display
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"gitlab.com/tslocum/cview"
+)
+
+// For asking what to do when a server's cert doesn't match the TOFU database
+var tofuModal = cview.NewModal()
+
+// Channel to indicate which button was chosen, by label
+var tofuCh = make(chan string)
+
+func tofuInit() {
+	panels.AddPanel("tofu", tofuModal, false, false)
+
+	m := tofuModal
+	tofuColors()
+
+	m.AddButtons([]string{"Trust", "This Session", "Reject"})
+	m.SetBorder(true)
+	frame := m.GetFrame()
+	frame.SetTitleAlign(cview.AlignCenter)
+	frame.SetTitle(" TOFU ")
+	m.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		tofuCh <- buttonLabel
+	})
+}
+
+// tofuColors sets the colors of the TOFU modal.
+func tofuColors() {
+	m := tofuModal
+	if viper.GetBool("a-general.color") {
+		m.SetButtonBackgroundColor(config.GetColor("btn_bg"))
+		m.SetButtonTextColor(config.GetColor("btn_text"))
+		m.SetBackgroundColor(config.GetColor("tofu_modal_bg"))
+		m.SetTextColor(config.GetColor("tofu_modal_text"))
+		form := m.GetForm()
+		form.SetButtonBackgroundColorFocused(config.GetColor("btn_text"))
+		form.SetButtonTextColorFocused(config.GetColor("btn_bg"))
+		frame := m.GetFrame()
+		frame.SetBorderColor(config.GetColor("tofu_modal_text"))
+		frame.SetTitleColor(config.GetColor("tofu_modal_text"))
+	} else {
+		m.SetButtonBackgroundColor(tcell.ColorWhite)
+		m.SetButtonTextColor(tcell.ColorBlack)
+		m.SetBackgroundColor(tcell.ColorBlack)
+		m.SetTextColor(tcell.ColorWhite)
+		form := m.GetForm()
+		form.SetButtonBackgroundColorFocused(tcell.ColorBlack)
+		form.SetButtonTextColorFocused(tcell.ColorWhite)
+		frame := m.GetFrame()
+		frame.SetBorderColor(tcell.ColorWhite)
+		frame.SetTitleColor(tcell.ColorWhite)
+	}
+}
+
+// Tofu displays the TOFU warning modal, for when the cert of a server
+// has changed. It shows the old and new fingerprints, and blocks until
+// the user chooses to trust the new cert, trust it for this session only,
+// or reject it. The choice is saved, so the connection can go ahead
+// if true is returned.
+//
+// host is what's displayed to the user, hostname and port are used
+// for the TOFU entry. The port can be empty, to indicate port 1965.
+func Tofu(host, hostname, port string, cert *x509.Certificate) bool {
+	tofuModal.SetText(
+		//nolint:lll
+		fmt.Sprintf("%s's certificate has changed, possibly indicating a security issue. The old certificate would have expired %s.\n\nOld: %s\nNew: %s\n\nDo you want to trust the new certificate?",
+			host,
+			humanize.Time(client.GetExpiry(hostname, port)),
+			client.GetFingerprint(hostname, port),
+			client.Fingerprint(cert),
+		),
+	)
+	panels.ShowPanel("tofu")
+	panels.SendToFront("tofu")
+	App.SetFocus(tofuModal)
+	App.Draw()
+
+	choice := <-tofuCh
+	panels.HidePanel("tofu")
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+
+	switch choice {
+	case "Trust":
+		client.ResetTofuEntry(hostname, port, cert)
+		return true
+	case "This Session":
+		client.TrustForSession(hostname, port, cert)
+		return true
+	}
+	return false
+}
+
+// tofuHost returns the host to display for a TOFU entry.
+func tofuHost(e client.TofuEntry) string {
+	if e.Port == "" {
+		if strings.Contains(e.Domain, ":") {
+			// IPv6 address
+			return "[" + e.Domain + "]"
+		}
+		return e.Domain
+	}
+	return net.JoinHostPort(e.Domain, e.Port)
+}
+
+// TofuPage displays the certs stored in the TOFU database in the current tab,
+// so they can be removed. `u` is the URL entered by the user.
+func TofuPage(t *tab, u string) {
+	if len(u) > 11 && u[:11] == "about:tofu?" {
+		// There's a query string, aka a host to remove
+		tofuPageQuery(t, u)
+		return
+	}
+
+	rawPage := "# Server Certificates\n\n" +
+		"These are the certificates that have been trusted for each server. " +
+		"Navigate to the link to remove one, so the next certificate the server uses " +
+		"will be trusted without asking.\n\n" +
+		"Use the export-tofu and import-tofu commands with a file path to copy them " +
+		"to another computer.\n\n"
+
+	entries := client.TofuEntries()
+	if len(entries) == 0 {
+		rawPage += "No certificates have been trusted yet.\n"
+	}
+	for _, e := range entries {
+		host := tofuHost(e)
+		firstSeen := "Unknown"
+		if !e.FirstSeen.IsZero() {
+			firstSeen = e.FirstSeen.Local().Format("2006-01-02")
+		}
+		rawPage += fmt.Sprintf("## %s\n\n* Fingerprint: %s\n* First seen: %s\n* Expires: %s\n=>%s Remove\n\n",
+			host, e.Fingerprint, firstSeen, e.Expiry.Local().Format("2006-01-02"),
+			"about:tofu?"+gemini.QueryEscape(host))
+	}
+
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	page := structs.Page{
+		Raw:        rawPage,
+		Content:    content,
+		Links:      links,
+		URL:        "about:tofu",
+		TermWidth:  termW,
+		LeftMargin: leftMargin(),
+		Mediatype:  structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
+
+func tofuPageQuery(t *tab, u string) {
+	host, err := gemini.QueryUnescape(u[11:])
+	if err != nil {
+		Error("URL Error", "Invalid query string: "+err.Error())
+		return
+	}
+	parsed, err := url.Parse("//" + host)
+	if err != nil {
+		Error("URL Error", "Invalid host: "+err.Error())
+		return
+	}
+
+	err = client.RemoveTofuEntry(parsed.Hostname(), parsed.Port())
+	TofuPage(t, "about:tofu") // Reload
+	if err != nil {
+		Error("Save Error", "Error saving the TOFU database to disk: "+err.Error())
+		return
+	}
+	Info("Removed the certificate for " + host)
+}
+
+// exportTofu writes the TOFU database to the file at the path typed,
+// for the export-tofu command.
+func exportTofu(arg string) {
+	if arg == "" {
+		Error("Command Error", "Type the path of a file to export to, like export-tofu ~/tofu.json.")
+		return
+	}
+	path, err := homedir.Expand(arg)
+	if err != nil {
+		Error("Command Error", "Invalid path: "+err.Error())
+		return
+	}
+	if err := client.ExportTofu(path); err != nil {
+		Error("Export Error", "Error saving the certificates: "+err.Error())
+		return
+	}
+	Info("Saved the server certificates to " + path)
+}
+
+// importTofu adds the certificates in a file written by exportTofu to the
+// TOFU database, for the import-tofu command.
+func importTofu(arg string) {
+	if arg == "" {
+		Error("Command Error", "Type the path of a file to import, like import-tofu ~/tofu.json.")
+		return
+	}
+	path, err := homedir.Expand(arg)
+	if err != nil {
+		Error("Command Error", "Invalid path: "+err.Error())
+		return
+	}
+	n, err := client.ImportTofu(path)
+	if err != nil {
+		Error("Import Error", "Error importing the certificates: "+err.Error())
+		return
+	}
+	if t := tabs[curTab]; t.page.URL == "about:tofu" && t.mode == tabModeDone {
+		TofuPage(t, "about:tofu") // Reload
+	}
+	Info(fmt.Sprintf("Imported %d server certificates.", n))
+}
@@ -0,0 +1,9 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package display
+
+// privateUmask would set the umask so files that are made can only be used by
+// this user. There's no umask on this OS, so nothing is done.
+func privateUmask() func() {
+	return func() {}
+}
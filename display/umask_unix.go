@@ -0,0 +1,14 @@
+// +build linux darwin freebsd netbsd openbsd
+
+package display
+
+import "syscall"
+
+// privateUmask sets the umask so files that are made can only be used by
+// this user, and returns a func that sets the old one back.
+func privateUmask() func() {
+	old := syscall.Umask(0077)
+	return func() {
+		syscall.Umask(old)
+	}
+}
@@ -0,0 +1,131 @@
+package display
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/makeworld-the-better-one/amfora/bookmarks"
+)
+
+// Completion of URLs typed in the bottomBar, from the URLs visited this
+// session and the bookmarks. Pressing Tab fills in the next suggestion,
+// and Shift-Tab the previous one. Nothing is loaded until Enter is pressed.
+
+// visitedURLs holds every URL visited this session across all tabs, including
+// closed ones, without duplicates. The most recent is at the end.
+var visitedURLs = make([]string, 0)
+var visitedURLsMu = sync.Mutex{}
+
+// The suggestions for the text that was typed, and which one is in the bottomBar.
+// urlSuggestions is nil when the suggestions need to be found again.
+var urlSuggestions []string
+var urlSuggestionIdx int
+
+// addVisitedURL adds the URL to visitedURLs, or moves it to the end if it's already there.
+func addVisitedURL(u string) {
+	if u == "" || u == "about:newtab" {
+		return
+	}
+	visitedURLsMu.Lock()
+	defer visitedURLsMu.Unlock()
+
+	for i := range visitedURLs {
+		if visitedURLs[i] == u {
+			visitedURLs = append(visitedURLs[:i], visitedURLs[i+1:]...)
+			break
+		}
+	}
+	visitedURLs = append(visitedURLs, u)
+}
+
+// knownURLs returns the URLs that can be suggested, without duplicates.
+// Visited URLs come first, most recent first, then the history of open tabs
+// that might have been restored from a session, and then bookmarks.
+// The history of incognito tabs isn't used.
+func knownURLs() []string {
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && u != "about:newtab" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	visitedURLsMu.Lock()
+	for i := len(visitedURLs) - 1; i >= 0; i-- {
+		add(visitedURLs[i])
+	}
+	visitedURLsMu.Unlock()
+
+	for _, t := range tabs {
+		if t.incognito {
+			continue
+		}
+		for i := len(t.history.urls) - 1; i >= 0; i-- {
+			add(t.history.urls[i])
+		}
+	}
+
+	bkmkURLs, _ := bookmarks.List()
+	for _, u := range bkmkURLs {
+		add(u)
+	}
+	return urls
+}
+
+// trimScheme removes the scheme from the URL, so it can be compared
+// with what's typed without one.
+func trimScheme(u string) string {
+	if i := strings.Index(u, "://"); i != -1 {
+		return u[i+3:]
+	}
+	return u
+}
+
+// matchURLs returns the URLs that match the text, ignoring case, in the same order.
+// URLs that start with the text, with or without their scheme, come before URLs
+// that only contain it.
+func matchURLs(text string, urls []string) []string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return []string{}
+	}
+
+	prefixed := make([]string, 0)
+	contained := make([]string, 0)
+	for _, u := range urls {
+		lower := strings.ToLower(u)
+		if strings.HasPrefix(lower, text) || strings.HasPrefix(trimScheme(lower), text) {
+			prefixed = append(prefixed, u)
+		} else if strings.Contains(lower, text) {
+			contained = append(contained, u)
+		}
+	}
+	return append(prefixed, contained...)
+}
+
+// suggestURL fills the bottomBar with the next suggested URL for what was typed,
+// or the previous one if back is true. The suggestions are found again if
+// the text has been changed by the user since the last one was filled in.
+func suggestURL(back bool) {
+	text := bottomBar.GetText()
+	if urlSuggestions == nil || urlSuggestionIdx >= len(urlSuggestions) ||
+		urlSuggestions[urlSuggestionIdx] != text {
+		urlSuggestions = matchURLs(text, knownURLs())
+		if len(urlSuggestions) == 0 {
+			urlSuggestions = nil
+			return
+		}
+		if back {
+			urlSuggestionIdx = len(urlSuggestions) - 1
+		} else {
+			urlSuggestionIdx = 0
+		}
+	} else if back {
+		urlSuggestionIdx = (urlSuggestionIdx - 1 + len(urlSuggestions)) % len(urlSuggestions)
+	} else {
+		urlSuggestionIdx = (urlSuggestionIdx + 1) % len(urlSuggestions)
+	}
+	bottomBar.SetText(urlSuggestions[urlSuggestionIdx])
+}
@@ -0,0 +1,53 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+var matchURLsURLs = []string{
+	"gemini://example.com/",
+	"gemini://gemini.circumlunar.space/docs/",
+	"gemini://example.org/gemini.gmi",
+	"about:bookmarks",
+}
+
+var matchURLsTests = []struct {
+	text string
+	want []string
+}{
+	{"", []string{}},
+	{"  ", []string{}},
+	{"exa", []string{"gemini://example.com/", "gemini://example.org/gemini.gmi"}},
+	{"EXA", []string{"gemini://example.com/", "gemini://example.org/gemini.gmi"}},
+	{"gemini", []string{
+		"gemini://example.com/",
+		"gemini://gemini.circumlunar.space/docs/",
+		"gemini://example.org/gemini.gmi",
+	}},
+	{"gemini.", []string{"gemini://gemini.circumlunar.space/docs/", "gemini://example.org/gemini.gmi"}},
+	{"docs", []string{"gemini://gemini.circumlunar.space/docs/"}},
+	{"about:b", []string{"about:bookmarks"}},
+	{"nothing", []string{}},
+}
+
+func TestMatchURLs(t *testing.T) {
+	for _, tt := range matchURLsTests {
+		if got := matchURLs(tt.text, matchURLsURLs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchURLs(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAddVisitedURL(t *testing.T) {
+	visitedURLs = []string{}
+	addVisitedURL("gemini://a.com/")
+	addVisitedURL("gemini://b.com/")
+	addVisitedURL("about:newtab")
+	addVisitedURL("gemini://a.com/")
+
+	want := []string{"gemini://b.com/", "gemini://a.com/"}
+	if !reflect.DeepEqual(visitedURLs, want) {
+		t.Errorf("visitedURLs = %q, want %q", visitedURLs, want)
+	}
+}
@@ -3,8 +3,11 @@ package display
 import (
 	"errors"
 	"net/url"
+	"path"
+	"strconv"
 	"strings"
 
+	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/go-gemini"
 	"github.com/spf13/viper"
 	"gitlab.com/tslocum/cview"
@@ -14,9 +17,9 @@ import (
 // This file contains funcs that are small, self-contained utilities.
 
 // makeContentLayout returns a flex that contains the given TextView
-// along with the provided left margin, as well as a single empty
+// or image view along with the provided left margin, as well as a single empty
 // line at the top, for a top margin.
-func makeContentLayout(tv *cview.TextView, leftMargin int) *cview.Flex {
+func makeContentLayout(tv cview.Primitive, leftMargin int) *cview.Flex {
 	// Create horizontal flex with the left margin as an empty space
 	horiz := cview.NewFlex()
 	horiz.SetDirection(cview.FlexColumn)
@@ -34,6 +37,80 @@ func makeContentLayout(tv *cview.TextView, leftMargin int) *cview.Flex {
 	return vert
 }
 
+// maxTabTitle is the most characters of a page title shown in a tab label.
+const maxTabTitle = 20
+
+// firstHeading returns the text of the first level one heading of the page,
+// or an empty string if it isn't gemtext or has no heading.
+func firstHeading(p *structs.Page) string {
+	if p.Mediatype != structs.TextGemini {
+		return ""
+	}
+	pre := false
+	for _, line := range strings.Split(p.Raw, "\n") {
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+		} else if !pre && strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "##") {
+			return strings.TrimSpace(line[1:])
+		}
+	}
+	return ""
+}
+
+// pageTitle returns the title of the page to show in the tab bar. It's the
+// first level one heading for gemtext pages, and the host of the URL otherwise.
+func pageTitle(p *structs.Page) string {
+	title := firstHeading(p)
+	if title == "" {
+		parsed, err := url.Parse(p.URL)
+		switch {
+		case err != nil || parsed.Scheme == "about":
+			title = p.URL
+		case parsed.Scheme == "file":
+			title = path.Base(parsed.Path)
+		default:
+			title = parsed.Hostname()
+		}
+	}
+
+	return truncateTitle(title)
+}
+
+// truncateTitle shortens the title to fit in the tab bar, if it's too long.
+func truncateTitle(title string) string {
+	if runes := []rune(title); len(runes) > maxTabTitle {
+		return string(runes[:maxTabTitle-1]) + "…"
+	}
+	return title
+}
+
+// tabLabel returns the label in the tab bar for the tab with the given index.
+// It has the tab number, or the favicon if there is one, and the page title.
+// The title set for the tab by the user is used instead, if there is one.
+// Incognito tabs are marked after the number, and tabs that loaded
+// a page in the background have a star.
+func tabLabel(i int) string {
+	p := tabs[i].page
+	s := strconv.Itoa(i + 1)
+	if tabs[i].unseen {
+		s += "*"
+	}
+	if tabs[i].incognito {
+		s += " (incognito)"
+	}
+	if p.Favicon != "" && viper.GetBool("a-general.emoji_favicons") {
+		s += " " + p.Favicon
+	}
+	title := truncateTitle(tabs[i].title)
+	if title == "" {
+		title = pageTitle(p)
+	}
+	if title != "" {
+		s += " " + cview.Escape(title)
+	}
+	return makeTabLabel(s)
+}
+
 // makeTabLabel takes a string and adds spacing to it, making it
 // suitable for display as a tab label.
 func makeTabLabel(s string) string {
@@ -62,8 +139,36 @@ func isValidTab(t *tab) bool {
 	return tabNumber(t) != -1
 }
 
+// leftMargin returns the width of the left margin in columns.
+// If center_text is enabled it's wide enough to center the text,
+// but never smaller than the left_margin.
 func leftMargin() int {
-	return int(float64(termW) * viper.GetFloat64("a-general.left_margin"))
+	cols := configLeftMargin()
+	if viper.GetBool("a-general.center_text") && termW > 0 {
+		if center := (termW - textWidth()) / 2; center > cols {
+			return center
+		}
+	}
+	return cols
+}
+
+// configLeftMargin returns the width of the left margin set in the config.
+// The config value is a number of columns if it's 1 or more,
+// and a fraction of the terminal width otherwise.
+func configLeftMargin() int {
+	margin := viper.GetFloat64("a-general.left_margin")
+	cols := int(margin)
+	if margin < 1 {
+		cols = int(float64(termW) * margin)
+	}
+	if cols < 0 {
+		return 0
+	}
+	if cols > termW/2 {
+		// Keep at least half of the terminal for the text
+		return termW / 2
+	}
+	return cols
 }
 
 func textWidth() int {
@@ -73,19 +178,49 @@ func textWidth() int {
 		return viper.GetInt("a-general.max_width")
 	}
 
-	rightMargin := leftMargin()
-	if leftMargin() > 10 {
+	// Centering the text doesn't change its width
+	margin := configLeftMargin()
+	rightMargin := margin
+	if margin > 10 {
 		// 10 is the max right margin
 		rightMargin = 10
 	}
 
-	max := termW - leftMargin() - rightMargin
+	max := termW - margin - rightMargin
 	if max < viper.GetInt("a-general.max_width") {
 		return max
 	}
 	return viper.GetInt("a-general.max_width")
 }
 
+// isDisplayable returns true if the absolute URL uses a scheme that can be
+// displayed in a tab, instead of being opened in another application.
+func isDisplayable(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "gemini", "about", "file", "spartan", "gopher", "finger", "nex":
+		return true
+	}
+	// Other schemes can only be displayed through a proxy
+	proxy := strings.TrimSpace(viper.GetString("proxies." + parsed.Scheme))
+	return proxy != "" && proxy != "off"
+}
+
+// samePageFragment returns the fragment of the absolute link, and true if the
+// link is to the page URL with just a fragment added.
+func samePageFragment(pageURL, link string) (string, bool) {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Fragment == "" {
+		return "", false
+	}
+	fragment := parsed.Fragment
+	parsed.Fragment = ""
+	return fragment, normalizeURL(parsed.String()) == normalizeURL(pageURL)
+}
+
 // resolveRelLink returns an absolute link for the given absolute link and relative one.
 // It also returns an error if it could not resolve the links, which should be displayed
 // to the user.
@@ -93,13 +228,64 @@ func resolveRelLink(t *tab, prev, next string) (string, error) {
 	if !t.hasContent() {
 		return next, nil
 	}
+	return resolveLink(prev, next)
+}
 
-	prevParsed, _ := url.Parse(prev)
-	nextParsed, err := url.Parse(next)
+// resolveLink resolves the link against the absolute base URL, following RFC 3986.
+// Dot-segments are removed, a link of only a query or fragment keeps the base path,
+// and an empty path is made into "/" when the link is relative and has a host.
+func resolveLink(base, link string) (string, error) {
+	baseParsed, err := url.Parse(base)
+	if err != nil {
+		return "", errors.New("page URL could not be parsed") //nolint:goerr113
+	}
+	linkParsed, err := url.Parse(strings.TrimSpace(link))
 	if err != nil {
 		return "", errors.New("link URL could not be parsed") //nolint:goerr113
 	}
-	return prevParsed.ResolveReference(nextParsed).String(), nil
+	resolved := baseParsed.ResolveReference(linkParsed)
+	if !linkParsed.IsAbs() && resolved.Host != "" && resolved.Path == "" {
+		resolved.Path = "/"
+	}
+	return resolved.String(), nil
+}
+
+// rootURL returns the root of the site of the absolute URL, with its scheme
+// and host and a path of "/". It returns false if the URL has no host.
+func rootURL(u string) (string, bool) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	root := url.URL{Scheme: parsed.Scheme, User: parsed.User, Host: parsed.Host, Path: "/"}
+	return root.String(), true
+}
+
+// parentURL returns the absolute URL with the last segment of its path
+// removed, like going up a directory: /a/b/c and /a/b/c/ both become /a/b/.
+// The query and fragment are removed too. It returns false if the URL is
+// already at the root, or has no path to go up.
+func parentURL(u string) (string, bool) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Opaque != "" || parsed.Scheme == "" {
+		return "", false
+	}
+	p := strings.TrimSuffix(parsed.Path, "/")
+	if p == "" && parsed.RawQuery == "" {
+		// Already at the root
+		return "", false
+	}
+	if p == "" {
+		// Just the query is removed
+		p = "/"
+	} else {
+		p = path.Dir(p)
+	}
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	parent := url.URL{Scheme: parsed.Scheme, User: parsed.User, Host: parsed.Host, Path: p}
+	return parent.String(), true
 }
 
 // normalizeURL attempts to make URLs that are different strings
@@ -166,6 +352,28 @@ func normalizeURL(u string) string {
 	return parsed.String()
 }
 
+// urlKey returns the URL pages are cached and kept in the recent and tab
+// histories by, so URLs from normalizeURL that are for the same page have the
+// same key. Depending on the cache options, hosts are lowercased, and the slash
+// at the end of paths other than / is removed. The URL should still be displayed
+// as it was, because these can be wrong for some servers. TOFU doesn't use it,
+// since certificates are kept by host and port, and the config keys for them
+// already ignore case.
+func urlKey(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return u
+	}
+	if viper.GetBool("cache.lowercase_hosts") {
+		parsed.Host = strings.ToLower(parsed.Host)
+	}
+	if viper.GetBool("cache.fold_trailing_slash") && parsed.Path != "/" && strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+		parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
+	}
+	return parsed.String()
+}
+
 // fixUserURL will take a user-typed URL and add a gemini scheme to it if
 // necessary. It is not the same as normalizeURL, and that func should still
 // be used, afterward.
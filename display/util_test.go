@@ -3,6 +3,11 @@ package display
 
 import (
 	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
 )
 
 var normalizeURLTests = []struct {
@@ -42,3 +47,228 @@ func TestNormalizeURL(t *testing.T) {
 		}
 	}
 }
+
+var resolveLinkTests = []struct {
+	base     string
+	link     string
+	expected string
+}{
+	{"gemini://example.com/dir/page.gmi", "other.gmi", "gemini://example.com/dir/other.gmi"},
+	{"gemini://example.com/dir/", "other.gmi", "gemini://example.com/dir/other.gmi"},
+	// Base without a trailing slash
+	{"gemini://example.com/dir", "other.gmi", "gemini://example.com/other.gmi"},
+	{"gemini://example.com", "other.gmi", "gemini://example.com/other.gmi"},
+	// Query strings
+	{"gemini://example.com/dir/page.gmi", "?query", "gemini://example.com/dir/page.gmi?query"},
+	{"gemini://example.com/dir/page.gmi?old", "?new", "gemini://example.com/dir/page.gmi?new"},
+	{"gemini://example.com", "?query", "gemini://example.com/?query"},
+	{"gemini://example.com/dir/page.gmi?old", "other.gmi", "gemini://example.com/dir/other.gmi"},
+	// Fragments and empty links
+	{"gemini://example.com/dir/page.gmi?q", "#frag", "gemini://example.com/dir/page.gmi?q#frag"},
+	{"gemini://example.com/dir/page.gmi", "", "gemini://example.com/dir/page.gmi"},
+	// Dot-segments
+	{"gemini://example.com/a/b/c.gmi", "../d.gmi", "gemini://example.com/a/d.gmi"},
+	{"gemini://example.com/a/b/c.gmi", "./", "gemini://example.com/a/b/"},
+	{"gemini://example.com/a/b/c.gmi", "..", "gemini://example.com/a/"},
+	{"gemini://example.com/a/b/", "../../../../d.gmi", "gemini://example.com/d.gmi"},
+	{"gemini://example.com/a/b/c.gmi", "/x/./y/../z.gmi", "gemini://example.com/x/z.gmi"},
+	// No scheme
+	{"gemini://example.com/a/b.gmi", "//other.org/c.gmi", "gemini://other.org/c.gmi"},
+	{"gemini://example.com/a/b.gmi", "other.org/c.gmi", "gemini://example.com/a/other.org/c.gmi"},
+	// Absolute links
+	{"gemini://example.com/a/b.gmi", "gopher://other.org/1/", "gopher://other.org/1/"},
+	{"gemini://example.com/a/b.gmi", "https://other.org", "https://other.org"},
+	{"gemini://example.com/a/b.gmi", "mailto:user@example.com", "mailto:user@example.com"},
+	// Escaping
+	{"gemini://example.com/a%20b/c.gmi", "d.gmi", "gemini://example.com/a%20b/d.gmi"},
+	{"gemini://example.com/a/b.gmi", " c.gmi ", "gemini://example.com/a/c.gmi"},
+}
+
+func TestResolveLink(t *testing.T) {
+	for _, tt := range resolveLinkTests {
+		actual, err := resolveLink(tt.base, tt.link)
+		if err != nil {
+			t.Errorf("resolveLink(%s, %s): unexpected error %v", tt.base, tt.link, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("resolveLink(%s, %s): expected %s, actual %s", tt.base, tt.link, tt.expected, actual)
+		}
+	}
+
+	if _, err := resolveLink("gemini://example.com/", "100%"); err == nil {
+		t.Errorf("resolveLink with an invalid link: expected an error")
+	}
+}
+
+var samePageFragmentTests = []struct {
+	page     string
+	link     string
+	fragment string
+	same     bool
+}{
+	{"gemini://example.com/page.gmi", "gemini://example.com/page.gmi#intro", "intro", true},
+	{"gemini://example.com/", "gemini://example.com#top", "top", true},
+	{"gemini://example.com/page.gmi", "gemini://example.com/other.gmi#intro", "intro", false},
+	{"gemini://example.com/page.gmi", "gemini://example.com/page.gmi", "", false},
+}
+
+func TestSamePageFragment(t *testing.T) {
+	for _, tt := range samePageFragmentTests {
+		fragment, same := samePageFragment(tt.page, tt.link)
+		if fragment != tt.fragment || same != tt.same {
+			t.Errorf("samePageFragment(%s, %s): expected %q, %v, actual %q, %v",
+				tt.page, tt.link, tt.fragment, tt.same, fragment, same)
+		}
+	}
+}
+
+var pageTitleTests = []struct {
+	p        structs.Page
+	expected string
+}{
+	{structs.Page{URL: "gemini://example.com/", Mediatype: structs.TextGemini, Raw: "## Sub\n# Title \ntext"}, "Title"},
+	{structs.Page{URL: "gemini://example.com/", Mediatype: structs.TextGemini, Raw: "```\n# Not a title\n```\n# Title"}, "Title"},
+	{structs.Page{URL: "gemini://example.com/", Mediatype: structs.TextGemini, Raw: "# A very long title for a page"}, "A very long title f…"},
+	{structs.Page{URL: "gemini://example.com:1966/page", Mediatype: structs.TextGemini, Raw: "No headings"}, "example.com"},
+	{structs.Page{URL: "gemini://example.com/", Mediatype: structs.TextPlain, Raw: "# Plain text"}, "example.com"},
+	{structs.Page{URL: "about:newtab"}, "about:newtab"},
+	{structs.Page{URL: "file:///home/user/page.gmi"}, "page.gmi"},
+}
+
+func TestPageTitle(t *testing.T) {
+	for _, tt := range pageTitleTests {
+		actual := pageTitle(&tt.p)
+		if actual != tt.expected {
+			t.Errorf("pageTitle(%q) = %q, want %q", tt.p.URL, actual, tt.expected)
+		}
+	}
+}
+
+func TestCenterText(t *testing.T) {
+	oldW := termW
+	defer func() { termW = oldW }()
+	defer viper.Set("a-general.center_text", nil)
+	defer viper.Set("a-general.max_width", nil)
+	defer viper.Set("a-general.left_margin", nil)
+
+	viper.Set("a-general.max_width", 72)
+	viper.Set("a-general.left_margin", 10)
+	viper.Set("a-general.center_text", true)
+	for _, tt := range []struct {
+		termW, margin, width int
+	}{
+		{200, 64, 72}, // Centered
+		{100, 14, 72}, // Still centered, with some of the space on the right
+		{80, 10, 60},  // Too narrow to center, so the text is narrower instead
+	} {
+		termW = tt.termW
+		if margin, width := leftMargin(), textWidth(); margin != tt.margin || width != tt.width {
+			t.Errorf("with %d columns, the margin is %d and text is %d wide, want %d and %d",
+				tt.termW, margin, width, tt.margin, tt.width)
+		}
+	}
+
+	viper.Set("a-general.center_text", false)
+	termW = 200
+	if margin := leftMargin(); margin != 10 {
+		t.Errorf("the margin is %d without centering, want 10", margin)
+	}
+}
+
+func TestRootURL(t *testing.T) {
+	tests := []struct {
+		u    string
+		want string
+		ok   bool
+	}{
+		{"gemini://example.com/a/b/c.gmi?q#frag", "gemini://example.com/", true},
+		{"gemini://example.com", "gemini://example.com/", true},
+		{"gemini://example.com:1966/a/", "gemini://example.com:1966/", true},
+		{"gopher://example.org/1/phlog", "gopher://example.org/", true},
+		{"spartan://[::1]/dir/", "spartan://[::1]/", true},
+		{"about:bookmarks", "", false},
+		{"file:///home/user/page.gmi", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := rootURL(tt.u)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rootURL(%q) = %q, %v, want %q, %v", tt.u, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParentURL(t *testing.T) {
+	tests := []struct {
+		u    string
+		want string
+		ok   bool
+	}{
+		{"gemini://example.com/a/b/c", "gemini://example.com/a/b/", true},
+		{"gemini://example.com/a/b/c/", "gemini://example.com/a/b/", true},
+		{"gemini://example.com/a/b.gmi?query#frag", "gemini://example.com/a/", true},
+		{"gemini://example.com/a", "gemini://example.com/", true},
+		{"gemini://example.com/?query", "gemini://example.com/", true},
+		{"gemini://example.com/a%20b/c", "gemini://example.com/a%20b/", true},
+		{"file:///home/user/page.gmi", "file:///home/user/", true},
+		{"gemini://example.com/", "", false},
+		{"gemini://example.com", "", false},
+		{"about:bookmarks", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parentURL(tt.u)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parentURL(%q) = %q, %v, want %q, %v", tt.u, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+var urlKeyTests = []struct {
+	u     string
+	fold  bool
+	lower bool
+	want  string
+}{
+	{"gemini://Example.com/Dir/", false, true, "gemini://example.com/Dir/"},
+	{"gemini://Example.com/Dir/", false, false, "gemini://Example.com/Dir/"},
+	{"gemini://example.com/dir/", true, true, "gemini://example.com/dir"},
+	{"gemini://example.com/dir/?q", true, true, "gemini://example.com/dir?q"},
+	{"gemini://example.com/%E8%9B%B8/", true, true, "gemini://example.com/%E8%9B%B8"},
+	{"gemini://example.com/", true, true, "gemini://example.com/"}, // The root keeps its slash
+	{"about:cache", true, true, "about:cache"},
+}
+
+func TestURLKey(t *testing.T) {
+	defer viper.Set("cache.fold_trailing_slash", nil)
+	defer viper.Set("cache.lowercase_hosts", nil)
+	for _, tt := range urlKeyTests {
+		viper.Set("cache.fold_trailing_slash", tt.fold)
+		viper.Set("cache.lowercase_hosts", tt.lower)
+		if got := urlKey(tt.u); got != tt.want {
+			t.Errorf("urlKey(%q) with fold %v and lower %v = %q, want %q", tt.u, tt.fold, tt.lower, got, tt.want)
+		}
+	}
+}
+
+func TestURLKeyCache(t *testing.T) {
+	defer viper.Set("cache.fold_trailing_slash", nil)
+	defer viper.Set("cache.lowercase_hosts", nil)
+	viper.Set("cache.fold_trailing_slash", true)
+	viper.Set("cache.lowercase_hosts", true)
+	cache.SetKeyFunc(urlKey)
+	defer cache.SetKeyFunc(nil)
+	defer cache.ClearPages()
+
+	p := &structs.Page{URL: "gemini://Example.com/dir/", MadeAt: time.Now()}
+	cache.AddPage(p)
+	got, ok := cache.GetPage("gemini://example.com/dir")
+	if !ok {
+		t.Fatal("the page wasn't found by a URL with the same key")
+	}
+	if got.URL != p.URL {
+		t.Errorf("the cached page URL is %q, want %q", got.URL, p.URL)
+	}
+	if urls := cache.URLs(); len(urls) != 1 || urls[0] != p.URL {
+		t.Errorf("cache.URLs() = %q, want the page URL %q", urls, p.URL)
+	}
+}
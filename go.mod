@@ -3,6 +3,7 @@ module github.com/makeworld-the-better-one/amfora
 go 1.14
 
 require (
+	github.com/atotto/clipboard v0.1.4
 	github.com/dustin/go-humanize v1.0.0
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	github.com/gdamore/tcell/v2 v2.1.1-0.20210125004847-19e17097d8fe
@@ -23,6 +24,7 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.6.1
 	gitlab.com/tslocum/cview v1.5.4-0.20210207045010-d776e728ef6d
+	golang.org/x/net v0.0.0-20201216054612-986b41b23924
 	golang.org/x/text v0.3.5
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/ini.v1 v1.62.0 // indirect
@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"net"
+	urlPkg "net/url"
+	"strings"
+)
+
+// GopherToGemtext converts a Gopher menu into gemtext, so it can be rendered
+// like any other page. Menu items become link lines, and runs of info lines
+// are put in preformatted blocks, to keep any ASCII art aligned.
+func GopherToGemtext(menu string) string {
+	var b strings.Builder
+	pre := false
+
+	setPre := func(on bool) {
+		if pre != on {
+			b.WriteString("```\n")
+			pre = on
+		}
+	}
+
+	for _, line := range strings.Split(menu, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "." {
+			// End of the menu
+			break
+		}
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line[1:], "\t")
+		display := fields[0]
+
+		if line[0] == 'i' || line[0] == '3' || len(fields) < 4 {
+			// Info or error line, or one that's malformed
+			setPre(true)
+			if strings.HasPrefix(display, "```") {
+				// Don't end the block early
+				display = " " + display
+			}
+			b.WriteString(display + "\n")
+			continue
+		}
+
+		link := gopherLink(line[0], fields[1], fields[2], fields[3])
+		if link == "" {
+			continue
+		}
+		setPre(false)
+		if strings.TrimSpace(display) == "" {
+			display = link
+		}
+		b.WriteString("=> " + link + " " + display + "\n")
+	}
+	setPre(false)
+	return b.String()
+}
+
+// gopherLink returns the URL for a Gopher menu item,
+// or an empty string if it has no host.
+func gopherLink(itemType byte, selector, host, port string) string {
+	if itemType == 'h' && strings.HasPrefix(selector, "URL:") {
+		// Link to a URL of another scheme, a common extension to Gopher
+		return strings.TrimSpace(selector[4:])
+	}
+	if host == "" {
+		return ""
+	}
+
+	u := urlPkg.URL{Scheme: "gopher", Path: "/" + string(itemType) + selector}
+	switch itemType {
+	case '8':
+		u.Scheme, u.Path = "telnet", ""
+	case 'T':
+		u.Scheme, u.Path = "tn3270", ""
+	}
+	if port != "" && port != "0" && !(u.Scheme == "gopher" && port == "70") {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+	return u.String()
+}
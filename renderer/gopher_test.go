@@ -0,0 +1,34 @@
+package renderer
+
+import (
+	"testing"
+)
+
+var gopherToGemtextTests = []struct {
+	menu     string
+	expected string
+}{
+	{
+		"iWelcome\t\tnull.host\t1\r\ni  to my hole\tfake\t(NULL)\t0\r\n1Phlog\t/phlog\texample.com\t70\r\n.\r\n",
+		"```\nWelcome\n  to my hole\n```\n=> gopher://example.com/1/phlog Phlog\n",
+	},
+	{
+		"0About me\t/about.txt\texample.com\t7070\r\n9File\t/f.zip\texample.com\t70\r\n",
+		"=> gopher://example.com:7070/0/about.txt About me\n=> gopher://example.com/9/f.zip File\n",
+	},
+	{
+		"hWeb\tURL:https://example.com/\texample.com\t70\r\n8Telnet\t\tbbs.example.com\t23\r\n",
+		"=> https://example.com/ Web\n=> telnet://bbs.example.com:23 Telnet\n",
+	},
+	{"3Not found\t\terror.host\t1\r\n.\r\n1After\t/\texample.com\t70\r\n", "```\nNot found\n```\n"},
+	{"i```\t\t\t\r\n1No host\t/\t\t70\r\n", "```\n ```\n```\n"},
+}
+
+func TestGopherToGemtext(t *testing.T) {
+	for _, tt := range gopherToGemtextTests {
+		actual := GopherToGemtext(tt.menu)
+		if actual != tt.expected {
+			t.Errorf("GopherToGemtext(%q): expected %q, actual %q", tt.menu, tt.expected, actual)
+		}
+	}
+}
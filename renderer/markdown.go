@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markdown is rendered by converting it to gemtext first, so that it looks
+// the same as any other Gemini page.
+
+// Regex for inline links and images: [text](url) and ![alt](url "title")
+var mdLinkRegex = regexp.MustCompile(`!?\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+// Regex for autolinks: <gemini://example.com>
+var mdAutolinkRegex = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]*:[^<>\s]+)>`)
+
+var mdHeadingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+var mdListRegex = regexp.MustCompile(`^\s*[-+*]\s+(.*)$`)
+var mdFenceRegex = regexp.MustCompile("^ {0,3}(```|~~~)(.*)$")
+
+// markdownToGemini converts text/markdown into text/gemini.
+//
+// Inline links can't exist in gemtext, so the link text is left in place
+// and the link itself is added on its own line, after the line it was in.
+// Code blocks become preformatted blocks, with the language as the alt text.
+func markdownToGemini(s string) string {
+	lines := strings.Split(s, "\n")
+	gemtext := make([]string, 0, len(lines))
+	fence := "" // The fence characters that opened the current code block, if any
+
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil &&
+			(fence == "" || (m[1] == fence && strings.TrimSpace(m[2]) == "")) {
+			if fence == "" {
+				// Start of code block
+				fence = m[1]
+				gemtext = append(gemtext, "```"+strings.TrimSpace(m[2]))
+			} else {
+				fence = ""
+				gemtext = append(gemtext, "```")
+			}
+			continue
+		}
+		if fence != "" {
+			// Inside a code block, nothing is changed
+			gemtext = append(gemtext, line)
+			continue
+		}
+
+		linkLines := make([]string, 0)
+		line = mdLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+			m := mdLinkRegex.FindStringSubmatch(link)
+			text := strings.TrimSpace(m[1])
+			if text == "" {
+				text = m[2]
+			}
+			linkLines = append(linkLines, "=> "+m[2]+" "+text)
+			return text
+		})
+		line = mdAutolinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+			u := mdAutolinkRegex.FindStringSubmatch(link)[1]
+			linkLines = append(linkLines, "=> "+u)
+			return u
+		})
+
+		if m := mdHeadingRegex.FindStringSubmatch(line); m != nil {
+			// Gemtext only has three levels of headings
+			level := len(m[1])
+			if level > 3 {
+				level = 3
+			}
+			line = strings.Repeat("#", level) + " " + m[2]
+		} else if m := mdListRegex.FindStringSubmatch(line); m != nil {
+			line = "* " + m[1]
+		}
+
+		gemtext = append(gemtext, line)
+		gemtext = append(gemtext, linkLines...)
+	}
+	return strings.Join(gemtext, "\n")
+}
+
+// RenderMarkdown converts text/markdown into a cview displayable format,
+// by rendering it as gemtext. It also returns a slice of link URLs.
+//
+// ErrCantDisplay is returned if the conversion fails, in which case
+// the document should be rendered as plaintext instead.
+//
+// proxied is whether the request is through the gemini:// scheme.
+// If it's not a gemini:// page, set this to true.
+// pageURL is used like in RenderGeminiTOC.
+func RenderMarkdown(s string, width int, proxied bool, pageURL string) (rendered string, links []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			rendered, links, err = "", nil, ErrCantDisplay
+		}
+	}()
+
+	rendered, links, _ = RenderGeminiTOC(markdownToGemini(s), width, proxied, pageURL)
+	return rendered, links, nil
+}
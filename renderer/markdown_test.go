@@ -0,0 +1,32 @@
+package renderer
+
+import (
+	"testing"
+)
+
+var markdownToGeminiTests = []struct {
+	md       string
+	expected string
+}{
+	{"# Title", "# Title"},
+	{"#### Deep heading ##", "### Deep heading"},
+	{"- item", "* item"},
+	{"  + nested item", "* nested item"},
+	{"> quote", "> quote"},
+	{"Plain text.", "Plain text."},
+	{"See [the site](gemini://example.com) for more.", "See the site for more.\n=> gemini://example.com the site"},
+	{"![](image.png)", "image.png\n=> image.png image.png"},
+	{"[a](one.gmi) and [b](two.gmi \"Title\")", "a and b\n=> one.gmi a\n=> two.gmi b"},
+	{"Go to <gemini://example.com/>", "Go to gemini://example.com/\n=> gemini://example.com/"},
+	{"```go\nfunc main() {}\n```", "```go\nfunc main() {}\n```"},
+	{"~~~\n# not a heading\n~~~", "```\n# not a heading\n```"},
+}
+
+func TestMarkdownToGemini(t *testing.T) {
+	for _, tt := range markdownToGeminiTests {
+		actual := markdownToGemini(tt.md)
+		if actual != tt.expected {
+			t.Errorf("markdownToGemini(%q): expected %q, actual %q", tt.md, tt.expected, actual)
+		}
+	}
+}
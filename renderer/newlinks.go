@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// The region tag of a link, and the color tag after it if there is one.
+// Region tags in the text are escaped, so they don't match.
+var linkRegionRegex = regexp.MustCompile(`\["([0-9]*)"\](\[#[0-9a-fA-F]{6}\])?`)
+
+// NewLinks returns whether each of the links isn't in old, the links of the
+// page from before. It returns nil if none of them are new, or old is empty.
+func NewLinks(old, links []string) []bool {
+	if len(old) == 0 {
+		// Nothing to compare to
+		return nil
+	}
+	oldSet := make(map[string]struct{}, len(old))
+	for _, link := range old {
+		oldSet[link] = struct{}{}
+	}
+	var isNew []bool
+	for i, link := range links {
+		if _, ok := oldSet[link]; ok {
+			continue
+		}
+		if isNew == nil {
+			isNew = make([]bool, len(links))
+		}
+		isNew[i] = true
+	}
+	return isNew
+}
+
+// MarkNewLinks changes the color of the new links in the rendered content to
+// the new_link color, or makes them bold if colors are disabled. isNew is
+// from NewLinks, and the region IDs of the content are the link indexes.
+func MarkNewLinks(content string, isNew []bool) string {
+	if len(isNew) == 0 {
+		return content
+	}
+	color := viper.GetBool("a-general.color")
+	inNew := false // Whether the last region opened is a new link
+	return linkRegionRegex.ReplaceAllStringFunc(content, func(tag string) string {
+		m := linkRegionRegex.FindStringSubmatch(tag)
+		if m[1] == "" {
+			// The end of a region
+			if inNew && !color {
+				tag = "[::-]" + tag
+			}
+			inNew = false
+			return tag
+		}
+		i, _ := strconv.Atoi(m[1])
+		inNew = i < len(isNew) && isNew[i]
+		if !inNew {
+			return tag
+		}
+		if color {
+			return `["` + m[1] + `"][` + config.GetColorString("new_link") + `]`
+		}
+		return tag + "[::b]"
+	})
+}
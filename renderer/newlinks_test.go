@@ -0,0 +1,44 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+var newLinksTests = []struct {
+	old   []string
+	links []string
+	want  []bool
+}{
+	{nil, []string{"/a"}, nil},
+	{[]string{"/a", "/b"}, []string{"/b", "/a"}, nil},
+	{[]string{"/a"}, []string{"/c", "/a", "/d"}, []bool{true, false, true}},
+}
+
+func TestNewLinks(t *testing.T) {
+	for _, tt := range newLinksTests {
+		if got := NewLinks(tt.old, tt.links); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewLinks(%q, %q) = %v, want %v", tt.old, tt.links, got, tt.want)
+		}
+	}
+}
+
+func TestMarkNewLinks(t *testing.T) {
+	viper.Set("a-general.color", false)
+	defer viper.Set("a-general.color", nil)
+
+	content := `[::b][1[][::-]  ["0"]One[""]` + "\r\n" +
+		`[::b][2[][::-]  ["1"]Two["1"[] wrapped[""]` + "\r\n" +
+		`     ["1"]more[""]`
+	want := `[::b][1[][::-]  ["0"]One[""]` + "\r\n" +
+		`[::b][2[][::-]  ["1"][::b]Two["1"[] wrapped[::-][""]` + "\r\n" +
+		`     ["1"][::b]more[::-][""]`
+	if got := MarkNewLinks(content, []bool{false, true}); got != want {
+		t.Errorf("MarkNewLinks() = %q, want %q", got, want)
+	}
+	if got := MarkNewLinks(content, nil); got != content {
+		t.Errorf("MarkNewLinks() with no new links = %q, want it unchanged", got)
+	}
+}
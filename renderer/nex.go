@@ -0,0 +1,39 @@
+package renderer
+
+import (
+	"strings"
+)
+
+// NexToGemtext converts a Nex directory listing into gemtext, so it can be
+// rendered like any other page. Lines starting with => are links like in
+// gemtext, and runs of the other lines are put in preformatted blocks,
+// because they're plain text.
+func NexToGemtext(listing string) string {
+	var b strings.Builder
+	pre := false
+
+	setPre := func(on bool) {
+		if pre != on {
+			b.WriteString("```\n")
+			pre = on
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(listing, "\n"), "\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "=>") {
+			setPre(false)
+			b.WriteString(line + "\n")
+			continue
+		}
+		setPre(true)
+		if strings.HasPrefix(line, "```") {
+			// Don't end the block early
+			line = " " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	setPre(false)
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package renderer
+
+import (
+	"testing"
+)
+
+var nexToGemtextTests = []struct {
+	listing  string
+	expected string
+}{
+	{
+		"Welcome\r\n\r\n=> about.txt About\n=> docs/\n",
+		"```\nWelcome\n\n```\n=> about.txt About\n=> docs/\n",
+	},
+	{"=> nex://example.com/ Home\nBye", "=> nex://example.com/ Home\n```\nBye\n```\n"},
+	{"```\n", "```\n ```\n```\n"},
+}
+
+func TestNexToGemtext(t *testing.T) {
+	for _, tt := range nexToGemtextTests {
+		actual := NexToGemtext(tt.listing)
+		if actual != tt.expected {
+			t.Errorf("NexToGemtext(%q): expected %q, actual %q", tt.listing, tt.expected, actual)
+		}
+	}
+}
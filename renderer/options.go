@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Options are the settings gemtext is rendered with by RenderGemtext.
+// Amfora gets them from its config with ConfigOptions, but they can be set
+// directly to render gemtext outside of it.
+type Options struct {
+	Width   int    // The number of columns to wrap to
+	Proxied bool   // Whether the page isn't from a gemini:// URL, so none of its links are colored as Gemini links
+	PageURL string // The URL of the page, so links that leave its site can be colored differently. Can be empty.
+
+	Color bool              // Whether to use the Theme colors, or just bold and italics
+	Theme map[string]string // Colors for the theme keys like "amfora_link", as "#rrggbb". Missing keys are black.
+	ANSI  bool              // Whether ANSI colors in preformatted blocks are displayed, see the ansi option
+
+	Tables               bool   // Whether gemtext tables are lined up, see the tables option
+	ShowLink             bool   // Whether the URL of links is shown after their text
+	ShowLinkNumbers      bool   // Whether the number of links is shown before them, instead of an arrow
+	SchemeBadges         bool   // Whether links to other schemes than Gemini have a badge, like [http]
+	SubdomainsSameSite   bool   // Whether links to subdomains are part of the site of PageURL
+	PreformattedCaptions bool   // Whether the alt text of preformatted blocks is shown above them
+	Bullet               string // What list items start with, * is kept if it's empty
+	QuotePrefix          string // What quote lines start with
+
+	FoldPre int          // Preformatted blocks with more lines than this are folded, unless Folds says otherwise. 0 folds none.
+	Folds   map[int]bool // Whether each preformatted block is folded, by its number on the page, for those longer than FoldPre
+}
+
+// Rendered is a gemtext document converted by RenderGemtext, with the fields
+// of a structs.Page it's used for.
+type Rendered struct {
+	Content    string             // The text with cview color tags, and a region for each link
+	Links      []string           // The URL of each link, in the order of their regions
+	Headings   []structs.Heading  // The headings, with the rows of Content they start on
+	MaxPreCols int                // The width of the longest preformatted line
+	PreBlocks  []structs.PreBlock // The preformatted blocks that can be folded, longer than FoldPre
+}
+
+// ConfigOptions returns the Options set by the config and the theme.
+func ConfigOptions(width int, proxied bool, pageURL string) Options {
+	bullet := "*"
+	if viper.GetBool("a-general.bullets") {
+		bullet = viper.GetString("a-general.bullet")
+	}
+	return Options{
+		Width:                width,
+		Proxied:              proxied,
+		PageURL:              pageURL,
+		Color:                viper.GetBool("a-general.color"),
+		Theme:                config.ColorStrings(),
+		ANSI:                 viper.GetBool("a-general.ansi"),
+		Tables:               viper.GetBool("a-general.tables"),
+		ShowLink:             viper.GetBool("a-general.show_link"),
+		ShowLinkNumbers:      viper.GetBool("a-general.show_link_numbers"),
+		SchemeBadges:         viper.GetBool("a-general.scheme_badges"),
+		SubdomainsSameSite:   viper.GetBool("a-general.subdomains_same_site"),
+		PreformattedCaptions: viper.GetBool("a-general.preformatted_captions"),
+		Bullet:               bullet,
+		QuotePrefix:          viper.GetString("a-general.quote_prefix"),
+	}
+}
+
+// PageOptions returns the ConfigOptions for a text/gemini page, which also fold
+// its long preformatted blocks like the fold_preformatted option says. folds
+// are the blocks folded or unfolded by hand, see structs.Page.Folds.
+func PageOptions(width int, proxied bool, pageURL string, folds map[int]bool) Options {
+	opts := ConfigOptions(width, proxied, pageURL)
+	opts.FoldPre = viper.GetInt("a-general.fold_preformatted")
+	opts.Folds = folds
+	return opts
+}
+
+// color returns the color of the theme key, for a cview color tag.
+func (o *Options) color(key string) string {
+	if c, ok := o.Theme[key]; ok {
+		return c
+	}
+	return "#000000"
+}
+
+// hasColor returns true if the theme has a color for the key.
+func (o *Options) hasColor(key string) bool {
+	_, ok := o.Theme[key]
+	return ok
+}
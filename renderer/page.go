@@ -3,15 +3,20 @@ package renderer
 import (
 	"bytes"
 	"errors"
+	"image"
+	_ "image/jpeg" // Register decoders for image pages
+	_ "image/png"
 	"io"
 	"mime"
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/go-gemini"
 	"github.com/spf13/viper"
+	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/ianaindex"
 )
 
@@ -73,10 +78,23 @@ func CanDisplay(res *gemini.Response) bool {
 	return err == nil && enc != nil
 }
 
+// IsImage returns true if the response is an image that can be decoded and
+// put in a Page struct. Whether the terminal can display it is not checked.
+func IsImage(res *gemini.Response) bool {
+	if gemini.SimplifyStatus(res.Status) != 20 {
+		return false
+	}
+	mediatype, _, err := decodeMeta(res.Meta)
+	if err != nil {
+		return false
+	}
+	return mediatype == string(structs.ImagePNG) || mediatype == string(structs.ImageJPEG)
+}
+
 // MakePage creates a formatted, rendered Page from the given network response and params.
 // You must set the Page.Width value yourself.
 func MakePage(url string, res *gemini.Response, width int, proxied bool) (*structs.Page, error) {
-	if !CanDisplay(res) {
+	if !CanDisplay(res) && !IsImage(res) {
 		return nil, ErrCantDisplay
 	}
 
@@ -101,24 +119,143 @@ func MakePage(url string, res *gemini.Response, width int, proxied bool) (*struc
 
 	mediatype, params, _ := decodeMeta(res.Meta)
 
-	// Convert content first
-	var utfText string
-	if isUTF8(params["charset"]) {
-		utfText = buf.String()
-	} else {
-		encoding, err := ianaindex.MIME.Encoding(params["charset"])
-		if encoding == nil || err != nil {
-			// Some encoding doesn't exist and wasn't caught in CanDisplay()
-			return nil, ErrBadEncoding
-		}
-		utfText, err = encoding.NewDecoder().String(buf.String())
+	if IsImage(res) {
+		// Make sure it can be decoded later
+		_, _, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
 		if err != nil {
-			return nil, err
+			return nil, ErrCantDisplay
 		}
+		return &structs.Page{
+			Mediatype:    structs.Mediatype(mediatype),
+			RawMediatype: mediatype,
+			Status:       res.Status,
+			URL:          url,
+			Raw:          buf.String(),
+			Graphic:      true,
+			Links:        []string{},
+			MadeAt:       time.Now(),
+		}, nil
+	}
+
+	// Menus and listings are converted before the text is decoded, so they can
+	// be decoded again like text, see Redecode. Their structure is all ASCII.
+	text := buf.String()
+	if mediatype == string(structs.GopherMenu) {
+		// Displayed as the gemtext it's converted to
+		text = GopherToGemtext(text)
+		mediatype = "text/gemini"
+	} else if mediatype == string(structs.NexDirectory) {
+		text = NexToGemtext(text)
+		mediatype = "text/gemini"
+	}
+
+	utfText, charset, err := decodeText(text, params["charset"])
+	if err != nil {
+		return nil, err
+	}
+	page, err := textPage(url, mediatype, utfText, width, proxied)
+	if err != nil {
+		return nil, err
+	}
+	page.Status = res.Status
+	page.Charset = charset
+	if utfText != text {
+		page.Encoded = text
+	}
+	return page, nil
+}
+
+// decodeText converts the text from the charset to UTF-8, and returns the
+// name of the charset it was decoded from, or an empty string for UTF-8.
+//
+// Text without a charset that isn't valid UTF-8 is most likely from an older
+// server that sends Latin-1, like many Gopher and Finger servers. It is decoded
+// as Windows-1252, which has the same letters and a few more.
+func decodeText(text, charset string) (string, string, error) {
+	if isUTF8(charset) {
+		if charset != "" || utf8.ValidString(text) {
+			return text, "", nil
+		}
+		utfText, err := charmap.Windows1252.NewDecoder().String(text)
+		return utfText, "windows-1252", err
+	}
+
+	encoding, err := ianaindex.MIME.Encoding(charset)
+	if encoding == nil || err != nil {
+		// Some encoding doesn't exist and wasn't caught in CanDisplay()
+		return "", "", ErrBadEncoding
 	}
+	utfText, err := encoding.NewDecoder().String(text)
+	if err != nil {
+		return "", "", err
+	}
+	name, err := ianaindex.MIME.Name(encoding)
+	if err != nil {
+		name = strings.ToLower(charset)
+	}
+	return utfText, strings.ToLower(name), nil
+}
 
-	if mediatype == "text/gemini" {
-		rendered, links := RenderGemini(utfText, width, proxied)
+// Redecode decodes the text of the page again with the charset, instead of
+// the one it was decoded from when it was made. It's for pages that were
+// sent with the wrong one, or none. ErrBadEncoding is returned for charsets
+// that aren't supported. The page has to be reformatted afterwards, as its
+// Content isn't changed. Its links are kept, since they're ASCII URLs, or
+// escaped to be for Gopher menus.
+func Redecode(p *structs.Page, charset string) error {
+	if p.Graphic {
+		return ErrCantDisplay
+	}
+	text := p.Encoded
+	if text == "" {
+		text = p.Raw
+	}
+	if charset == "" {
+		charset = "utf-8"
+	}
+	utfText, name, err := decodeText(text, charset)
+	if err != nil {
+		return err
+	}
+	p.Raw = utfText
+	p.Charset = name
+	p.Encoded = ""
+	if utfText != text {
+		p.Encoded = text
+	}
+	return nil
+}
+
+// CanStream returns true if the response is text that can be displayed while
+// it's still arriving, see MakeStreamPage. Only plain text and gemtext in
+// UTF-8 can be, as the rest of the response may change how the start of it
+// is displayed for other types.
+func CanStream(res *gemini.Response) bool {
+	if !CanDisplay(res) {
+		return false
+	}
+	mediatype, params, _ := decodeMeta(res.Meta)
+	return (mediatype == "text/plain" || mediatype == "text/gemini") && isUTF8(params["charset"])
+}
+
+// MakeStreamPage creates a formatted, rendered Page from the text of the
+// response that has been read so far, for responses where CanStream is true.
+// It can be called again as more of the response arrives.
+// You must set the Page.Width value yourself.
+func MakeStreamPage(url string, res *gemini.Response, text string, width int, proxied bool) (*structs.Page, error) {
+	mediatype, _, _ := decodeMeta(res.Meta)
+	page, err := textPage(url, mediatype, text, width, proxied)
+	if err != nil {
+		return nil, err
+	}
+	page.Status = res.Status
+	return page, nil
+}
+
+// textPage creates a Page from the UTF-8 text of a response with the mediatype.
+func textPage(url, mediatype, utfText string, width int, proxied bool) (*structs.Page, error) {
+	if mediatype == "text/gemini" && strings.HasPrefix(url, "spartan://") {
+		rendered, links, prompts, headings := RenderSpartan(utfText, width)
 		return &structs.Page{
 			Mediatype:    structs.TextGemini,
 			RawMediatype: mediatype,
@@ -126,9 +263,42 @@ func MakePage(url string, res *gemini.Response, width int, proxied bool) (*struc
 			Raw:          utfText,
 			Content:      rendered,
 			Links:        links,
+			Prompts:      prompts,
+			Headings:     headings,
+			MaxPreCols:   MaxPreCols(utfText, structs.TextGemini),
+			MadeAt:       time.Now(),
+		}, nil
+	} else if mediatype == "text/gemini" {
+		r := RenderGemtext(utfText, PageOptions(width, proxied, url, nil))
+		return &structs.Page{
+			Mediatype:    structs.TextGemini,
+			RawMediatype: mediatype,
+			URL:          url,
+			Raw:          utfText,
+			Content:      r.Content,
+			Links:        r.Links,
+			Headings:     r.Headings,
+			PreBlocks:    r.PreBlocks,
+			MaxPreCols:   MaxPreCols(utfText, structs.TextGemini),
 			MadeAt:       time.Now(),
 		}, nil
 	} else if strings.HasPrefix(mediatype, "text/") {
+		if mediatype == "text/markdown" || strings.HasSuffix(url, ".md") {
+			rendered, links, err := RenderMarkdown(utfText, width, proxied, url)
+			if err == nil {
+				return &structs.Page{
+					Mediatype:    structs.TextMarkdown,
+					RawMediatype: mediatype,
+					URL:          url,
+					Raw:          utfText,
+					Content:      rendered,
+					Links:        links,
+					MaxPreCols:   MaxPreCols(utfText, structs.TextMarkdown),
+					MadeAt:       time.Now(),
+				}, nil
+			}
+			// Otherwise it's displayed as plaintext below
+		}
 		if mediatype == "text/x-ansi" || strings.HasSuffix(url, ".ans") || strings.HasSuffix(url, ".ansi") {
 			// ANSI
 			return &structs.Page{
@@ -138,6 +308,7 @@ func MakePage(url string, res *gemini.Response, width int, proxied bool) (*struc
 				Raw:          utfText,
 				Content:      RenderANSI(utfText),
 				Links:        []string{},
+				MaxPreCols:   MaxPreCols(utfText, structs.TextAnsi),
 				MadeAt:       time.Now(),
 			}, nil
 		}
@@ -150,6 +321,7 @@ func MakePage(url string, res *gemini.Response, width int, proxied bool) (*struc
 			Raw:          utfText,
 			Content:      RenderPlainText(utfText),
 			Links:        []string{},
+			MaxPreCols:   MaxPreCols(utfText, structs.TextPlain),
 			MadeAt:       time.Now(),
 		}, nil
 	}
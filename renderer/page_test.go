@@ -0,0 +1,51 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		text        string
+		charset     string
+		want        string
+		wantCharset string
+	}{
+		{"caf\xc3\xa9", "", "café", ""},
+		{"caf\xc3\xa9", "UTF-8", "café", ""},
+		{"caf\xe9", "", "café", "windows-1252"},
+		{"caf\xe9", "iso-8859-1", "café", "iso-8859-1"},
+		{"\x93quoted\x94", "", "“quoted”", "windows-1252"},
+	}
+	for _, tt := range tests {
+		got, charset, err := decodeText(tt.text, tt.charset)
+		if err != nil || got != tt.want || charset != tt.wantCharset {
+			t.Errorf("decodeText(%q, %q) = %q, %q, %v, want %q, %q",
+				tt.text, tt.charset, got, charset, err, tt.want, tt.wantCharset)
+		}
+	}
+
+	if _, _, err := decodeText("text", "not-a-charset"); err != ErrBadEncoding {
+		t.Errorf("decodeText with an unknown charset returned %v, want ErrBadEncoding", err)
+	}
+}
+
+func TestRedecode(t *testing.T) {
+	p := structs.Page{Raw: "caf\xc3\xa9", Mediatype: structs.TextPlain}
+	if err := Redecode(&p, "iso-8859-1"); err != nil {
+		t.Fatal(err)
+	}
+	if p.Raw != "cafÃ©" || p.Charset != "iso-8859-1" || p.Encoded != "caf\xc3\xa9" {
+		t.Fatalf("Redecode as iso-8859-1 = %+v", p)
+	}
+
+	// Going back uses the text as it was sent, not the decoded one
+	if err := Redecode(&p, "utf-8"); err != nil {
+		t.Fatal(err)
+	}
+	if p.Raw != "café" || p.Charset != "" || p.Encoded != "" {
+		t.Errorf("Redecode as utf-8 = %+v", p)
+	}
+}
@@ -10,26 +10,149 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 
-	"github.com/makeworld-the-better-one/amfora/config"
-	"github.com/spf13/viper"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/mattn/go-runewidth"
 	"gitlab.com/tslocum/cview"
 )
 
 // Regex for identifying ANSI color codes
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// Regex for identifying all ANSI escape sequences: CSI sequences like
+// colors and cursor movement, OSC sequences like window titles, and
+// two character escapes like charset selection.
+var ansiEscapeRegex = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)?|[ -/]*[0-~])`)
+
+// Cursor forward sequences are turned into at most this many spaces
+const maxANSISpaces = 1000
+
+// cleanANSI removes the ANSI escape sequences that can't be displayed,
+// leaving only color and style (SGR) sequences. Cursor forward sequences,
+// often used by ANSI art instead of spaces, are replaced with spaces.
+func cleanANSI(s string) string {
+	return ansiEscapeRegex.ReplaceAllStringFunc(s, func(seq string) string {
+		if !strings.HasPrefix(seq, "\x1b[") {
+			return ""
+		}
+		switch seq[len(seq)-1] {
+		case 'm':
+			if ansiRegex.MatchString(seq) {
+				return seq
+			}
+		case 'C':
+			n, err := strconv.Atoi(seq[2 : len(seq)-1])
+			if err != nil || n < 1 {
+				n = 1
+			}
+			if n > maxANSISpaces {
+				n = maxANSISpaces
+			}
+			return strings.Repeat(" ", n)
+		}
+		return ""
+	})
+}
+
+// ansiColorKeys are the theme keys for the 16 ANSI colors, in order.
+// The colors are only changed for the ones that are set in the theme.
+var ansiColorKeys = [16]string{
+	"ansi_black", "ansi_red", "ansi_green", "ansi_yellow",
+	"ansi_blue", "ansi_magenta", "ansi_cyan", "ansi_white",
+	"ansi_bright_black", "ansi_bright_red", "ansi_bright_green", "ansi_bright_yellow",
+	"ansi_bright_blue", "ansi_bright_magenta", "ansi_bright_cyan", "ansi_bright_white",
+}
+
+// ansiPalette returns the truecolor SGR values, like "255;0;0", that the
+// 16 ANSI colors are changed to by the theme, with colors like "#ff0000".
+// They're empty for the colors that aren't changed.
+func ansiPalette(theme map[string]string) [16]string {
+	var palette [16]string
+	for i, key := range ansiColorKeys {
+		c, ok := theme[key]
+		if !ok || len(c) != 7 || c[0] != '#' {
+			continue
+		}
+		rgb, err := strconv.ParseUint(c[1:], 16, 32)
+		if err != nil {
+			continue
+		}
+		palette[i] = fmt.Sprintf("%d;%d;%d", rgb>>16, (rgb>>8)&0xff, rgb&0xff)
+	}
+	return palette
+}
+
+// remapANSI replaces the 16 ANSI colors used in the SGR sequences of s with
+// the truecolor ones from the palette, see ansiPalette. That includes them
+// as the first 16 of the 256 colors. Truecolor and other 256 colors are left alone.
+func remapANSI(s string, palette [16]string) string {
+	if palette == [16]string{} {
+		return s
+	}
+	return ansiRegex.ReplaceAllStringFunc(s, func(seq string) string {
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		out := make([]string, 0, len(params))
+		for i := 0; i < len(params); i++ {
+			n, err := strconv.Atoi(params[i])
+			if err != nil {
+				out = append(out, params[i])
+				continue
+			}
+			color, target := -1, "38"
+			switch {
+			case (n == 38 || n == 48) && i+2 < len(params) && params[i+1] == "5":
+				// 256 colors
+				if c, err := strconv.Atoi(params[i+2]); err == nil && c < 16 && palette[c] != "" {
+					out = append(out, strconv.Itoa(n)+";2;"+palette[c])
+				} else {
+					out = append(out, params[i:i+3]...)
+				}
+				i += 2
+				continue
+			case (n == 38 || n == 48) && i+1 < len(params) && params[i+1] == "2":
+				// Truecolor, with the R, G, and B values after it
+				end := i + 5
+				if end > len(params) {
+					end = len(params)
+				}
+				out = append(out, params[i:end]...)
+				i = end - 1
+				continue
+			case n >= 30 && n <= 37:
+				color = n - 30
+			case n >= 90 && n <= 97:
+				color = n - 90 + 8
+			case n >= 40 && n <= 47:
+				color, target = n-40, "48"
+			case n >= 100 && n <= 107:
+				color, target = n-100+8, "48"
+			}
+			if color != -1 && palette[color] != "" {
+				out = append(out, target+";2;"+palette[color])
+			} else {
+				out = append(out, params[i])
+			}
+		}
+		return "\x1b[" + strings.Join(out, ";") + "m"
+	})
+}
+
 // RenderANSI renders plain text pages containing ANSI codes.
 // Practically, it is used for the text/x-ansi.
+//
+// Colors and styles are translated, including 256 color and truecolor ones,
+// and other sequences like cursor movement are removed. The 16 ANSI colors
+// are changed to the ones set in the theme, if there are any.
 func RenderANSI(s string) string {
-	s = cview.Escape(s)
-	if viper.GetBool("a-general.color") && viper.GetBool("a-general.ansi") {
-		s = cview.TranslateANSI(s)
+	s = cview.Escape(cleanANSI(s))
+	if opts := ConfigOptions(0, false, ""); opts.Color && opts.ANSI {
+		s = cview.TranslateANSI(remapANSI(s, ansiPalette(opts.Theme)))
 		// The TranslateANSI function injects tags like [-:-:-]
 		// but this will reset the background to use the user's terminal color.
 		// These tags need to be replaced with resets that use the theme color.
 		s = strings.ReplaceAll(s, "[-:-:-]",
-			fmt.Sprintf("[-:%s:-]", config.GetColorString("bg")))
+			fmt.Sprintf("[-:%s:-]", opts.color("bg")))
 	} else {
 		s = ansiRegex.ReplaceAllString(s, "")
 	}
@@ -44,6 +167,59 @@ func RenderPlainText(s string) string {
 	return cview.Escape(s)
 }
 
+// WrapText wraps the lines of rendered plain text or ANSI documents that are
+// longer than the width. Every line of those documents is treated as
+// preformatted, so this is only done if the user chooses to.
+func WrapText(s string, width int) string {
+	lines := strings.Split(s, "\n")
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		wrapped = append(wrapped, wrapLine(line, width, "", "", false)...)
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+// RenderSource renders the raw text of any page as is, for viewing its source.
+// ANSI escape characters are replaced with ^[ so they can be seen.
+func RenderSource(s string) string {
+	return strings.ReplaceAll(cview.Escape(s), "\x1b", "^[")
+}
+
+// MaxPreCols returns the number of terminal columns the longest preformatted
+// line of the raw content takes up. For gemtext and markdown only preformatted
+// blocks are used, for other documents every line is considered preformatted.
+//
+// It is used to limit how far a page can be scrolled horizontally.
+func MaxPreCols(s string, mediatype structs.Mediatype) int {
+	if mediatype == structs.TextMarkdown {
+		// Code blocks are converted to preformatted blocks
+		s = markdownToGemini(s)
+		mediatype = structs.TextGemini
+	}
+
+	max := 0
+	pre := mediatype != structs.TextGemini
+	for _, line := range strings.Split(s, "\n") {
+		if mediatype == structs.TextGemini && strings.HasPrefix(line, "```") {
+			pre = !pre
+			continue
+		}
+		if !pre {
+			continue
+		}
+		// ANSI codes don't take up any space once rendered
+		line = ansiRegex.ReplaceAllString(cleanANSI(strings.TrimSuffix(line, "\r")), "")
+		if w := runewidth.StringWidth(line); w > max {
+			max = w
+		}
+	}
+	return max
+}
+
 // wrapLine wraps a line to the provided width, and adds the provided prefix and suffix to each wrapped line.
 // It recovers from wrapping panics and should never cause a panic.
 // It returns a slice of lines, without newlines at the end.
@@ -76,36 +252,115 @@ func wrapLine(line string, width int, prefix, suffix string, includeFirst bool)
 	return ret
 }
 
+// wrapListItem wraps the text of a list item, adding a bullet to the first line.
+// The wrapped lines are indented to line up with the text after the bullet.
+// If there's no bullet in the options, the asterisk is kept instead.
+func wrapListItem(text string, opts *Options) []string {
+	width := opts.Width
+	bullet := opts.Bullet
+	if bullet == "" {
+		bullet = "*"
+	}
+	prefix := " " + bullet + " "
+	indent := runewidth.StringWidth(prefix)
+	if indent >= width {
+		// Too narrow for a hanging indent
+		indent = 0
+	}
+	prefix = cview.Escape(prefix)
+
+	color := fmt.Sprintf("[%s]", opts.color("list_text"))
+	wrapped := wrapLine(text, width-indent, strings.Repeat(" ", indent)+color, "[-]", false)
+	wrapped[0] = color + prefix + wrapped[0] + "[-]"
+	return wrapped
+}
+
+// schemeBadge returns the badge shown before the text of a link to a URL with
+// the scheme, like [http], so it's clear the link leaves Gemini. It's empty for
+// Gemini and relative links, or if badges are disabled. The color is from the
+// scheme_badge_SCHEME theme key, or scheme_badge if that isn't set.
+func schemeBadge(scheme string, opts *Options) string {
+	if scheme == "" || scheme == "gemini" || scheme == "about" || !opts.SchemeBadges {
+		return ""
+	}
+	badge := "[" + cview.Escape(scheme) + "[] "
+	if !opts.Color {
+		return badge
+	}
+	key := "scheme_badge_" + scheme
+	if !opts.hasColor(key) {
+		key = "scheme_badge"
+	}
+	return fmt.Sprintf("[%s]", opts.color(key)) + badge + "[-]"
+}
+
 // convertRegularGemini converts non-preformatted blocks of text/gemini
 // into a cview-compatible format.
 // Since this only works on non-preformatted blocks, RenderGemini
 // should always be used instead.
 //
-// It also returns a slice of link URLs.
+// It also returns a slice of link URLs, and the headings with their rows
+// counted from the start of s.
 // numLinks is the number of links that exist so far.
-// width is the number of columns to wrap to.
-//
 //
-// proxied is whether the request is through the gemini:// scheme.
-// If it's not a gemini:// page, set this to true.
-func convertRegularGemini(s string, numLinks, width int, proxied bool) (string, []string) {
+// base is the parsed PageURL of the options, to tell links to the same site
+// from the others. It can be nil if the page isn't from a server.
+func convertRegularGemini(s string, numLinks int, opts *Options, base *urlPkg.URL) (string, []string, []structs.Heading) {
+	width := opts.Width
+	proxied := opts.Proxied
 	links := make([]string, 0)
+	headings := make([]structs.Heading, 0)
 	lines := strings.Split(s, "\n")
 	wrappedLines := make([]string, 0) // Final result
+	tables := opts.Tables
+	notTable := 0 // Lines before this index were found not to be a table
 
-	for i := range lines {
+	for i := 0; i < len(lines); i++ {
 		lines[i] = strings.TrimRight(lines[i], " \r\t\n")
 
+		if tables && i >= notTable && isTableRow(lines[i]) {
+			// Find the rest of the table rows
+			end := i + 1
+			for end < len(lines) && isTableRow(strings.TrimRight(lines[end], " \r\t\n")) {
+				end++
+			}
+			rows := make([]string, end-i)
+			for j := range rows {
+				rows[j] = strings.TrimRight(lines[i+j], " \r\t\n")
+			}
+			if formatted, ok := formatTable(rows, width); ok {
+				// Table rows aren't wrapped, they fit the width
+				for _, row := range formatted {
+					wrappedLines = append(wrappedLines,
+						fmt.Sprintf("[%s]", opts.color("regular_text"))+row+"[-]")
+				}
+				i = end - 1
+				continue
+			}
+			// Too wide, or just one row, so they're displayed as regular lines
+			notTable = end
+		}
+
 		if strings.HasPrefix(lines[i], "#") {
 			// Headings
+			level := len(lines[i]) - len(strings.TrimLeft(lines[i], "#"))
+			if level > 3 {
+				level = 3
+			}
+			if text := strings.TrimSpace(strings.TrimLeft(lines[i], "#")); text != "" {
+				headings = append(headings, structs.Heading{
+					Level: level, Text: text, Slug: HeadingSlug(text), Row: len(wrappedLines),
+				})
+			}
+
 			var tag string
-			if viper.GetBool("a-general.color") {
+			if opts.Color {
 				if strings.HasPrefix(lines[i], "###") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_3"))
+					tag = fmt.Sprintf("[%s::b]", opts.color("hdg_3"))
 				} else if strings.HasPrefix(lines[i], "##") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_2"))
+					tag = fmt.Sprintf("[%s::b]", opts.color("hdg_2"))
 				} else if strings.HasPrefix(lines[i], "#") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_1"))
+					tag = fmt.Sprintf("[%s::b]", opts.color("hdg_1"))
 				}
 				wrappedLines = append(wrappedLines, wrapLine(lines[i], width, tag, "[-::-]", true)...)
 			} else {
@@ -117,8 +372,8 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 		} else if strings.HasPrefix(lines[i], "=>") && len([]rune(lines[i])) >= 3 {
 			// Trim whitespace and separate link from link text
 
-			lines[i] = strings.Trim(lines[i][2:], " \t") // Remove `=>` part too
-			delim := strings.IndexAny(lines[i], " \t")   // Whitespace between link and link text
+			lines[i] = strings.TrimSpace(lines[i][2:]) // Remove `=>` part too
+			delim := strings.IndexAny(lines[i], " \t") // Whitespace between link and link text
 
 			var url string
 			var linkText string
@@ -129,8 +384,12 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 			} else {
 				// There is link text
 				url = lines[i][:delim]
-				linkText = strings.Trim(lines[i][delim:], " \t")
-				if viper.GetBool("a-general.show_link") {
+				linkText = strings.TrimSpace(lines[i][delim:])
+				if runewidth.StringWidth(linkText) == 0 {
+					// Just invisible characters, like a non-breaking or zero-width space,
+					// so the link would be an empty region that can't be seen
+					linkText = url
+				} else if opts.ShowLink {
 					linkText += " (" + url + ")"
 				}
 			}
@@ -164,6 +423,17 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 				spacing = "  "
 			}
 
+			// The link number in brackets, like [1], to the left of the link text
+			label := "[" + strconv.Itoa(num) + "[]"
+			plainIndent := len(strconv.Itoa(num)) + 4 // +4 for spaces and brackets
+			plainSpacing := "  "
+			if !opts.ShowLinkNumbers {
+				// Just an arrow instead, the link can still be followed by its number
+				label = "=>"
+				indent, plainIndent = 3, 3
+				spacing, plainSpacing = " ", " "
+			}
+
 			// Wrap and add link text
 			// Wrap the link text, but add some spaces to indent the wrapped lines past the link number
 			// Set the style tags
@@ -171,52 +441,64 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 
 			var wrappedLink []string
 
-			if viper.GetBool("a-general.color") {
-				pU, err := urlPkg.Parse(url)
+			// Outside of the link region, so the region IDs still match the links
+			var badge string
+			pU, err := urlPkg.Parse(url)
+			if err == nil {
+				badge = schemeBadge(pU.Scheme, opts)
+			}
+
+			if opts.Color {
 				if !proxied && err == nil &&
 					(pU.Scheme == "" || pU.Scheme == "gemini" || pU.Scheme == "about") {
 					// A gemini link
 					// Add the link text in blue (in a region), and a gray link number to the left of it
 					// Those are the default colors, anyway
+					// Links that leave the site of the page get their own color
+
+					linkColor := "amfora_link"
+					if base != nil && base.Hostname() != "" && !sameSite(base, pU, opts.SubdomainsSameSite) {
+						linkColor = "offsite_link"
+					}
 
 					wrappedLink = wrapLine(linkText, width,
 						strings.Repeat(" ", indent)+
-							`["`+strconv.Itoa(num-1)+`"][`+config.GetColorString("amfora_link")+`]`,
+							`["`+strconv.Itoa(num-1)+`"][`+opts.color(linkColor)+`]`,
 						`[-][""]`,
 						false, // Don't indent the first line, it's the one with link number
 					)
 
 					// Add special stuff to first line, like the link number
-					wrappedLink[0] = fmt.Sprintf(`[%s::b][`, config.GetColorString("link_number")) +
-						strconv.Itoa(num) + "[]" + "[-::-]" + spacing +
-						`["` + strconv.Itoa(num-1) + `"][` + config.GetColorString("amfora_link") + `]` +
+					wrappedLink[0] = fmt.Sprintf(`[%s::b]`, opts.color("link_number")) +
+						label + "[-::-]" + spacing + badge +
+						`["` + strconv.Itoa(num-1) + `"][` + opts.color(linkColor) + `]` +
 						wrappedLink[0] + `[-][""]`
 				} else {
 					// Not a gemini link
 
 					wrappedLink = wrapLine(linkText, width,
 						strings.Repeat(" ", indent)+
-							`["`+strconv.Itoa(num-1)+`"][`+config.GetColorString("foreign_link")+`]`,
+							`["`+strconv.Itoa(num-1)+`"][`+opts.color("foreign_link")+`]`,
 						`[-][""]`,
 						false, // Don't indent the first line, it's the one with link number
 					)
 
-					wrappedLink[0] = fmt.Sprintf(`[%s::b][`, config.GetColorString("link_number")) +
-						strconv.Itoa(num) + "[]" + "[-::-]" + spacing +
-						`["` + strconv.Itoa(num-1) + `"][` + config.GetColorString("foreign_link") + `]` +
+					wrappedLink[0] = fmt.Sprintf(`[%s::b]`, opts.color("link_number")) +
+						label + "[-::-]" + spacing + badge +
+						`["` + strconv.Itoa(num-1) + `"][` + opts.color("foreign_link") + `]` +
 						wrappedLink[0] + `[-][""]`
 				}
 			} else {
 				// No colors allowed
 
 				wrappedLink = wrapLine(linkText, width,
-					strings.Repeat(" ", len(strconv.Itoa(num))+4)+ // +4 for spaces and brackets
+					strings.Repeat(" ", plainIndent)+
 						`["`+strconv.Itoa(num-1)+`"]`,
 					`[""]`,
 					false, // Don't indent the first line, it's the one with link number
 				)
 
-				wrappedLink[0] = `[::b][` + strconv.Itoa(num) + "[][::-]  " +
+				wrappedLink[0] = `[::b]` + label + "[::-]" + plainSpacing + badge +
 					`["` + strconv.Itoa(num-1) + `"]` +
 					wrappedLink[0] + `[""]`
 			}
@@ -225,29 +507,21 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 
 			// Lists
 		} else if strings.HasPrefix(lines[i], "* ") {
-			if viper.GetBool("a-general.bullets") {
-				// Wrap list item, and indent wrapped lines past the bullet
-				wrappedItem := wrapLine(lines[i][1:], width,
-					fmt.Sprintf("    [%s]", config.GetColorString("list_text")),
-					"[-]", false)
-				// Add bullet
-				wrappedItem[0] = fmt.Sprintf(" [%s]\u2022", config.GetColorString("list_text")) +
-					wrappedItem[0] + "[-]"
-				wrappedLines = append(wrappedLines, wrappedItem...)
-			}
-			// Optionally list lines could be colored here too, if color is enabled
+			wrappedLines = append(wrappedLines, wrapListItem(lines[i][2:], opts)...)
 		} else if strings.HasPrefix(lines[i], ">") {
-			// It's a quote line, add extra quote symbols and italics to the start of each wrapped line
+			// It's a quote line, add the quote prefix and italics to the start of each wrapped line
+			quotePrefix := cview.Escape(opts.QuotePrefix)
 
 			if len(lines[i]) == 1 {
 				// Just an empty quote line
-				wrappedLines = append(wrappedLines, fmt.Sprintf("[%s::i]>[-::-]", config.GetColorString("quote_text")))
+				wrappedLines = append(wrappedLines, fmt.Sprintf("[%s::i]%s[-::-]",
+					opts.color("quote_text"), strings.TrimRight(quotePrefix, " ")))
 			} else {
 				// Remove beginning quote and maybe space
 				lines[i] = strings.TrimPrefix(lines[i], ">")
 				lines[i] = strings.TrimPrefix(lines[i], " ")
 				wrappedLines = append(wrappedLines,
-					wrapLine(lines[i], width, fmt.Sprintf("[%s::i]> ", config.GetColorString("quote_text")),
+					wrapLine(lines[i], width, fmt.Sprintf("[%s::i]%s", opts.color("quote_text"), quotePrefix),
 						"[-::-]", true)...,
 				)
 			}
@@ -258,12 +532,67 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 		} else {
 			// Regular line, just wrap it
 			wrappedLines = append(wrappedLines, wrapLine(lines[i], width,
-				fmt.Sprintf("[%s]", config.GetColorString("regular_text")),
+				fmt.Sprintf("[%s]", opts.color("regular_text")),
 				"[-]", true)...)
 		}
 	}
 
-	return strings.Join(wrappedLines, "\r\n"), links
+	return strings.Join(wrappedLines, "\r\n"), links, headings
+}
+
+// HeadingSlug returns the ID of a heading for URL fragments, which is made of
+// its lowercase letters and numbers, with dashes between the words. Escaping
+// the heading for cview doesn't change it, and neither does making a slug of it
+// again, so a URL fragment can be compared to slugs after passing it through this.
+func HeadingSlug(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// StripLinks removes the link lines from text/gemini, leaving the text and headings.
+// Lines in preformatted blocks are never removed.
+func StripLinks(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := make([]string, 0, len(lines))
+	pre := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+		} else if !pre && strings.HasPrefix(line, "=>") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// sameSite returns true if the link, which can be relative, goes to the same
+// host as the page at base. Subdomains of the host, or the host it's a
+// subdomain of, are only part of the site if subdomains is true.
+func sameSite(base, link *urlPkg.URL, subdomains bool) bool {
+	u := base.ResolveReference(link)
+	if u.Scheme != base.Scheme {
+		return false
+	}
+	a := strings.TrimSuffix(strings.ToLower(base.Hostname()), ".")
+	b := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if a == b {
+		return true
+	}
+	return subdomains &&
+		(strings.HasSuffix(b, "."+a) || strings.HasSuffix(a, "."+b))
 }
 
 // RenderGemini converts text/gemini into a cview displayable format.
@@ -275,27 +604,106 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 // proxied is whether the request is through the gemini:// scheme.
 // If it's not a gemini:// page, set this to true.
 func RenderGemini(s string, width int, proxied bool) (string, []string) {
+	rendered, links, _ := RenderGeminiTOC(s, width, proxied, "")
+	return rendered, links
+}
+
+// RenderGeminiTOC is like RenderGemini, but it also returns the headings of
+// the page, for its table of contents. Their rows are the lines of the
+// rendered text they start on.
+//
+// pageURL is the URL of the page, so links that leave its site can be colored
+// differently. It can be empty, for pages that aren't from a server.
+func RenderGeminiTOC(s string, width int, proxied bool, pageURL string) (string, []string, []structs.Heading) {
+	r := RenderGemtext(s, ConfigOptions(width, proxied, pageURL))
+	return r.Content, r.Links, r.Headings
+}
+
+// RenderGemtext converts text/gemini into a cview displayable format, with
+// the options instead of the config. It doesn't read the config or any other
+// global state, so other programs can use it with their own options. The
+// renderer package itself still imports the config, for ConfigOptions and
+// for making pages.
+func RenderGemtext(s string, opts Options) Rendered {
+	maxPreCols := MaxPreCols(s, structs.TextGemini)
 	s = cview.Escape(s)
+	width := opts.Width
+
+	var base *urlPkg.URL
+	if opts.PageURL != "" {
+		base, _ = urlPkg.Parse(opts.PageURL)
+	}
 
 	lines := strings.Split(s, "\n")
 	links := make([]string, 0)
+	headings := make([]structs.Heading, 0)
+	slugs := make(map[string]int) // How many headings have each slug so far
+	preBlocks := make([]structs.PreBlock, 0)
 
 	// Process and wrap non preformatted lines
 	rendered := "" // Final result
 	pre := false
-	buf := "" // Block of regular or preformatted lines
+	buf := ""   // Block of regular or preformatted lines
+	alt := ""   // The alt text of the current preformatted block
+	numPre := 0 // The number of preformatted blocks so far
+
+	// processAlt adds the alt text of a preformatted block above it, as a caption.
+	// It's not part of the block, so it's wrapped and not counted for MaxPreCols.
+	processAlt := func() {
+		if alt == "" || !opts.PreformattedCaptions {
+			return
+		}
+		caption := wrapLine(alt, width,
+			fmt.Sprintf("[%s::di]", opts.color("preformatted_text")), "[-::-]", true)
+		rendered += strings.Join(caption, "\r\n") + "\r\n"
+	}
+
+	// processFold adds the line above a preformatted block that can be folded,
+	// which says what's in it, and whether it's folded with [+] or [-].
+	// It's used instead of the caption, and has the alt text if there is some.
+	processFold := func(lines int, folded bool) {
+		mark := "[-[]"
+		if folded {
+			mark = "[+[]"
+		}
+		label := alt
+		if label == "" {
+			label = "preformatted block"
+		}
+		fold := wrapLine(fmt.Sprintf("%s %s (%d lines)", mark, label, lines), width,
+			fmt.Sprintf("[%s::b]", opts.color("preformatted_text")), "[-::-]", true)
+		rendered += strings.Join(fold, "\r\n") + "\r\n"
+	}
 
 	// processPre is for rendering preformatted blocks
 	processPre := func() {
+		n := numPre
+		numPre++
+		if lines := strings.Count(buf, "\r\n"); opts.FoldPre > 0 && lines > opts.FoldPre {
+			folded, ok := opts.Folds[n]
+			if !ok {
+				folded = true
+			}
+			preBlocks = append(preBlocks, structs.PreBlock{
+				N: n, Row: strings.Count(rendered, "\n"), Lines: lines, Folded: folded,
+			})
+			processFold(lines, folded)
+			if folded {
+				return
+			}
+		} else {
+			processAlt()
+		}
 
 		// Support ANSI color codes in preformatted blocks - see #59
-		if viper.GetBool("a-general.color") && viper.GetBool("a-general.ansi") {
-			buf = cview.TranslateANSI(buf)
+		buf = cleanANSI(buf)
+		if opts.Color && opts.ANSI {
+			buf = cview.TranslateANSI(remapANSI(buf, ansiPalette(opts.Theme)))
 			// The TranslateANSI function injects tags like [-:-:-]
 			// but this will reset the background to use the user's terminal color.
 			// These tags need to be replaced with resets that use the theme color.
 			buf = strings.ReplaceAll(buf, "[-:-:-]",
-				fmt.Sprintf("[%s:%s:-]", config.GetColorString("preformatted_text"), config.GetColorString("bg")))
+				fmt.Sprintf("[%s:%s:-]", opts.color("preformatted_text"), opts.color("bg")))
 		} else {
 			buf = ansiRegex.ReplaceAllString(buf, "")
 		}
@@ -307,17 +715,29 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 		// Lines are modified below to always end with \r\n
 		buf = strings.TrimSuffix(buf, "\r\n")
 
-		rendered += fmt.Sprintf("[%s]", config.GetColorString("preformatted_text")) +
-			buf + fmt.Sprintf("[%s:%s:-]\r\n", config.GetColorString("regular_text"), config.GetColorString("bg"))
+		rendered += fmt.Sprintf("[%s]", opts.color("preformatted_text")) +
+			buf + fmt.Sprintf("[%s:%s:-]\r\n", opts.color("regular_text"), opts.color("bg"))
 	}
 
 	// processRegular processes non-preformatted sections
 	processRegular := func() {
 		// ANSI not allowed in regular text - see #59
-		buf = ansiRegex.ReplaceAllString(buf, "")
+		buf = ansiEscapeRegex.ReplaceAllString(buf, "")
 
-		ren, lks := convertRegularGemini(buf, len(links), width, proxied)
+		ren, lks, hdgs := convertRegularGemini(buf, len(links), &opts, base)
 		links = append(links, lks...)
+		row := strings.Count(rendered, "\n")
+		for _, h := range hdgs {
+			h.Row += row
+			if n := slugs[h.Slug]; n > 0 {
+				// Later headings with the same text can be linked to as well
+				slugs[h.Slug]++
+				h.Slug += "-" + strconv.Itoa(n)
+			} else {
+				slugs[h.Slug] = 1
+			}
+			headings = append(headings, h)
+		}
 		rendered += ren
 	}
 
@@ -331,6 +751,7 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 			} else {
 				// Not preformatted, regular text
 				processRegular()
+				alt = strings.TrimSpace(ansiEscapeRegex.ReplaceAllString(lines[i][3:], ""))
 			}
 			buf = "" // Clear buffer for next block
 			pre = !pre
@@ -348,5 +769,7 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 		processRegular()
 	}
 
-	return rendered, links
+	return Rendered{
+		Content: rendered, Links: links, Headings: headings, MaxPreCols: maxPreCols, PreBlocks: preBlocks,
+	}
 }
@@ -0,0 +1,262 @@
+package renderer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+var cleanANSITests = []struct {
+	in   string
+	want string
+}{
+	{"plain", "plain"},
+	{"\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m"},
+	{"\x1b[38;5;208morange\x1b[m", "\x1b[38;5;208morange\x1b[m"},
+	{"\x1b[38;2;255;128;0mtrue\x1b[0m", "\x1b[38;2;255;128;0mtrue\x1b[0m"},
+	{"\x1b[1;4mbold underline\x1b[22;24m", "\x1b[1;4mbold underline\x1b[22;24m"},
+	{"\x1b[2J\x1b[Hclear", "clear"},
+	{"\x1b[10;20Hmoved", "moved"},
+	{"a\x1b[3Cb", "a   b"},
+	{"a\x1b[Cb", "a b"},
+	{"\x1b[?25lhidden cursor\x1b[?25h", "hidden cursor"},
+	{"\x1b]0;title\x07text", "text"},
+	{"\x1b]0;title\x1b\\text", "text"},
+	{"\x1b(Bcharset", "charset"},
+	{"\x1b[38:2:1:2:3mcolons", "colons"},
+}
+
+func TestCleanANSI(t *testing.T) {
+	for _, tt := range cleanANSITests {
+		if got := cleanANSI(tt.in); got != tt.want {
+			t.Errorf("cleanANSI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaxPreColsANSI(t *testing.T) {
+	s := "\x1b[31mabc\x1b[0m\x1b[5Cd\n\x1b[2J\x1b[38;2;1;2;3mab"
+	if got := MaxPreCols(s, structs.TextAnsi); got != 9 {
+		t.Errorf("MaxPreCols = %d, want 9", got)
+	}
+}
+
+func TestRenderGeminiTOC(t *testing.T) {
+	s := "# Title\ntext\n```\n# not a heading\n```\n## Section\n### Sub\n#\n## Section\n"
+	_, _, headings := RenderGeminiTOC(s, 80, false, "")
+	want := []structs.Heading{
+		{Level: 1, Text: "Title", Slug: "title", Row: 0},
+		{Level: 2, Text: "Section", Slug: "section", Row: 3},
+		{Level: 3, Text: "Sub", Slug: "sub", Row: 4},
+		{Level: 2, Text: "Section", Slug: "section-1", Row: 6},
+	}
+	if len(headings) != len(want) {
+		t.Fatalf("RenderGeminiTOC headings = %+v, want %+v", headings, want)
+	}
+	for i := range want {
+		if headings[i] != want[i] {
+			t.Errorf("heading %d = %+v, want %+v", i, headings[i], want[i])
+		}
+	}
+}
+
+var headingSlugTests = []struct {
+	text string
+	want string
+}{
+	{"Title", "title"},
+	{"Hello, World!", "hello-world"},
+	{"  1.2 Fragments & anchors ", "1-2-fragments-anchors"},
+	{"[[]note]", "note"}, // Escaped like cview does
+	{"hello-world", "hello-world"},
+	{"Ünïcode héading", "ünïcode-héading"},
+	{"!!!", ""},
+}
+
+func TestHeadingSlug(t *testing.T) {
+	for _, tt := range headingSlugTests {
+		if got := HeadingSlug(tt.text); got != tt.want {
+			t.Errorf("HeadingSlug(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWrapListItem(t *testing.T) {
+	tags := regexp.MustCompile(`\[[^\[\]]*\]`)
+	wrapped := wrapListItem("one two three four", &Options{Width: 12, Bullet: "•"})
+	want := []string{" • one two", "   three", "   four"}
+	if len(wrapped) != len(want) {
+		t.Fatalf("wrapListItem lines = %q, want %q", wrapped, want)
+	}
+	for i := range want {
+		if got := strings.TrimRight(tags.ReplaceAllString(wrapped[i], ""), " "); got != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestRenderGeminiCaption(t *testing.T) {
+	viper.Set("a-general.preformatted_captions", true)
+	defer viper.Set("a-general.preformatted_captions", nil)
+
+	tags := regexp.MustCompile(`\[[^\[\]]*\]`)
+	s := "```A cat\n =^.^=\n```\n# After"
+	rendered, _, headings := RenderGeminiTOC(s, 80, false, "")
+	lines := strings.Split(tags.ReplaceAllString(rendered, ""), "\r\n")
+	if len(lines) < 2 || lines[0] != "A cat" || lines[1] != " =^.^=" {
+		t.Errorf("RenderGeminiTOC lines = %q, want the caption before the block", lines)
+	}
+	if len(headings) != 1 || headings[0].Row != 2 {
+		t.Errorf("RenderGeminiTOC headings = %+v, want one on row 2", headings)
+	}
+	if got := MaxPreCols(s, structs.TextGemini); got != 6 {
+		t.Errorf("MaxPreCols = %d, want 6, without the caption", got)
+	}
+}
+
+func TestRenderGeminiSchemeBadge(t *testing.T) {
+	viper.Set("a-general.scheme_badges", true)
+	defer viper.Set("a-general.scheme_badges", nil)
+
+	rendered, links := RenderGemini("=> gemini://a.com/ A\n=> https://b.com/ B\n=> /c C", 80, false)
+	if len(links) != 3 {
+		t.Fatalf("RenderGemini links = %q, want 3", links)
+	}
+	lines := strings.Split(rendered, "\r\n")
+	if strings.Contains(lines[0], `[] ["`) || strings.Contains(lines[2], `[] ["`) {
+		t.Errorf("RenderGemini added a badge to a Gemini link: %q", rendered)
+	}
+	if !strings.Contains(lines[1], `[https[] ["1"]B`) {
+		t.Errorf("RenderGemini line = %q, want the badge before the region of link 1", lines[1])
+	}
+}
+
+func TestRenderGeminiLinkWithoutText(t *testing.T) {
+	tags := regexp.MustCompile(`\[[^\[\]]*\]`)
+	for _, s := range []string{
+		"=> gemini://example.com",
+		"=>\tgemini://example.com\t",
+		"=> gemini://example.com \u00a0",
+		"=> gemini://example.com \u200b",
+	} {
+		rendered, links := RenderGemini(s, 80, false)
+		if len(links) != 1 || links[0] != "gemini://example.com" {
+			t.Errorf("RenderGemini(%q) links = %q, want the URL", s, links)
+		}
+		if !strings.Contains(rendered, `["0"]`) {
+			t.Errorf("RenderGemini(%q) = %q, want a region for the link", s, rendered)
+			continue
+		}
+		text := tags.ReplaceAllString(rendered[strings.Index(rendered, `["0"]`):], "")
+		if strings.TrimSpace(text) != "gemini://example.com" {
+			t.Errorf("RenderGemini(%q) link text = %q, want the URL", s, text)
+		}
+	}
+}
+
+func TestRemapANSI(t *testing.T) {
+	var palette [16]string
+	palette[1] = "1;2;3"  // Red
+	palette[12] = "4;5;6" // Bright blue
+	palette[0] = "7;8;9"  // Black
+	tests := map[string]string{
+		"\x1b[31mred\x1b[0m":           "\x1b[38;2;1;2;3mred\x1b[0m",
+		"\x1b[1;94mblue":               "\x1b[1;38;2;4;5;6mblue",
+		"\x1b[40;32mbg":                "\x1b[48;2;7;8;9;32mbg", // Green isn't changed
+		"\x1b[38;5;1m\x1b[48;5;200m":   "\x1b[38;2;1;2;3m\x1b[48;5;200m",
+		"\x1b[38;2;31;40;94mtruecolor": "\x1b[38;2;31;40;94mtruecolor",
+		"\x1b[mreset":                  "\x1b[mreset",
+		"\x1b[38;2;31m\x1b[31m":        "\x1b[38;2;31m\x1b[38;2;1;2;3m", // Cut short
+	}
+	for s, want := range tests {
+		if got := remapANSI(s, palette); got != want {
+			t.Errorf("remapANSI(%q) = %q, want %q", s, got, want)
+		}
+	}
+	if got := remapANSI("\x1b[31mred", [16]string{}); got != "\x1b[31mred" {
+		t.Errorf("remapANSI changed %q without a palette", got)
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	base, _ := url.Parse("gemini://example.com/dir/page.gmi")
+	tests := []struct {
+		link       string
+		subdomains bool
+		want       bool
+	}{
+		{"other.gmi", false, true},
+		{"/", false, true},
+		{"gemini://EXAMPLE.com./", false, true},
+		{"gemini://example.com:1966/", false, true},
+		{"//example.org/", false, false},
+		{"gemini://example.org/", false, false},
+		{"about:bookmarks", false, false},
+		{"gemini://sub.example.com/", false, false},
+		{"gemini://sub.example.com/", true, true},
+		{"gemini://notexample.com/", true, false},
+	}
+	for _, tt := range tests {
+		link, _ := url.Parse(tt.link)
+		if got := sameSite(base, link, tt.subdomains); got != tt.want {
+			t.Errorf("sameSite(%q) with subdomains_same_site %v = %v, want %v", tt.link, tt.subdomains, got, tt.want)
+		}
+	}
+}
+
+func TestRenderGemtext(t *testing.T) {
+	opts := Options{
+		Width:           80,
+		PageURL:         "gemini://example.com/",
+		Color:           true,
+		Theme:           map[string]string{"amfora_link": "#0000ff", "offsite_link": "#00ffff"},
+		ShowLinkNumbers: true,
+	}
+	r := RenderGemtext("# Title\n=> /a Here\n=> gemini://example.org/ There\n```\nwide line\n```", opts)
+	if len(r.Links) != 2 || r.Links[0] != "/a" || r.Links[1] != "gemini://example.org/" {
+		t.Errorf("RenderGemtext links = %q", r.Links)
+	}
+	if len(r.Headings) != 1 || r.Headings[0].Text != "Title" {
+		t.Errorf("RenderGemtext headings = %+v", r.Headings)
+	}
+	if r.MaxPreCols != 9 {
+		t.Errorf("RenderGemtext MaxPreCols = %d, want 9", r.MaxPreCols)
+	}
+	if !strings.Contains(r.Content, `["0"][#0000ff]Here`) || !strings.Contains(r.Content, `["1"][#00ffff]There`) {
+		t.Errorf("RenderGemtext didn't use the theme colors: %q", r.Content)
+	}
+}
+
+func TestRenderGemtextFolds(t *testing.T) {
+	doc := "Text\n```code\none\ntwo\nthree\n```\n```\nshort\n```\n```\nfour\nfive\nsix\n```\n"
+	r := RenderGemtext(doc, Options{Width: 80, FoldPre: 2, Folds: map[int]bool{2: false}})
+	want := []structs.PreBlock{
+		{N: 0, Row: 1, Lines: 3, Folded: true},
+		{N: 2, Row: 3, Lines: 3, Folded: false},
+	}
+	if len(r.PreBlocks) != len(want) {
+		t.Fatalf("RenderGemtext PreBlocks = %+v, want %+v", r.PreBlocks, want)
+	}
+	for i := range want {
+		if r.PreBlocks[i] != want[i] {
+			t.Errorf("PreBlock %d = %+v, want %+v", i, r.PreBlocks[i], want[i])
+		}
+	}
+	for _, s := range []string{"[+[] code (3 lines)", "short", "[-[] preformatted block (3 lines)", "four"} {
+		if !strings.Contains(r.Content, s) {
+			t.Errorf("RenderGemtext content doesn't have %q: %q", s, r.Content)
+		}
+	}
+	if strings.Contains(r.Content, "one") {
+		t.Errorf("RenderGemtext content has the lines of a folded block: %q", r.Content)
+	}
+
+	r = RenderGemtext(doc, Options{Width: 80})
+	if len(r.PreBlocks) != 0 || !strings.Contains(r.Content, "one") {
+		t.Errorf("RenderGemtext folded blocks without FoldPre: %+v", r.PreBlocks)
+	}
+}
@@ -0,0 +1,40 @@
+package renderer
+
+import (
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// RenderSpartan converts text/gemini from a Spartan server into a cview
+// displayable format. It also returns a slice of link URLs, and the headings
+// like RenderGeminiTOC.
+//
+// Prompt lines (=:) are rendered as links. Their URLs are returned in prompts
+// too, because following them should ask the user for input first.
+func RenderSpartan(s string, width int) (rendered string, links []string, prompts []string, headings []structs.Heading) {
+	s, prompts = spartanPrompts(s)
+	rendered, links, headings = RenderGeminiTOC(s, width, true, "")
+	return rendered, links, prompts, headings
+}
+
+// spartanPrompts turns prompt lines into link lines, and returns their URLs.
+func spartanPrompts(s string) (string, []string) {
+	prompts := make([]string, 0)
+	lines := strings.Split(s, "\n")
+	pre := false
+	for i := range lines {
+		if strings.HasPrefix(lines[i], "```") {
+			pre = !pre
+			continue
+		}
+		if pre || !strings.HasPrefix(lines[i], "=:") {
+			continue
+		}
+		lines[i] = "=>" + lines[i][2:]
+		if fields := strings.Fields(lines[i][2:]); len(fields) > 0 {
+			prompts = append(prompts, fields[0])
+		}
+	}
+	return strings.Join(lines, "\n"), prompts
+}
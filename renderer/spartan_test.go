@@ -0,0 +1,27 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+)
+
+var spartanPromptsTests = []struct {
+	s        string
+	expected string
+	prompts  []string
+}{
+	{"=: /search Search", "=> /search Search", []string{"/search"}},
+	{"=:/post", "=>/post", []string{"/post"}},
+	{"=> /link Link", "=> /link Link", []string{}},
+	{"```\n=: /search\n```", "```\n=: /search\n```", []string{}},
+	{"=:", "=>", []string{}},
+}
+
+func TestSpartanPrompts(t *testing.T) {
+	for _, tt := range spartanPromptsTests {
+		actual, prompts := spartanPrompts(tt.s)
+		if actual != tt.expected || !reflect.DeepEqual(prompts, tt.prompts) {
+			t.Errorf("spartanPrompts(%q): expected %q %q, actual %q %q", tt.s, tt.expected, tt.prompts, actual, prompts)
+		}
+	}
+}
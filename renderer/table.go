@@ -0,0 +1,126 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Tables in regular gemtext lines, like:
+//
+//   | Name | Size |
+//   |------|------|
+//   | a    | 1 MB |
+//
+// Gemtext has no tables, so this is a heuristic that's only used if enabled
+// in the config. Preformatted blocks are never changed.
+
+// Regex for brackets escaped by cview.Escape, which are displayed as just the tag.
+var escapedTagRegex = regexp.MustCompile(`\[([a-zA-Z0-9_,;: \-\."#]+)\[(\[*)\]`)
+
+// Regex for cells of the row under the header, like --- or :---:
+var tableSepRegex = regexp.MustCompile(`^:?-+:?$`)
+
+// visibleWidth returns the number of columns text escaped by cview.Escape takes up.
+func visibleWidth(s string) int {
+	return runewidth.StringWidth(escapedTagRegex.ReplaceAllString(s, "[$1$2]"))
+}
+
+// isTableRow returns true if the regular gemtext line could be a table row.
+func isTableRow(line string) bool {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "=>") ||
+		strings.HasPrefix(line, "* ") || strings.HasPrefix(line, ">") {
+		return false
+	}
+	return len(tableCells(line)) > 1
+}
+
+// tableCells splits the row into its cells. Pipes at the start and end
+// of the row are optional.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	if !strings.Contains(line, "|") {
+		return nil
+	}
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// isTableSep returns true if all the cells are like the ones under a header.
+func isTableSep(cells []string) bool {
+	for _, c := range cells {
+		if !tableSepRegex.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTable aligns the columns of the rows, so they line up. Rows with fewer
+// cells than others are filled with empty ones. It returns false if there
+// aren't at least two rows, or if the table would be wider than width.
+func formatTable(rows []string, width int) ([]string, bool) {
+	if len(rows) < 2 {
+		return nil, false
+	}
+
+	cells := make([][]string, len(rows))
+	widths := make([]int, 0)
+	for i := range rows {
+		cells[i] = tableCells(rows[i])
+		for len(widths) < len(cells[i]) {
+			widths = append(widths, 0)
+		}
+		if isTableSep(cells[i]) {
+			continue
+		}
+		for j, c := range cells[i] {
+			if w := visibleWidth(c); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	total := 3 * (len(widths) - 1) // Space taken up by " | " between cells
+	for _, w := range widths {
+		total += w
+	}
+	if total > width {
+		return nil, false
+	}
+
+	formatted := make([]string, len(rows))
+	for i := range cells {
+		if isTableSep(cells[i]) {
+			seps := make([]string, len(widths))
+			for j, w := range widths {
+				seps[j] = strings.Repeat("-", w)
+			}
+			formatted[i] = strings.Join(seps, "-+-")
+			continue
+		}
+
+		var b strings.Builder
+		for j, w := range widths {
+			c := ""
+			if j < len(cells[i]) {
+				c = cells[i][j]
+			}
+			if j > 0 {
+				b.WriteString(" | ")
+			}
+			b.WriteString(c)
+			if j < len(widths)-1 {
+				b.WriteString(strings.Repeat(" ", w-visibleWidth(c)))
+			}
+		}
+		formatted[i] = strings.TrimRight(b.String(), " ")
+	}
+	return formatted, true
+}
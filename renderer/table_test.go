@@ -0,0 +1,63 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatTable(t *testing.T) {
+	rows := []string{
+		"| Name | Size |",
+		"|---|:--:|",
+		"| amfora.tar.gz | 5 MB |",
+		"|a|",
+	}
+	want := []string{
+		"Name          | Size",
+		"--------------+-----",
+		"amfora.tar.gz | 5 MB",
+		"a             |",
+	}
+	got, ok := formatTable(rows, 80)
+	if !ok {
+		t.Fatal("formatTable returned false")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("formatTable = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTableEscaped(t *testing.T) {
+	// cview.Escape("[a]") is "[a[]"
+	got, ok := formatTable([]string{"[a[] | b", "c | d"}, 80)
+	want := []string{"[a[] | b", "c   | d"}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("formatTable = %q, %v, want %q", got, ok, want)
+	}
+}
+
+func TestFormatTableTooWide(t *testing.T) {
+	if _, ok := formatTable([]string{"abc | def", "g | h"}, 8); ok {
+		t.Error("formatTable returned true for a table wider than the width")
+	}
+	if _, ok := formatTable([]string{"abc | def"}, 80); ok {
+		t.Error("formatTable returned true for a single row")
+	}
+}
+
+func TestIsTableRow(t *testing.T) {
+	tests := map[string]bool{
+		"a | b":        true,
+		"| a |":        false,
+		"no pipes":     false,
+		"=> url a | b": false,
+		"* a | b":      false,
+		"# a | b":      false,
+		"> a | b":      false,
+	}
+	for line, want := range tests {
+		if got := isTableRow(line); got != want {
+			t.Errorf("isTableRow(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
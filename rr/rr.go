@@ -14,6 +14,9 @@ type RestartReader struct {
 	// Where in the buffer we are. If it's equal to len(buf) then the reader
 	// should be used.
 	i int64
+
+	// Whether new data is no longer added to the buffer, see StopBuffering.
+	stopped bool
 }
 
 func (rr *RestartReader) Read(p []byte) (n int, err error) {
@@ -22,6 +25,12 @@ func (rr *RestartReader) Read(p []byte) (n int, err error) {
 	}
 
 	if rr.i >= int64(len(rr.buf)) {
+		if rr.stopped {
+			// Free the buffer and read straight from the reader
+			rr.buf = make([]byte, 0)
+			rr.i = 0
+			return rr.r.Read(p)
+		}
 		// Read new data
 		tmp := make([]byte, len(p))
 		n, err = rr.r.Read(tmp)
@@ -37,6 +46,14 @@ func (rr *RestartReader) Read(p []byte) (n int, err error) {
 
 	bufSize := len(rr.buf[rr.i:])
 
+	if len(p) > bufSize && rr.stopped {
+		// It wants more data then what's in the buffer, which isn't saved
+		copy(p, rr.buf[rr.i:])
+		rr.i = int64(len(rr.buf))
+		n, err = rr.r.Read(p[bufSize:])
+		n += bufSize
+		return
+	}
 	if len(p) > bufSize {
 		// It wants more data then what's in the buffer
 		tmp := make([]byte, len(p)-bufSize)
@@ -64,6 +81,14 @@ func (rr *RestartReader) Restart() {
 	rr.i = 0
 }
 
+// StopBuffering causes data that isn't in the buffer yet to be read without
+// being saved, and the buffer to be freed once it's been read through.
+// It can't be restarted after that. It's useful when the rest of the data
+// is large and only needs to be read once, like for a download.
+func (rr *RestartReader) StopBuffering() {
+	rr.stopped = true
+}
+
 // Close clears the buffer and closes the underlying io.ReadCloser, returning
 // its error.
 func (rr *RestartReader) Close() error {
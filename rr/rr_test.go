@@ -43,3 +43,24 @@ func TestRestart(t *testing.T) {
 	assert.Equal(t, 4, n, "should have read 4 bytes")
 	assert.Equal(t, nil, err, "err should be nil")
 }
+
+//nolint
+func TestStopBuffering(t *testing.T) {
+	reset()
+	p := make([]byte, 4)
+	r1.Read(p)
+
+	r1.Restart()
+	r1.StopBuffering()
+	p = make([]byte, 6)
+	n, err := r1.Read(p)
+	assert.Equal(t, []byte("123456"), p, "should read the buffer, then new data")
+	assert.Equal(t, 6, n, "should have read 6 bytes")
+	assert.Equal(t, nil, err, "err should be nil")
+	assert.Equal(t, 4, len(r1.buf), "new data should not be added to the buffer")
+
+	p = make([]byte, 4)
+	n, _ = r1.Read(p)
+	assert.Equal(t, []byte("7890"), p[:n], "should read the rest of the data")
+	assert.Equal(t, 0, len(r1.buf), "the buffer should be freed")
+}
@@ -6,9 +6,14 @@ import "time"
 type Mediatype string
 
 const (
-	TextGemini Mediatype = "text/gemini"
-	TextPlain  Mediatype = "text/plain"
-	TextAnsi   Mediatype = "text/x-ansi"
+	TextGemini   Mediatype = "text/gemini"
+	TextPlain    Mediatype = "text/plain"
+	TextAnsi     Mediatype = "text/x-ansi"
+	TextMarkdown Mediatype = "text/markdown"
+	ImagePNG     Mediatype = "image/png"
+	ImageJPEG    Mediatype = "image/jpeg"
+	GopherMenu   Mediatype = "text/x-gopher-menu"   // Converted to TextGemini by the renderer
+	NexDirectory Mediatype = "text/x-nex-directory" // Converted to TextGemini by the renderer
 )
 
 type PageMode int
@@ -16,32 +21,80 @@ type PageMode int
 const (
 	ModeOff        PageMode = iota // Regular mode
 	ModeLinkSelect                 // When the enter key is pressed, allow for tab-based link navigation
-	ModeSearch                     // When a keyword is being searched in a page - TODO: NOT USED YET
+	ModeSearch                     // When a keyword is being searched in a page
+	ModeTextSelect                 // When lines of the page are being selected, to copy their text
 )
 
+// Security is how a page was loaded, which is shown before its URL.
+type Security int
+
+const (
+	SecurityNone    Security = iota // Not loaded from a server, like about: and file: pages
+	SecurityPlain                   // Loaded without TLS, like Spartan and Gopher pages
+	SecurityTLS                     // Loaded over TLS, with a cert trusted on first use
+	SecuritySession                 // Loaded over TLS, with a cert that's only trusted until Amfora is closed
+)
+
+// Heading is a heading line of a text/gemini page, for its table of contents.
+type Heading struct {
+	Level int    // 1 to 3, from the number of # characters
+	Text  string // Escaped for cview, without the # characters
+	Slug  string // For linking to the heading with a URL fragment, unique on the page
+	Row   int    // The line of the rendered Content the heading starts on
+}
+
+// PreBlock is a preformatted block of a text/gemini page that's long enough
+// to be folded, so just a line saying what's in it is shown.
+type PreBlock struct {
+	N      int  // Which preformatted block of the page it is, counting from 0
+	Row    int  // The line of the rendered Content its first line is on, which says what's in it
+	Lines  int  // The number of lines in the block
+	Folded bool // Whether the lines are hidden
+}
+
 // Page is for storing UTF-8 text/gemini pages, as well as text/plain pages.
 type Page struct {
 	URL          string
-	Mediatype    Mediatype // Used for rendering purposes, generalized
-	RawMediatype string    // The actual mediatype sent by the server
-	Raw          string    // The raw response, as received over the network
-	Content      string    // The processed content, NOT raw. Uses cview color tags. It will also have a left margin.
-	Links        []string  // URLs, for each region in the content.
-	Row          int       // Vertical scroll position
-	Column       int       // Horizontal scroll position - does not map exactly to a cview.TextView because it includes left margin size changes, see #197
-	TermWidth    int       // The terminal width when the Content was set, to know when reformatting should happen.
-	Selected     string    // The current text or link selected
-	SelectedID   string    // The cview region ID for the selected text/link
+	Mediatype    Mediatype    // Used for rendering purposes, generalized
+	RawMediatype string       // The actual mediatype sent by the server
+	Status       int          // The status code of the response, or 0 if the page isn't from one
+	Raw          string       // The raw response, as received over the network
+	Charset      string       // The charset Raw was decoded from, empty if it was UTF-8
+	Encoded      string       // The response before it was decoded from the Charset, if that changed it
+	Graphic      bool         // Whether Raw is image data, which is drawn instead of the Content. Such pages aren't scrolled or reformatted.
+	Content      string       // The processed content, NOT raw. Uses cview color tags. The left margin is added when it's displayed.
+	Links        []string     // URLs, for each region in the content.
+	NewLinks     []bool       // For each link, whether it wasn't on the page before it was reloaded, and hasn't been followed since. Nil if none are new.
+	Prompts      []string     // URLs of Spartan prompt lines, which ask for input when followed. They are in Links too.
+	Headings     []Heading    // The headings of text/gemini Content, for the table of contents. They change when the page is reformatted.
+	PreBlocks    []PreBlock   // The preformatted blocks of text/gemini Content that can be folded. They change when the page is reformatted.
+	Folds        map[int]bool // Whether preformatted blocks were folded or unfolded by hand, by PreBlock.N. Others use the fold_preformatted option.
+	Row          int          // Vertical scroll position
+	Column       int          // Horizontal scroll position - does not map exactly to a cview.TextView because it includes left margin size changes, see #197
+	MaxPreCols   int          // The number of terminal columns the longest preformatted line takes up. Used to limit horizontal scrolling.
+	TermWidth    int          // The terminal width when the Content was set, to know when reformatting should happen.
+	LeftMargin   int          // The left margin size when the Content was set, also to know when reformatting should happen.
+	NoLinkNums   bool         // Whether link numbers were hidden when the Content was set, also to know when reformatting should happen.
+	ThemeVersion int          // The version of the theme when the Content was set, also to know when reformatting should happen.
+	Wrapped      bool         // Whether long lines of text documents were wrapped when the Content was set, also to know when reformatting should happen.
+	Selected     string       // The current text or link selected
+	SelectedID   string       // The cview region ID for the selected text/link
 	Mode         PageMode
+	Security     Security
+	Reader       bool // Whether link lines are hidden from the Content, to just show the text
+	Source       bool // Whether the Content is the Raw text as received, instead of being rendered
 	Favicon      string
 	MadeAt       time.Time // When the page was made. Zero value indicates it should stay in cache forever.
 }
 
 // Size returns an approx. size of a Page in bytes.
 func (p *Page) Size() int {
-	n := len(p.Raw) + len(p.Content) + len(p.URL) + len(p.Selected) + len(p.SelectedID)
+	n := len(p.Raw) + len(p.Encoded) + len(p.Content) + len(p.URL) + len(p.Selected) + len(p.SelectedID)
 	for i := range p.Links {
 		n += len(p.Links[i])
 	}
+	for i := range p.Headings {
+		n += len(p.Headings[i].Text)
+	}
 	return n
 }
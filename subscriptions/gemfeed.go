@@ -0,0 +1,97 @@
+package subscriptions
+
+import (
+	"bufio"
+	"io"
+	urlPkg "net/url"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Gemfeeds are gemtext pages that follow the Gemini subscription convention:
+// a level one heading for the title, and link lines whose labels start with
+// a date in YYYY-MM-DD format, one for each entry.
+// See gemini://gemini.circumlunar.space/docs/companion/subscription.gmi
+
+// GetGemfeed returns a Feed object parsed from the gemtext document at the
+// provided URL, and a bool indicating whether the page is actually a gemfeed.
+// A page is only a gemfeed if it has at least one dated link line.
+func GetGemfeed(url string, r io.Reader) (*gofeed.Feed, bool) {
+	if r == nil {
+		return nil, false
+	}
+	base, err := urlPkg.Parse(url)
+	if err != nil {
+		return nil, false
+	}
+
+	feed := &gofeed.Feed{FeedType: "gemfeed"}
+	pre := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+			continue
+		}
+		if pre {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "# ") && feed.Title == "":
+			feed.Title = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, "## ") && feed.Title != "" && feed.Description == "" && len(feed.Items) == 0:
+			feed.Description = strings.TrimSpace(line[3:])
+		case strings.HasPrefix(line, "=>"):
+			item := gemfeedItem(base, line)
+			if item != nil {
+				feed.Items = append(feed.Items, item)
+			}
+		}
+	}
+	if scanner.Err() != nil || len(feed.Items) == 0 {
+		return nil, false
+	}
+	return feed, true
+}
+
+// gemfeedItem returns the feed entry for a link line, or nil if the
+// link isn't dated.
+func gemfeedItem(base *urlPkg.URL, line string) *gofeed.Item {
+	line = strings.TrimSpace(line[2:])
+	i := strings.IndexAny(line, " \t")
+	if i == -1 {
+		// No label
+		return nil
+	}
+	label := strings.TrimSpace(line[i:])
+	if len(label) < 10 {
+		return nil
+	}
+	pub, err := time.Parse("2006-01-02", label[:10])
+	if err != nil {
+		return nil
+	}
+	link, err := base.Parse(line[:i])
+	if err != nil {
+		return nil
+	}
+
+	// The date and title are often separated by a dash
+	title := strings.TrimLeft(label[10:], " \t-–—:")
+	if title == "" {
+		title = label[:10]
+	}
+	pub = pub.UTC()
+	return &gofeed.Item{
+		Title:           title,
+		Links:           []string{link.String()},
+		Published:       label[:10],
+		PublishedParsed: &pub,
+	}
+}
@@ -0,0 +1,64 @@
+package subscriptions
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var gemfeedTests = []struct {
+	doc    string
+	ok     bool
+	title  string
+	desc   string
+	titles []string
+	links  []string
+}{
+	{
+		"# My Log\n## Thoughts\n=> one.gmi 2021-03-04 - First post\n=> /two.gmi 2021-03-05 Second\n=> other.gmi Not dated\n",
+		true,
+		"My Log",
+		"Thoughts",
+		[]string{"First post", "Second"},
+		[]string{"gemini://example.com/log/one.gmi", "gemini://example.com/two.gmi"},
+	},
+	{
+		"# Log\n```\n=> one.gmi 2021-03-04 Preformatted\n```\n=>\tgemini://other.com/\t2020-01-02\n",
+		true,
+		"Log",
+		"",
+		[]string{"2020-01-02"},
+		[]string{"gemini://other.com/"},
+	},
+	{"# Just a page\n=> one.gmi One\n=> two.gmi 2021-13-01 Bad date\n", false, "", "", nil, nil},
+}
+
+func TestGetGemfeed(t *testing.T) {
+	for i, tt := range gemfeedTests {
+		feed, ok := GetGemfeed("gemini://example.com/log/", strings.NewReader(tt.doc))
+		if ok != tt.ok {
+			t.Errorf("test %d: got ok %v, want %v", i, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if feed.Title != tt.title || feed.Description != tt.desc {
+			t.Errorf("test %d: got title %q and description %q", i, feed.Title, feed.Description)
+		}
+		if len(feed.Items) != len(tt.titles) {
+			t.Errorf("test %d: got %d items, want %d", i, len(feed.Items), len(tt.titles))
+			continue
+		}
+		for j, item := range feed.Items {
+			if item.Title != tt.titles[j] || item.Links[0] != tt.links[j] {
+				t.Errorf("test %d: got item %q %q", i, item.Title, item.Links[0])
+			}
+		}
+	}
+
+	feed, _ := GetGemfeed("gemini://example.com/", strings.NewReader("=> a.gmi 2021-03-04 A"))
+	if want := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC); !feed.Items[0].PublishedParsed.Equal(want) {
+		t.Errorf("got published time %v, want %v", feed.Items[0].PublishedParsed, want)
+	}
+}
@@ -242,7 +242,7 @@ func AddPage(url string, r io.Reader) error {
 //
 // If there is over 5 redirects the error will be ErrTooManyRedirects.
 // ErrNotSuccess, as well as other fetch errors will also be returned.
-func getResource(url string) (string, *gemini.Response, error) {
+func getResource(url string) (string, *client.Response, error) {
 	res, err := client.Fetch(url)
 	if err != nil {
 		if res != nil {
@@ -339,7 +339,13 @@ func updateFeed(url string) {
 		return
 	}
 	filename := path.Base(newURL)
-	feed, ok := GetFeed(mediatype, filename, res.Body)
+	var feed *gofeed.Feed
+	var ok bool
+	if mediatype == "text/gemini" {
+		feed, ok = GetGemfeed(newURL, res.Body)
+	} else {
+		feed, ok = GetFeed(mediatype, filename, res.Body)
+	}
 	if !ok {
 		return
 	}